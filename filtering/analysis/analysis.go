@@ -0,0 +1,105 @@
+package analysis
+
+import (
+	"go.einride.tech/aip/filtering"
+)
+
+// Result is the result of an analysis, which is unknown when the filters are outside the analyzed subset.
+type Result int
+
+const (
+	// ResultUnknown is the result of an analysis that could not be decided.
+	ResultUnknown Result = iota
+	// ResultTrue is the result of an analysis that holds.
+	ResultTrue
+	// ResultFalse is the result of an analysis that does not hold.
+	ResultFalse
+)
+
+// String returns a string representation of the result.
+func (r Result) String() string {
+	switch r {
+	case ResultTrue:
+		return "true"
+	case ResultFalse:
+		return "false"
+	default:
+		return "unknown"
+	}
+}
+
+// Implies returns ResultTrue if every resource that matches filter a also matches filter b.
+//
+// For example, `shipper = "shippers/1" AND create_time > "2022-01-01T00:00:00Z"` implies `shipper = "shippers/1"`,
+// which can be used to check that a filter is at least as restrictive as a policy filter. An empty filter matches all
+// resources.
+func Implies(a, b filtering.Filter) Result {
+	return implies(analyze(a), analyze(b))
+}
+
+// Equivalent returns ResultTrue if filter a and filter b match the same resources.
+func Equivalent(a, b filtering.Filter) Result {
+	conjunctionA, conjunctionB := analyze(a), analyze(b)
+	aImpliesB, bImpliesA := implies(conjunctionA, conjunctionB), implies(conjunctionB, conjunctionA)
+	switch {
+	case aImpliesB == ResultTrue && bImpliesA == ResultTrue:
+		return ResultTrue
+	case aImpliesB == ResultFalse || bImpliesA == ResultFalse:
+		return ResultFalse
+	default:
+		return ResultUnknown
+	}
+}
+
+// IsUnsatisfiable returns ResultTrue if no resource matches the filter, such as `a = 1 AND a = 2`.
+func IsUnsatisfiable(filter filtering.Filter) Result {
+	c := analyze(filter)
+	switch {
+	case c.isUnsatisfiable():
+		return ResultTrue
+	case len(c.opaque) == 0:
+		return ResultFalse
+	default:
+		return ResultUnknown
+	}
+}
+
+func implies(a, b *conjunction) Result {
+	if a.isUnsatisfiable() {
+		return ResultTrue
+	}
+	if b.isFalse {
+		// A satisfiable filter does not imply an unsatisfiable filter, unless its opaque conjuncts are unsatisfiable.
+		if len(a.opaque) == 0 {
+			return ResultFalse
+		}
+		return ResultUnknown
+	}
+	implied := true
+	for path, bConstraint := range b.constraints {
+		aConstraint, ok := a.constraints[path]
+		if !ok {
+			aConstraint = newFieldConstraint(bConstraint.kind, bConstraint.domain)
+			aConstraint.normalize()
+		}
+		if aConstraint.kind != bConstraint.kind {
+			return ResultUnknown
+		}
+		if !aConstraint.implies(bConstraint) {
+			implied = false
+		}
+	}
+	if !implied {
+		// The analyzed constraints of a admit a resource that does not match b, unless excluded by opaque conjuncts.
+		if len(a.opaque) == 0 {
+			return ResultFalse
+		}
+		return ResultUnknown
+	}
+	for key := range b.opaque {
+		if !a.opaque[key] {
+			return ResultUnknown
+		}
+	}
+	return ResultTrue
+}
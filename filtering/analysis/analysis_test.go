@@ -0,0 +1,138 @@
+package analysis
+
+import (
+	"testing"
+
+	"go.einride.tech/aip/filtering"
+	syntaxv1 "go.einride.tech/aip/proto/gen/einride/example/syntax/v1"
+	"gotest.tools/v3/assert"
+)
+
+func TestImplies(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		a, b     string
+		expected Result
+	}{
+		{a: `shipper = "shippers/1"`, b: ``, expected: ResultTrue},
+		{a: ``, b: `shipper = "shippers/1"`, expected: ResultFalse},
+		{a: `shipper = "shippers/1" AND count > 3`, b: `shipper = "shippers/1"`, expected: ResultTrue},
+		{a: `shipper = "shippers/1"`, b: `shipper = "shippers/2"`, expected: ResultFalse},
+		{a: `shipper = "shippers/1"`, b: `shipper != "shippers/2"`, expected: ResultTrue},
+		{a: `shipper != "shippers/2"`, b: `shipper = "shippers/1"`, expected: ResultFalse},
+		{a: `"shippers/1" = shipper`, b: `shipper = "shippers/1"`, expected: ResultTrue},
+		{a: `count > 3`, b: `count >= 4`, expected: ResultTrue},
+		{a: `count >= 3`, b: `count > 3`, expected: ResultFalse},
+		{a: `count >= 3 AND count != 3`, b: `count > 3`, expected: ResultTrue},
+		{a: `count > 1 AND count < 4`, b: `count = 2 OR count = 3`, expected: ResultTrue},
+		{a: `count > 1 AND count < 5`, b: `count = 2 OR count = 3`, expected: ResultFalse},
+		{a: `3 < count`, b: `count > 2`, expected: ResultTrue},
+		{a: `price > 1.0 AND price < 2.0`, b: `price >= 1.0 AND price != 3.0`, expected: ResultTrue},
+		{a: `price >= 1.0`, b: `price > 1.0`, expected: ResultFalse},
+		{a: `price >= 1.0 AND price != 1.0`, b: `price > 1.0`, expected: ResultTrue},
+		{
+			a:        `create_time > "2022-01-01T00:00:00Z" AND create_time < timestamp("2022-02-01T00:00:00Z")`,
+			b:        `create_time >= "2022-01-01T01:00:00+01:00"`,
+			expected: ResultTrue,
+		},
+		{a: `create_time > "2022-01-01T00:00:00Z"`, b: `create_time > "2022-01-02T00:00:00Z"`, expected: ResultFalse},
+		{a: `enum = ENUM_ONE`, b: `enum = ENUM_ONE OR enum = ENUM_TWO`, expected: ResultTrue},
+		{a: `enum != ENUM_UNSPECIFIED AND enum != ENUM_TWO`, b: `enum = ENUM_ONE`, expected: ResultTrue},
+		{a: `enum != ENUM_UNSPECIFIED`, b: `enum = ENUM_ONE`, expected: ResultFalse},
+		{a: `NOT (enum = ENUM_ONE OR enum = ENUM_TWO)`, b: `enum = ENUM_UNSPECIFIED`, expected: ResultTrue},
+		{a: `deleted AND NOT archived`, b: `deleted`, expected: ResultTrue},
+		{a: `NOT deleted AND NOT archived`, b: `NOT deleted`, expected: ResultTrue},
+		{a: `deleted`, b: `NOT deleted`, expected: ResultFalse},
+		{a: `shipper:"1" AND count > 3`, b: `shipper:"1"`, expected: ResultTrue},
+		{a: `shipper:"1" AND count > 3`, b: `count > 2`, expected: ResultTrue},
+		{a: `shipper:"1"`, b: `shipper:"2"`, expected: ResultUnknown},
+		{a: `shipper:"1"`, b: `count > 2`, expected: ResultUnknown},
+		{a: `shipper = "shippers/*"`, b: `shipper = "shippers/1"`, expected: ResultUnknown},
+		{a: `count > 3 AND count < 2 AND shipper:"1"`, b: `shipper = "shippers/1"`, expected: ResultTrue},
+		{a: `NOT count > 3`, b: `count <= 3`, expected: ResultUnknown},
+	} {
+		tt := tt
+		t.Run(tt.a+" => "+tt.b, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, Implies(parseFilter(t, tt.a), parseFilter(t, tt.b)))
+		})
+	}
+}
+
+func TestEquivalent(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		a, b     string
+		expected Result
+	}{
+		{a: `count > 3`, b: `count >= 4`, expected: ResultTrue},
+		{a: `count > 3 AND shipper = "shippers/1"`, b: `shipper = "shippers/1" AND 3 < count`, expected: ResultTrue},
+		{a: `enum != ENUM_UNSPECIFIED`, b: `enum = ENUM_ONE OR enum = ENUM_TWO`, expected: ResultTrue},
+		{a: `count > 3`, b: `count > 4`, expected: ResultFalse},
+		{a: `shipper:"1" AND count > 3`, b: `count > 3 AND shipper:"1"`, expected: ResultTrue},
+		{a: `shipper:"1"`, b: `shipper:"2"`, expected: ResultUnknown},
+	} {
+		tt := tt
+		t.Run(tt.a+" <=> "+tt.b, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, Equivalent(parseFilter(t, tt.a), parseFilter(t, tt.b)))
+		})
+	}
+}
+
+func TestIsUnsatisfiable(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		filter   string
+		expected Result
+	}{
+		{filter: ``, expected: ResultFalse},
+		{filter: `count = 1 AND count = 2`, expected: ResultTrue},
+		{filter: `count > 1 AND count < 2`, expected: ResultTrue},
+		{filter: `count >= 1 AND count <= 2 AND count != 1 AND count != 2`, expected: ResultTrue},
+		{filter: `count > 9223372036854775807`, expected: ResultTrue},
+		{filter: `price > 1.0 AND price < 2.0`, expected: ResultFalse},
+		{filter: `price >= 1.0 AND price <= 1.0 AND price != 1.0`, expected: ResultTrue},
+		{filter: `create_time > "2022-01-02T00:00:00Z" AND create_time < "2022-01-01T00:00:00Z"`, expected: ResultTrue},
+		{filter: `enum != ENUM_UNSPECIFIED AND enum != ENUM_ONE AND enum != ENUM_TWO`, expected: ResultTrue},
+		{filter: `deleted AND NOT deleted`, expected: ResultTrue},
+		{filter: `(shipper = "a" OR shipper = "b") AND shipper != "a" AND shipper != "b"`, expected: ResultTrue},
+		{filter: `(shipper = "a" OR shipper = "b") AND shipper != "a"`, expected: ResultFalse},
+		{filter: `shipper:"1" AND count = 1 AND count = 2`, expected: ResultTrue},
+		{filter: `shipper:"1" AND count = 1`, expected: ResultUnknown},
+		{filter: `message.repeated_message.int64 = 1 AND message.repeated_message.int64 = 2`, expected: ResultUnknown},
+	} {
+		tt := tt
+		t.Run(tt.filter, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, IsUnsatisfiable(parseFilter(t, tt.filter)))
+		})
+	}
+}
+
+func parseFilter(t *testing.T, filter string) filtering.Filter {
+	t.Helper()
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		filtering.DeclareIdent("shipper", filtering.TypeString),
+		filtering.DeclareIdent("count", filtering.TypeInt),
+		filtering.DeclareIdent("price", filtering.TypeFloat),
+		filtering.DeclareIdent("deleted", filtering.TypeBool),
+		filtering.DeclareIdent("archived", filtering.TypeBool),
+		filtering.DeclareIdent("create_time", filtering.TypeTimestamp),
+		filtering.DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+		filtering.DeclareMessageIdent("message", (&syntaxv1.Message{}).ProtoReflect().Descriptor()),
+	)
+	assert.NilError(t, err)
+	result, err := filtering.ParseFilter(&mockRequest{filter: filter}, declarations)
+	assert.NilError(t, err)
+	return result
+}
+
+type mockRequest struct {
+	filter string
+}
+
+func (m *mockRequest) GetFilter() string {
+	return m.filter
+}
@@ -0,0 +1,373 @@
+package analysis
+
+import (
+	"fmt"
+	"time"
+
+	"go.einride.tech/aip/filtering"
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// conjunction is the analyzed form of a filter, as a conjunction of constraints on fields and opaque conjuncts.
+type conjunction struct {
+	// constraints are the normalized constraints of the fields restricted by the filter, by field path.
+	constraints map[string]*fieldConstraint
+	// opaque is the set of conjuncts outside the analyzed subset, keyed by their unparsed filter syntax.
+	opaque map[string]bool
+	// isFalse is true if the filter has a constant false conjunct.
+	isFalse bool
+}
+
+// isUnsatisfiable returns true if the analyzed constraints can not be satisfied, regardless of the opaque conjuncts.
+func (c *conjunction) isUnsatisfiable() bool {
+	if c.isFalse {
+		return true
+	}
+	for _, constraint := range c.constraints {
+		if !constraint.isSatisfiable() {
+			return true
+		}
+	}
+	return false
+}
+
+// restriction is a restriction of a single field in the analyzed subset.
+type restriction struct {
+	path   string
+	kind   kind
+	domain []interface{}
+	// function is the comparison function of the restriction, where = and != compare against a set of values.
+	function string
+	values   []interface{}
+}
+
+func (r *restriction) fieldConstraint() *fieldConstraint {
+	result := newFieldConstraint(r.kind, r.domain)
+	switch r.function {
+	case filtering.FunctionEquals:
+		result.restrictIn(r.values...)
+	case filtering.FunctionNotEquals:
+		result.restrictNotIn(r.values...)
+	case filtering.FunctionLessThan:
+		result.restrictUpper(r.values[0], false)
+	case filtering.FunctionLessEquals:
+		result.restrictUpper(r.values[0], true)
+	case filtering.FunctionGreaterThan:
+		result.restrictLower(r.values[0], false)
+	case filtering.FunctionGreaterEquals:
+		result.restrictLower(r.values[0], true)
+	}
+	return result
+}
+
+// analyze the provided filter into a conjunction.
+func analyze(filter filtering.Filter) *conjunction {
+	a := analyzer{
+		checkedExpr: filter.CheckedExpr,
+		result: &conjunction{
+			constraints: make(map[string]*fieldConstraint),
+			opaque:      make(map[string]bool),
+		},
+	}
+	if e := filter.CheckedExpr.GetExpr(); e != nil {
+		for _, conjunct := range a.conjuncts(e) {
+			a.addConjunct(conjunct)
+		}
+	}
+	for _, constraint := range a.result.constraints {
+		constraint.normalize()
+	}
+	return a.result
+}
+
+type analyzer struct {
+	checkedExpr *expr.CheckedExpr
+	result      *conjunction
+}
+
+func (a *analyzer) addConjunct(e *expr.Expr) {
+	if value, ok := a.constantValue(e); ok {
+		if b, ok := value.(bool); ok {
+			a.result.isFalse = a.result.isFalse || !b
+			return
+		}
+	}
+	if r, ok := a.restriction(e); ok {
+		if existing, ok := a.result.constraints[r.path]; ok {
+			if existing.kind == r.kind {
+				existing.restrict(r.fieldConstraint())
+				return
+			}
+		} else {
+			a.result.constraints[r.path] = r.fieldConstraint()
+			return
+		}
+	}
+	key, err := filtering.Unparse(e)
+	if err != nil {
+		// Conjuncts that can not be unparsed are distinct from all other conjuncts.
+		key = fmt.Sprintf("\x00%p", e)
+	}
+	a.result.opaque[key] = true
+}
+
+// conjuncts returns the top-level conjuncts of the provided expression.
+func (a *analyzer) conjuncts(e *expr.Expr) []*expr.Expr {
+	call := e.GetCallExpr()
+	switch call.GetFunction() {
+	case filtering.FunctionAnd:
+	case filtering.FunctionFuzzyAnd:
+		if !a.allBool(call.GetArgs()) {
+			return []*expr.Expr{e}
+		}
+	default:
+		return []*expr.Expr{e}
+	}
+	var result []*expr.Expr
+	for _, arg := range call.GetArgs() {
+		result = append(result, a.conjuncts(arg)...)
+	}
+	return result
+}
+
+// restriction returns the restriction of the provided expression, if it is in the analyzed subset.
+func (a *analyzer) restriction(e *expr.Expr) (*restriction, bool) {
+	if path, k, domain, ok := a.field(e); ok && k == kindBool {
+		return &restriction{
+			path:     path,
+			kind:     k,
+			domain:   domain,
+			function: filtering.FunctionEquals,
+			values:   []interface{}{true},
+		}, true
+	}
+	call := e.GetCallExpr()
+	switch function := call.GetFunction(); function {
+	case filtering.FunctionNot:
+		if len(call.GetArgs()) != 1 {
+			return nil, false
+		}
+		return a.negatedRestriction(call.GetArgs()[0])
+	case filtering.FunctionOr:
+		return a.disjunctionRestriction(call.GetArgs())
+	case filtering.FunctionEquals,
+		filtering.FunctionNotEquals,
+		filtering.FunctionLessThan,
+		filtering.FunctionLessEquals,
+		filtering.FunctionGreaterThan,
+		filtering.FunctionGreaterEquals:
+		if len(call.GetArgs()) != 2 || a.isWildcard(e) {
+			return nil, false
+		}
+		lhs, rhs := call.GetArgs()[0], call.GetArgs()[1]
+		path, k, domain, ok := a.field(lhs)
+		if !ok {
+			if path, k, domain, ok = a.field(rhs); !ok {
+				return nil, false
+			}
+			lhs, rhs = rhs, lhs
+			function = flipComparison(function)
+		}
+		if function != filtering.FunctionEquals && function != filtering.FunctionNotEquals && !k.isOrdered() {
+			return nil, false
+		}
+		value, ok := a.value(rhs, k)
+		if !ok {
+			return nil, false
+		}
+		return &restriction{path: path, kind: k, domain: domain, function: function, values: []interface{}{value}}, true
+	}
+	return nil, false
+}
+
+// negatedRestriction returns the negation of the restriction of the provided expression.
+//
+// Only negations of equalities are in the analyzed subset, since ranges are not satisfied by null values.
+func (a *analyzer) negatedRestriction(e *expr.Expr) (*restriction, bool) {
+	r, ok := a.restriction(e)
+	if !ok {
+		return nil, false
+	}
+	switch r.function {
+	case filtering.FunctionEquals:
+		if r.kind == kindBool && len(r.values) == 1 {
+			r.values = []interface{}{!r.values[0].(bool)}
+			return r, true
+		}
+		r.function = filtering.FunctionNotEquals
+		return r, true
+	case filtering.FunctionNotEquals:
+		r.function = filtering.FunctionEquals
+		return r, true
+	}
+	return nil, false
+}
+
+// disjunctionRestriction returns the restriction of a disjunction of equalities on the same field.
+func (a *analyzer) disjunctionRestriction(args []*expr.Expr) (*restriction, bool) {
+	var result *restriction
+	for _, arg := range args {
+		r, ok := a.restriction(arg)
+		if !ok || r.function != filtering.FunctionEquals {
+			return nil, false
+		}
+		if result == nil {
+			result = r
+			continue
+		}
+		if r.path != result.path || r.kind != result.kind {
+			return nil, false
+		}
+		result.values = append(result.values, r.values...)
+	}
+	return result, result != nil
+}
+
+// field returns the path, kind and domain of the provided expression, if it is a field in the analyzed subset.
+func (a *analyzer) field(e *expr.Expr) (string, kind, []interface{}, bool) {
+	reference := a.checkedExpr.GetReferenceMap()[e.GetId()]
+	if reference.GetValue() != nil || a.isProjection(e) {
+		return "", 0, nil, false
+	}
+	path := reference.GetName()
+	if path == "" {
+		var ok bool
+		if path, ok = filtering.QualifiedName(e); !ok {
+			return "", 0, nil, false
+		}
+	}
+	t := a.checkedExpr.GetTypeMap()[e.GetId()]
+	switch t.GetPrimitive() {
+	case expr.Type_INT64:
+		return path, kindInt, nil, true
+	case expr.Type_DOUBLE:
+		return path, kindFloat, nil, true
+	case expr.Type_STRING:
+		return path, kindString, nil, true
+	case expr.Type_BOOL:
+		return path, kindBool, []interface{}{false, true}, true
+	}
+	if t.GetWellKnown() == expr.Type_TIMESTAMP {
+		return path, kindTimestamp, nil, true
+	}
+	if t.GetMessageType() != "" {
+		enumType, err := protoregistry.GlobalTypes.FindEnumByName(protoreflect.FullName(t.GetMessageType()))
+		if err != nil {
+			return "", 0, nil, false
+		}
+		values := enumType.Descriptor().Values()
+		domain := make([]interface{}, 0, values.Len())
+		for i := 0; i < values.Len(); i++ {
+			domain = append(domain, string(values.Get(i).Name()))
+		}
+		return path, kindEnum, domain, true
+	}
+	return "", 0, nil, false
+}
+
+// value returns the constant value of the provided expression, as a value of the provided kind.
+func (a *analyzer) value(e *expr.Expr, k kind) (interface{}, bool) {
+	if call := e.GetCallExpr(); call.GetFunction() == filtering.FunctionTimestamp && len(call.GetArgs()) == 1 {
+		e = call.GetArgs()[0]
+	}
+	value, ok := a.constantValue(e)
+	if !ok {
+		return nil, false
+	}
+	switch value := value.(type) {
+	case int64:
+		switch k {
+		case kindInt:
+			return value, true
+		case kindFloat:
+			return float64(value), true
+		}
+	case float64:
+		if k == kindFloat {
+			return value, true
+		}
+	case bool:
+		if k == kindBool {
+			return value, true
+		}
+	case string:
+		switch k {
+		case kindString, kindEnum:
+			return value, true
+		case kindTimestamp:
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, false
+			}
+			return t.UTC(), true
+		}
+	}
+	return nil, false
+}
+
+// constantValue returns the value of the provided constant expression or reference to a declared constant.
+func (a *analyzer) constantValue(e *expr.Expr) (interface{}, bool) {
+	constant := e.GetConstExpr()
+	if constant == nil {
+		constant = a.checkedExpr.GetReferenceMap()[e.GetId()].GetValue()
+	}
+	switch kind := constant.GetConstantKind().(type) {
+	case *expr.Constant_BoolValue:
+		return kind.BoolValue, true
+	case *expr.Constant_Int64Value:
+		return kind.Int64Value, true
+	case *expr.Constant_DoubleValue:
+		return kind.DoubleValue, true
+	case *expr.Constant_StringValue:
+		return kind.StringValue, true
+	}
+	return nil, false
+}
+
+func (a *analyzer) allBool(args []*expr.Expr) bool {
+	for _, arg := range args {
+		if a.checkedExpr.GetTypeMap()[arg.GetId()].GetPrimitive() != expr.Type_BOOL {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *analyzer) isWildcard(e *expr.Expr) bool {
+	for _, overloadID := range a.checkedExpr.GetReferenceMap()[e.GetId()].GetOverloadId() {
+		switch overloadID {
+		case filtering.FunctionOverloadEqualsStringWildcard, filtering.FunctionOverloadNotEqualsStringWildcard:
+			return true
+		}
+	}
+	return false
+}
+
+// flipComparison returns the comparison function with swapped operands.
+func flipComparison(function string) string {
+	switch function {
+	case filtering.FunctionLessThan:
+		return filtering.FunctionGreaterThan
+	case filtering.FunctionLessEquals:
+		return filtering.FunctionGreaterEquals
+	case filtering.FunctionGreaterThan:
+		return filtering.FunctionLessThan
+	case filtering.FunctionGreaterEquals:
+		return filtering.FunctionLessEquals
+	default:
+		return function
+	}
+}
+
+// isProjection returns true if the provided expression selects a field of the elements of a repeated message field,
+// such as `line_items.title`. Restrictions on projections hold for any element, and are not in the analyzed subset.
+func (a *analyzer) isProjection(e *expr.Expr) bool {
+	for operand := e.GetSelectExpr().GetOperand(); operand != nil; operand = operand.GetSelectExpr().GetOperand() {
+		if a.checkedExpr.GetTypeMap()[operand.GetId()].GetListType() != nil {
+			return true
+		}
+	}
+	return false
+}
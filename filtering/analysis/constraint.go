@@ -0,0 +1,317 @@
+package analysis
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// kind is the kind of values of a constrained field.
+type kind int
+
+const (
+	kindInt kind = iota
+	kindFloat
+	kindTimestamp
+	kindString
+	kindBool
+	kindEnum
+)
+
+// isOrdered returns true if ranges are supported for values of the kind.
+func (k kind) isOrdered() bool {
+	switch k {
+	case kindInt, kindFloat, kindTimestamp:
+		return true
+	}
+	return false
+}
+
+// maxEnumeratedRange is the max size of int ranges that are enumerated when checking implication of value sets.
+const maxEnumeratedRange = 1024
+
+// bound is a lower or upper bound of a range.
+type bound struct {
+	value     interface{}
+	inclusive bool
+}
+
+// fieldConstraint is a conjunction of restrictions on the values of a single field.
+//
+// Values are int64, float64, time.Time, string or bool, where enum values are represented by their value names.
+type fieldConstraint struct {
+	kind kind
+	// domain is the finite set of all values of the kind, or nil if the domain is infinite.
+	domain []interface{}
+	// in is the set of allowed values, or nil if all values in the range are allowed.
+	in map[interface{}]bool
+	// notIn is the set of excluded values.
+	notIn map[interface{}]bool
+	lower *bound
+	upper *bound
+	// empty is true if no values satisfy the bounds, such as int bounds beyond the int range.
+	empty bool
+}
+
+func newFieldConstraint(k kind, domain []interface{}) *fieldConstraint {
+	c := &fieldConstraint{kind: k, domain: domain}
+	if domain != nil {
+		c.in = make(map[interface{}]bool, len(domain))
+		for _, value := range domain {
+			c.in[value] = true
+		}
+	}
+	return c
+}
+
+// restrictIn restricts the field to the provided set of values.
+func (c *fieldConstraint) restrictIn(values ...interface{}) {
+	restricted := make(map[interface{}]bool, len(values))
+	for _, value := range values {
+		if c.in == nil || c.in[value] {
+			restricted[value] = true
+		}
+	}
+	c.in = restricted
+}
+
+// restrictNotIn excludes the provided values.
+func (c *fieldConstraint) restrictNotIn(values ...interface{}) {
+	if c.notIn == nil {
+		c.notIn = make(map[interface{}]bool, len(values))
+	}
+	for _, value := range values {
+		c.notIn[value] = true
+	}
+}
+
+// restrictLower restricts the range to values above the provided bound.
+func (c *fieldConstraint) restrictLower(value interface{}, inclusive bool) {
+	if c.lower == nil {
+		c.lower = &bound{value: value, inclusive: inclusive}
+		return
+	}
+	if cmp := compareValues(value, c.lower.value); cmp > 0 || cmp == 0 && !inclusive {
+		c.lower = &bound{value: value, inclusive: inclusive}
+	}
+}
+
+// restrictUpper restricts the range to values below the provided bound.
+func (c *fieldConstraint) restrictUpper(value interface{}, inclusive bool) {
+	if c.upper == nil {
+		c.upper = &bound{value: value, inclusive: inclusive}
+		return
+	}
+	if cmp := compareValues(value, c.upper.value); cmp < 0 || cmp == 0 && !inclusive {
+		c.upper = &bound{value: value, inclusive: inclusive}
+	}
+}
+
+// restrict restricts the constraint by all restrictions of the provided constraint.
+func (c *fieldConstraint) restrict(other *fieldConstraint) {
+	if other.in != nil {
+		values := make([]interface{}, 0, len(other.in))
+		for value := range other.in {
+			values = append(values, value)
+		}
+		c.restrictIn(values...)
+	}
+	for value := range other.notIn {
+		c.restrictNotIn(value)
+	}
+	if other.lower != nil {
+		c.restrictLower(other.lower.value, other.lower.inclusive)
+	}
+	if other.upper != nil {
+		c.restrictUpper(other.upper.value, other.upper.inclusive)
+	}
+}
+
+// normalize the constraint, such that every bound is the tightest bound of the allowed values.
+//
+// After normalization, int bounds are inclusive, bounds are not excluded, and value sets only contain allowed
+// values and have no bounds or excluded values.
+func (c *fieldConstraint) normalize() {
+	if c.in != nil {
+		for value := range c.in {
+			if !c.admits(value) {
+				delete(c.in, value)
+			}
+		}
+		c.notIn, c.lower, c.upper = nil, nil, nil
+		return
+	}
+	if c.kind == kindInt {
+		if c.lower != nil && !c.lower.inclusive {
+			c.lower = c.intBound(c.lower.value.(int64), 1)
+		}
+		if c.upper != nil && !c.upper.inclusive {
+			c.upper = c.intBound(c.upper.value.(int64), -1)
+		}
+	}
+	for !c.empty && c.lower != nil && c.lower.inclusive && c.notIn[c.lower.value] {
+		if c.kind == kindInt {
+			c.lower = c.intBound(c.lower.value.(int64), 1)
+		} else {
+			c.lower = &bound{value: c.lower.value, inclusive: false}
+		}
+	}
+	for !c.empty && c.upper != nil && c.upper.inclusive && c.notIn[c.upper.value] {
+		if c.kind == kindInt {
+			c.upper = c.intBound(c.upper.value.(int64), -1)
+		} else {
+			c.upper = &bound{value: c.upper.value, inclusive: false}
+		}
+	}
+	if c.empty {
+		c.restrictIn()
+		c.notIn, c.lower, c.upper = nil, nil, nil
+		return
+	}
+	if c.lower != nil && c.upper != nil && c.lower.inclusive && c.upper.inclusive &&
+		compareValues(c.lower.value, c.upper.value) == 0 {
+		c.restrictIn(c.lower.value)
+		c.notIn, c.lower, c.upper = nil, nil, nil
+	}
+}
+
+// intBound returns an inclusive bound of the provided int value moved by the provided delta, where overflows
+// make the constraint empty.
+func (c *fieldConstraint) intBound(value, delta int64) *bound {
+	if delta > 0 && value == math.MaxInt64 || delta < 0 && value == math.MinInt64 {
+		c.empty = true
+		return &bound{value: value, inclusive: true}
+	}
+	return &bound{value: value + delta, inclusive: true}
+}
+
+// isSatisfiable returns true if any value satisfies the normalized constraint.
+func (c *fieldConstraint) isSatisfiable() bool {
+	if c.in != nil {
+		return len(c.in) > 0
+	}
+	if c.lower == nil || c.upper == nil {
+		return true
+	}
+	return compareValues(c.lower.value, c.upper.value) < 0
+}
+
+// admits returns true if the provided value satisfies the constraint.
+func (c *fieldConstraint) admits(value interface{}) bool {
+	if c.in != nil && !c.in[value] || c.notIn[value] {
+		return false
+	}
+	if c.lower != nil {
+		if cmp := compareValues(value, c.lower.value); cmp < 0 || cmp == 0 && !c.lower.inclusive {
+			return false
+		}
+	}
+	if c.upper != nil {
+		if cmp := compareValues(value, c.upper.value); cmp > 0 || cmp == 0 && !c.upper.inclusive {
+			return false
+		}
+	}
+	return true
+}
+
+// values returns the allowed values of the normalized constraint, if they are a small finite set.
+func (c *fieldConstraint) values() ([]interface{}, bool) {
+	if c.in != nil {
+		result := make([]interface{}, 0, len(c.in))
+		for value := range c.in {
+			result = append(result, value)
+		}
+		return result, true
+	}
+	if c.kind != kindInt || c.lower == nil || c.upper == nil {
+		return nil, false
+	}
+	lower, upper := c.lower.value.(int64), c.upper.value.(int64)
+	if lower > upper || uint64(upper-lower) >= maxEnumeratedRange {
+		return nil, false
+	}
+	var result []interface{}
+	for value := lower; value <= upper; value++ {
+		if !c.notIn[value] {
+			result = append(result, value)
+		}
+	}
+	return result, true
+}
+
+// implies returns true if all values that satisfy the normalized constraint satisfy the other normalized constraint.
+func (c *fieldConstraint) implies(other *fieldConstraint) bool {
+	if !c.isSatisfiable() {
+		return true
+	}
+	if values, ok := c.values(); ok {
+		for _, value := range values {
+			if !other.admits(value) {
+				return false
+			}
+		}
+		return true
+	}
+	if other.in != nil {
+		return false
+	}
+	for value := range other.notIn {
+		if c.admits(value) {
+			return false
+		}
+	}
+	if other.lower != nil {
+		if c.lower == nil {
+			return false
+		}
+		if cmp := compareValues(c.lower.value, other.lower.value); cmp < 0 ||
+			cmp == 0 && c.lower.inclusive && !other.lower.inclusive {
+			return false
+		}
+	}
+	if other.upper != nil {
+		if c.upper == nil {
+			return false
+		}
+		if cmp := compareValues(c.upper.value, other.upper.value); cmp > 0 ||
+			cmp == 0 && c.upper.inclusive && !other.upper.inclusive {
+			return false
+		}
+	}
+	return true
+}
+
+// compareValues compares two values of the same kind.
+func compareValues(lhs, rhs interface{}) int {
+	switch lhs := lhs.(type) {
+	case int64:
+		return compareOrdered(lhs, rhs.(int64))
+	case float64:
+		return compareOrdered(lhs, rhs.(float64))
+	case string:
+		return strings.Compare(lhs, rhs.(string))
+	case time.Time:
+		return lhs.Compare(rhs.(time.Time))
+	case bool:
+		if lhs == rhs.(bool) {
+			return 0
+		}
+		if rhs.(bool) {
+			return -1
+		}
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareOrdered[T int64 | float64](lhs, rhs T) int {
+	switch {
+	case lhs < rhs:
+		return -1
+	case lhs > rhs:
+		return 1
+	default:
+		return 0
+	}
+}
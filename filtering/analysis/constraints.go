@@ -0,0 +1,152 @@
+package analysis
+
+import (
+	"fmt"
+
+	"go.einride.tech/aip/filtering"
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Constraint is a constraint on a single field, extracted from the top-level conjunction of a filter.
+//
+// Values are int64, float64, time.Time, string or bool, where enum values are represented by their value names.
+// A value satisfies the constraint if it is one of the values, unless nil, and is within the bounds.
+type Constraint struct {
+	// Path is the path of the constrained field, such as "parent" or "origin.display_name".
+	Path string
+	// Values are the allowed values of the field, from equalities and disjunctions of equalities.
+	// Values is nil if the constraint has no equalities, and empty if the equalities are contradictory.
+	Values []interface{}
+	// Lower is the lower bound of the field, if any.
+	Lower *Bound
+	// Upper is the upper bound of the field, if any.
+	Upper *Bound
+}
+
+// Bound is a lower or upper bound of a Constraint.
+type Bound struct {
+	// Value of the bound.
+	Value interface{}
+	// Inclusive is true if the bound value satisfies the constraint.
+	Inclusive bool
+}
+
+// IsEquality returns true if the constraint is an equality on a single value.
+func (c *Constraint) IsEquality() bool {
+	return len(c.Values) == 1
+}
+
+// IsBoundedRange returns true if the constraint has both a lower and an upper bound.
+func (c *Constraint) IsBoundedRange() bool {
+	return c.Lower != nil && c.Upper != nil
+}
+
+// ExtractConstraints extracts the constraints on the fields with the provided paths from the top-level conjunction of
+// the filter, and returns the residual filter of all other conjuncts, type-checked against the provided declarations.
+//
+// The extracted conjuncts are equalities, disjunctions of equalities on the same field, and ranges on int, float and
+// timestamp fields. A resource matches the filter if its fields satisfy all the constraints and it matches the
+// residual filter. The constraints are returned in the order of the provided paths, and the residual filter is empty
+// if all conjuncts are extracted.
+//
+// The provided filter is not modified.
+func ExtractConstraints(
+	filter filtering.Filter,
+	declarations *filtering.Declarations,
+	paths ...string,
+) ([]Constraint, filtering.Filter, error) {
+	if filter.CheckedExpr.GetExpr() == nil {
+		return nil, filter, nil
+	}
+	checkedExpr := proto.Clone(filter.CheckedExpr).(*expr.CheckedExpr)
+	a := analyzer{checkedExpr: checkedExpr}
+	indexed := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		indexed[path] = true
+	}
+	constraints := make(map[string]*Constraint, len(paths))
+	var residual []*expr.Expr
+	for _, conjunct := range a.conjuncts(checkedExpr.GetExpr()) {
+		r, ok := a.restriction(conjunct)
+		if !ok || !indexed[r.path] || r.function == filtering.FunctionNotEquals {
+			residual = append(residual, conjunct)
+			continue
+		}
+		constraint, ok := constraints[r.path]
+		if !ok {
+			constraint = &Constraint{Path: r.path}
+			constraints[r.path] = constraint
+		}
+		constraint.restrict(r)
+	}
+	result := make([]Constraint, 0, len(constraints))
+	for _, path := range paths {
+		if constraint, ok := constraints[path]; ok {
+			result = append(result, *constraint)
+		}
+	}
+	if len(residual) == 0 {
+		return result, filtering.Filter{}, nil
+	}
+	nextID := filtering.MaxID(checkedExpr.GetExpr()) + 1
+	residualExpr := residual[0]
+	for _, conjunct := range residual[1:] {
+		residualExpr = filtering.And(residualExpr, conjunct)
+		residualExpr.Id = nextID
+		nextID++
+	}
+	var checker filtering.Checker
+	checker.Init(residualExpr, checkedExpr.GetSourceInfo(), declarations)
+	residualCheckedExpr, err := checker.Check()
+	if err != nil {
+		return nil, filtering.Filter{}, fmt.Errorf("extract constraints: %w", err)
+	}
+	return result, filtering.Filter{CheckedExpr: residualCheckedExpr, Warnings: filter.Warnings}, nil
+}
+
+// restrict the constraint by the provided restriction.
+func (c *Constraint) restrict(r *restriction) {
+	switch r.function {
+	case filtering.FunctionEquals:
+		if c.Values == nil {
+			c.Values = make([]interface{}, 0, len(r.values))
+			for _, value := range r.values {
+				if !containsValue(c.Values, value) {
+					c.Values = append(c.Values, value)
+				}
+			}
+			return
+		}
+		values := c.Values[:0]
+		for _, value := range c.Values {
+			if containsValue(r.values, value) {
+				values = append(values, value)
+			}
+		}
+		c.Values = values
+	case filtering.FunctionLessThan, filtering.FunctionLessEquals:
+		value, inclusive := r.values[0], r.function == filtering.FunctionLessEquals
+		if c.Upper == nil {
+			c.Upper = &Bound{Value: value, Inclusive: inclusive}
+		} else if cmp := compareValues(value, c.Upper.Value); cmp < 0 || cmp == 0 && !inclusive {
+			c.Upper = &Bound{Value: value, Inclusive: inclusive}
+		}
+	case filtering.FunctionGreaterThan, filtering.FunctionGreaterEquals:
+		value, inclusive := r.values[0], r.function == filtering.FunctionGreaterEquals
+		if c.Lower == nil {
+			c.Lower = &Bound{Value: value, Inclusive: inclusive}
+		} else if cmp := compareValues(value, c.Lower.Value); cmp > 0 || cmp == 0 && !inclusive {
+			c.Lower = &Bound{Value: value, Inclusive: inclusive}
+		}
+	}
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
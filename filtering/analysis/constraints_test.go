@@ -0,0 +1,105 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"go.einride.tech/aip/filtering"
+	syntaxv1 "go.einride.tech/aip/proto/gen/einride/example/syntax/v1"
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"gotest.tools/v3/assert"
+)
+
+func TestExtractConstraints(t *testing.T) {
+	t.Parallel()
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		filtering.DeclareIdent("parent", filtering.TypeString),
+		filtering.DeclareIdent("shipper", filtering.TypeString),
+		filtering.DeclareIdent("count", filtering.TypeInt),
+		filtering.DeclareIdent("deleted", filtering.TypeBool),
+		filtering.DeclareIdent("create_time", filtering.TypeTimestamp),
+		filtering.DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+	)
+	assert.NilError(t, err)
+	paths := []string{"parent", "enum", "create_time", "deleted"}
+	for _, tt := range []struct {
+		filter              string
+		expectedConstraints []Constraint
+		expectedResidual    string
+	}{
+		{
+			filter:              `shipper = "shippers/1"`,
+			expectedConstraints: []Constraint{},
+			expectedResidual:    `shipper = "shippers/1"`,
+		},
+		{
+			filter: `parent = "shippers/1" AND shipper:"foo"`,
+			expectedConstraints: []Constraint{
+				{Path: "parent", Values: []interface{}{"shippers/1"}},
+			},
+			expectedResidual: `shipper:"foo"`,
+		},
+		{
+			filter: `shipper:"foo" AND enum = ENUM_ONE OR enum = ENUM_TWO AND count > 3 AND NOT deleted`,
+			expectedConstraints: []Constraint{
+				{Path: "enum", Values: []interface{}{"ENUM_ONE", "ENUM_TWO"}},
+				{Path: "deleted", Values: []interface{}{false}},
+			},
+			expectedResidual: `shipper:"foo" AND count > 3`,
+		},
+		{
+			filter: `create_time >= "2022-01-01T00:00:00Z" AND create_time < "2022-02-01T00:00:00Z" AND ` +
+				`create_time > "2022-01-15T00:00:00Z" AND parent = "shippers/1"`,
+			expectedConstraints: []Constraint{
+				{Path: "parent", Values: []interface{}{"shippers/1"}},
+				{
+					Path:  "create_time",
+					Lower: &Bound{Value: time.Date(2022, 1, 15, 0, 0, 0, 0, time.UTC)},
+					Upper: &Bound{Value: time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)},
+				},
+			},
+		},
+		{
+			filter:              `parent != "shippers/1" AND parent = "shippers/2" OR shipper = "shippers/3"`,
+			expectedConstraints: []Constraint{},
+			expectedResidual:    `parent != "shippers/1" AND parent = "shippers/2" OR shipper = "shippers/3"`,
+		},
+		{
+			filter: `enum = ENUM_ONE OR enum = ENUM_TWO AND enum = ENUM_TWO`,
+			expectedConstraints: []Constraint{
+				{Path: "enum", Values: []interface{}{"ENUM_TWO"}},
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.filter, func(t *testing.T) {
+			t.Parallel()
+			filter, err := filtering.ParseFilter(&mockRequest{filter: tt.filter}, declarations)
+			assert.NilError(t, err)
+			constraints, residual, err := ExtractConstraints(filter, declarations, paths...)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tt.expectedConstraints, constraints)
+			if tt.expectedResidual == "" {
+				assert.Assert(t, residual.CheckedExpr == nil)
+				return
+			}
+			actualResidual, err := filtering.Unparse(residual.CheckedExpr.GetExpr())
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expectedResidual, actualResidual)
+			assert.Equal(t, len(residual.CheckedExpr.GetTypeMap()), countExprs(residual.CheckedExpr.GetExpr()))
+			original, err := filtering.Unparse(filter.CheckedExpr.GetExpr())
+			assert.NilError(t, err)
+			assert.Equal(t, tt.filter, original)
+		})
+	}
+}
+
+func countExprs(e *expr.Expr) int {
+	var result int
+	filtering.Walk(func(_, _ *expr.Expr) bool {
+		result++
+		return true
+	}, e)
+	return result
+}
@@ -0,0 +1,9 @@
+// Package analysis provides static analysis of type-checked AIP filters, such as implication and satisfiability.
+//
+// The analysis is exact for the conjunctive subset of the filter syntax: AND of comparisons between fields and
+// constants, where equality is supported for all scalar types, ranges for ints, floats and timestamps, and OR of
+// equalities on the same field for sets of values. Restrictions outside the subset are treated as opaque, which can
+// make the result of an analysis unknown.
+//
+// See: https://google.aip.dev/160 (Filtering)
+package analysis
@@ -2,10 +2,13 @@ package filtering
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 type Checker struct {
@@ -13,17 +16,45 @@ type Checker struct {
 	expr         *expr.Expr
 	sourceInfo   *expr.SourceInfo
 	typeMap      map[int64]*expr.Type
+	referenceMap map[int64]*expr.Reference
+	warnings     []Warning
+	// filter is the source of the expression, if known, for error and warning positions.
+	filter string
+	// nextID is the next ID for exprs created by the checker, or zero if none have been created.
+	nextID int64
 }
 
-func (c *Checker) Init(exp *expr.Expr, sourceInfo *expr.SourceInfo, declarations *Declarations) {
+// CheckerOption configures a Checker.
+type CheckerOption func(*Checker)
+
+// WithFilter is a CheckerOption that provides the source filter of the checked expression, for the positions and
+// spans of errors and warnings.
+//
+// Without the source filter, positions have byte columns and spans only cover the first token of the expression.
+func WithFilter(filter string) CheckerOption {
+	return func(checker *Checker) {
+		checker.filter = filter
+	}
+}
+
+func (c *Checker) Init(exp *expr.Expr, sourceInfo *expr.SourceInfo, declarations *Declarations, opts ...CheckerOption) {
 	*c = Checker{
 		expr:         exp,
 		declarations: declarations,
 		sourceInfo:   sourceInfo,
 		typeMap:      make(map[int64]*expr.Type, len(sourceInfo.GetPositions())),
+		referenceMap: make(map[int64]*expr.Reference),
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
 }
 
+// Check type-checks the expression and returns the checked expression.
+//
+// The expression is normalized in place: aliases are rewritten to their targets, and receiver-style calls such as
+// `s.matches("^h")` are rewritten to calls with the receiver as first arg, as in `matches(s, "^h")`. The original
+// expressions are recorded as macro calls in the source info.
 func (c *Checker) Check() (*expr.CheckedExpr, error) {
 	if err := c.checkExpr(c.expr); err != nil {
 		return nil, err
@@ -36,12 +67,18 @@ func (c *Checker) Check() (*expr.CheckedExpr, error) {
 		return nil, c.errorf(c.expr, "non-bool result type")
 	}
 	return &expr.CheckedExpr{
-		TypeMap:    c.typeMap,
-		SourceInfo: c.sourceInfo,
-		Expr:       c.expr,
+		TypeMap:      c.typeMap,
+		ReferenceMap: c.referenceMap,
+		SourceInfo:   c.sourceInfo,
+		Expr:         c.expr,
 	}, nil
 }
 
+// Warnings returns the warnings from the last call to Check, such as usages of deprecated identifiers.
+func (c *Checker) Warnings() []Warning {
+	return c.warnings
+}
+
 func (c *Checker) checkExpr(e *expr.Expr) error {
 	if e == nil {
 		return nil
@@ -57,6 +94,8 @@ func (c *Checker) checkExpr(e *expr.Expr) error {
 			return c.checkInt64Literal(e)
 		case *expr.Constant_StringValue:
 			return c.checkStringLiteral(e)
+		case *expr.Constant_NullValue:
+			return c.checkNullLiteral(e)
 		default:
 			return c.errorf(e, "unsupported constant kind")
 		}
@@ -73,13 +112,32 @@ func (c *Checker) checkExpr(e *expr.Expr) error {
 
 func (c *Checker) checkIdentExpr(e *expr.Expr) error {
 	identExpr := e.GetIdentExpr()
+	c.checkDeprecation(e, identExpr.GetName())
+	if target, ok := c.declarations.resolveAlias(identExpr.GetName()); ok {
+		ident, ok := c.declarations.LookupIdent(target)
+		if !ok {
+			return c.errorf(e, "undeclared identifier '%s' (alias of '%s')", target, identExpr.GetName())
+		}
+		c.rewriteIdent(e, target)
+		if err := c.setType(e, ident.GetIdent().GetType()); err != nil {
+			return c.wrapf(err, e, "identifier '%s'", target)
+		}
+		c.setReference(e, ident)
+		return nil
+	}
 	ident, ok := c.declarations.LookupIdent(identExpr.GetName())
 	if !ok {
 		return c.errorf(e, "undeclared identifier '%s'", identExpr.GetName())
 	}
+	if constant, ok := literal(ident); ok {
+		// The standard true, false and null constants are literals, as in `archived = false` and `weight = null`.
+		e.ExprKind = &expr.Expr_ConstExpr{ConstExpr: constant}
+		return c.checkExpr(e)
+	}
 	if err := c.setType(e, ident.GetIdent().GetType()); err != nil {
 		return c.wrapf(err, e, "identifier '%s'", identExpr.GetName())
 	}
+	c.setReference(e, ident)
 	return nil
 }
 
@@ -89,10 +147,20 @@ func (c *Checker) checkSelectExpr(e *expr.Expr) (err error) {
 			err = c.wrapf(err, e, "check select expr")
 		}
 	}()
-	if qualifiedName, ok := toQualifiedName(e); ok {
+	if qualifiedName, ok := QualifiedName(e); ok {
 		if ident, ok := c.declarations.LookupIdent(qualifiedName); ok {
+			c.checkDeprecation(e, qualifiedName)
+			c.setReference(e, ident)
 			return c.setType(e, ident.GetIdent().GetType())
 		}
+		if target, ok := c.declarations.resolveAlias(qualifiedName); ok {
+			if ident, ok := c.declarations.LookupIdent(target); ok {
+				c.checkDeprecation(e, qualifiedName)
+				c.rewriteIdent(e, target)
+				c.setReference(e, ident)
+				return c.setType(e, ident.GetIdent().GetType())
+			}
+		}
 	}
 	selectExpr := e.GetSelectExpr()
 	if selectExpr.GetOperand() == nil {
@@ -108,19 +176,58 @@ func (c *Checker) checkSelectExpr(e *expr.Expr) (err error) {
 	switch operandType.GetTypeKind().(type) {
 	case *expr.Type_MapType_:
 		return c.setType(e, operandType.GetMapType().GetValueType())
+	case *expr.Type_Dyn:
+		// Values selected from dyn values, such as nested values of google.protobuf.Struct fields, are dyn.
+		return c.setType(e, TypeDyn)
+	case *expr.Type_MessageType:
+		return c.checkSelectField(e, operandType.GetMessageType())
+	case *expr.Type_ListType_:
+		// Fields of repeated message fields are selected from each element, as in `line_items.sku = "ABC"`, which is
+		// true if any line item has the SKU. The type of the select is the type of the field of an element.
+		if messageType := operandType.GetListType().GetElemType().GetMessageType(); messageType != "" {
+			return c.checkSelectField(e, messageType)
+		}
+		return c.errorf(e, "unsupported select of '%s' from list", selectExpr.GetField())
 	default:
 		return c.errorf(e, "unsupported operand type")
 	}
 }
 
+// checkSelectField checks the select of a field of a message of the provided type.
+func (c *Checker) checkSelectField(e *expr.Expr, messageType string) error {
+	message, ok := c.declarations.resolveMessage(messageType)
+	if !ok {
+		return c.errorf(e, "unsupported operand type %s", messageType)
+	}
+	selectExpr := e.GetSelectExpr()
+	field := message.Fields().ByName(protoreflect.Name(selectExpr.GetField()))
+	if field == nil {
+		return c.errorf(e, "no field '%s' in message %s", selectExpr.GetField(), message.FullName())
+	}
+	fieldType, ok := fieldType(field)
+	if !ok {
+		return c.errorf(e, "unsupported type of field '%s' in message %s", field.Name(), message.FullName())
+	}
+	return c.setType(e, fieldType)
+}
+
 func (c *Checker) checkCallExpr(e *expr.Expr) (err error) {
 	defer func() {
 		if err != nil {
 			err = c.wrapf(err, e, "check call expr")
 		}
 	}()
+	c.rewriteReceiverCall(e)
 	callExpr := e.GetCallExpr()
-	for _, arg := range callExpr.GetArgs() {
+	for i, arg := range callExpr.GetArgs() {
+		if callExpr.GetFunction() == FunctionHas && i == 1 && isPresenceWildcard(arg) {
+			// The unquoted wildcard of presence checks is a string constant, like the values of enum constants.
+			c.referenceMap[arg.GetId()] = &expr.Reference{Value: NewStringConstant(PresenceWildcard)}
+			if err := c.setType(arg, TypeString); err != nil {
+				return err
+			}
+			continue
+		}
 		if err := c.checkExpr(arg); err != nil {
 			return err
 		}
@@ -129,6 +236,12 @@ func (c *Checker) checkCallExpr(e *expr.Expr) (err error) {
 	if !ok {
 		return c.errorf(e, "undeclared function '%s'", callExpr.GetFunction())
 	}
+	if overloadID, ok := c.resolveCallExprPresenceOverload(e); ok {
+		c.referenceMap[e.GetId()] = &expr.Reference{
+			OverloadId: []string{overloadID},
+		}
+		return c.setType(e, TypeBool)
+	}
 	functionOverload, err := c.resolveCallExprFunctionOverload(e, functionDeclaration)
 	if err != nil {
 		return err
@@ -136,6 +249,10 @@ func (c *Checker) checkCallExpr(e *expr.Expr) (err error) {
 	if err := c.checkCallExprBuiltinFunctionOverloads(e, functionOverload); err != nil {
 		return err
 	}
+	functionOverload = c.resolveCallExprWildcardOverload(e, functionOverload)
+	c.referenceMap[e.GetId()] = &expr.Reference{
+		OverloadId: []string{functionOverload.GetOverloadId()},
+	}
 	return c.setType(e, functionOverload.GetResultType())
 }
 
@@ -144,38 +261,168 @@ func (c *Checker) resolveCallExprFunctionOverload(
 	functionDeclaration *expr.Decl,
 ) (*expr.Decl_FunctionDecl_Overload, error) {
 	callExpr := e.GetCallExpr()
+	argTypes := make([]*expr.Type, 0, len(callExpr.GetArgs()))
+	for _, arg := range callExpr.GetArgs() {
+		argType, ok := c.getType(arg)
+		if !ok {
+			return nil, c.errorf(arg, "unknown type")
+		}
+		argTypes = append(argTypes, argType)
+	}
 	for _, overload := range functionDeclaration.GetFunction().GetOverloads() {
 		if len(callExpr.GetArgs()) != len(overload.GetParams()) {
 			continue
 		}
-		if len(overload.GetTypeParams()) == 0 {
-			allTypesMatch := true
-			for i, param := range overload.GetParams() {
-				argType, ok := c.getType(callExpr.GetArgs()[i])
-				if !ok {
-					return nil, c.errorf(callExpr.GetArgs()[i], "unknown type")
-				}
-				if !proto.Equal(argType, param) {
-					allTypesMatch = false
-					break
-				}
+		bindings := make(map[string]*expr.Type, len(overload.GetTypeParams()))
+		retypedArgs := map[int]*expr.Type{}
+		allTypesMatch := true
+		for i, param := range overload.GetParams() {
+			if unifyType(param, argTypes[i], overload.GetTypeParams(), bindings) {
+				continue
 			}
-			if allTypesMatch {
-				return overload, nil
+			// Enum values used as args to the has operator are parsed as strings.
+			if callExpr.GetFunction() != FunctionHas {
+				allTypesMatch = false
+				break
 			}
+			if constantType, ok := c.lookupConstantType(callExpr.GetArgs()[i]); ok &&
+				unifyType(param, constantType, overload.GetTypeParams(), bindings) {
+				retypedArgs[i] = constantType
+				continue
+			}
+			allTypesMatch = false
+			break
+		}
+		if !allTypesMatch {
+			continue
+		}
+		// The type-parametric equality overloads are only implemented for comparable types.
+		if isEqualityOverload(overload.GetOverloadId()) && !c.isComparableType(bindings["T"]) {
+			continue
 		}
-		// TODO: Add support for type parameters.
+		for i, t := range retypedArgs {
+			c.typeMap[callExpr.GetArgs()[i].GetId()] = t
+		}
+		if len(overload.GetTypeParams()) == 0 {
+			return overload, nil
+		}
+		return &expr.Decl_FunctionDecl_Overload{
+			OverloadId: overload.GetOverloadId(),
+			TypeParams: overload.GetTypeParams(),
+			Params:     overload.GetParams(),
+			ResultType: substituteType(overload.GetResultType(), bindings),
+			Doc:        overload.GetDoc(),
+		}, nil
 	}
-	var argTypes []string
-	for _, arg := range callExpr.GetArgs() {
-		t, ok := c.getType(arg)
+	argTypeNames := make([]string, 0, len(argTypes))
+	for _, t := range argTypes {
+		argTypeNames = append(argTypeNames, t.String())
+	}
+	return nil, c.errorf(e, "no matching overload found for calling '%s' with %s", callExpr.GetFunction(), argTypeNames)
+}
+
+// lookupConstantType returns the type of the declared constant named by the provided string constant expression.
+func (c *Checker) lookupConstantType(e *expr.Expr) (*expr.Type, bool) {
+	constExpr := e.GetConstExpr()
+	if constExpr == nil {
+		return nil, false
+	}
+	stringValue, ok := constExpr.GetConstantKind().(*expr.Constant_StringValue)
+	if !ok {
+		return nil, false
+	}
+	ident, ok := c.declarations.LookupIdent(stringValue.StringValue)
+	if !ok || ident.GetIdent().GetValue() == nil {
+		return nil, false
+	}
+	return ident.GetIdent().GetType(), true
+}
+
+// unifyType returns true if the argument type matches the parameter type, binding any type parameters in the
+// parameter type to the corresponding parts of the argument type.
+//
+// Wrapper types match the wrapped primitive type, and null matches wrapper types.
+func unifyType(param, arg *expr.Type, typeParams []string, bindings map[string]*expr.Type) bool {
+	switch kind := param.GetTypeKind().(type) {
+	case *expr.Type_Primitive:
+		if argWrapper, ok := arg.GetTypeKind().(*expr.Type_Wrapper); ok {
+			return argWrapper.Wrapper == kind.Primitive
+		}
+	case *expr.Type_Wrapper:
+		if _, ok := arg.GetTypeKind().(*expr.Type_Null); ok {
+			return true
+		}
+	case *expr.Type_TypeParam:
+		if !isTypeParam(kind.TypeParam, typeParams) {
+			break
+		}
+		if bound, ok := bindings[kind.TypeParam]; ok {
+			return proto.Equal(bound, arg)
+		}
+		bindings[kind.TypeParam] = arg
+		return true
+	case *expr.Type_ListType_:
+		argList, ok := arg.GetTypeKind().(*expr.Type_ListType_)
 		if !ok {
-			argTypes = append(argTypes, "UNKNOWN")
-		} else {
-			argTypes = append(argTypes, t.String())
+			return false
+		}
+		return unifyType(kind.ListType.GetElemType(), argList.ListType.GetElemType(), typeParams, bindings)
+	case *expr.Type_MapType_:
+		argMap, ok := arg.GetTypeKind().(*expr.Type_MapType_)
+		if !ok {
+			return false
+		}
+		return unifyType(kind.MapType.GetKeyType(), argMap.MapType.GetKeyType(), typeParams, bindings) &&
+			unifyType(kind.MapType.GetValueType(), argMap.MapType.GetValueType(), typeParams, bindings)
+	}
+	return proto.Equal(param, arg)
+}
+
+// substituteType returns the provided type with all bound type parameters replaced.
+func substituteType(t *expr.Type, bindings map[string]*expr.Type) *expr.Type {
+	switch kind := t.GetTypeKind().(type) {
+	case *expr.Type_TypeParam:
+		if bound, ok := bindings[kind.TypeParam]; ok {
+			return bound
+		}
+	case *expr.Type_ListType_:
+		return TypeList(substituteType(kind.ListType.GetElemType(), bindings))
+	case *expr.Type_MapType_:
+		return TypeMap(
+			substituteType(kind.MapType.GetKeyType(), bindings),
+			substituteType(kind.MapType.GetValueType(), bindings),
+		)
+	}
+	return t
+}
+
+// isEqualityOverload returns true if the provided overload is one of the type-parametric equality overloads.
+func isEqualityOverload(overloadID string) bool {
+	return overloadID == FunctionOverloadEquals || overloadID == FunctionOverloadNotEquals
+}
+
+// isComparableType returns true if values of the provided type can be compared for equality, which excludes lists,
+// maps and messages.
+func (c *Checker) isComparableType(t *expr.Type) bool {
+	switch kind := t.GetTypeKind().(type) {
+	case *expr.Type_Primitive, *expr.Type_Wrapper:
+		return true
+	case *expr.Type_WellKnown:
+		return kind.WellKnown == expr.Type_TIMESTAMP || kind.WellKnown == expr.Type_DURATION
+	case *expr.Type_MessageType:
+		_, ok := c.declarations.enumTypes[kind.MessageType]
+		return ok
+	}
+	return false
+}
+
+func isTypeParam(name string, typeParams []string) bool {
+	for _, typeParam := range typeParams {
+		if typeParam == name {
+			return true
 		}
 	}
-	return nil, c.errorf(e, "no matching overload found for calling '%s' with %s", callExpr.GetFunction(), argTypes)
+	return false
 }
 
 func (c *Checker) checkCallExprBuiltinFunctionOverloads(
@@ -196,6 +443,12 @@ func (c *Checker) checkCallExprBuiltinFunctionOverloads(
 				return c.errorf(callExpr.GetArgs()[0], "invalid duration")
 			}
 		}
+	case FunctionOverloadMatchesString:
+		if constExpr := callExpr.GetArgs()[1].GetConstExpr(); constExpr != nil {
+			if _, err := regexp.Compile(constExpr.GetStringValue()); err != nil {
+				return c.errorf(callExpr.GetArgs()[1], "invalid regular expression: %v", err)
+			}
+		}
 	case FunctionOverloadLessThanTimestampString,
 		FunctionOverloadGreaterThanTimestampString,
 		FunctionOverloadLessEqualsTimestampString,
@@ -211,6 +464,69 @@ func (c *Checker) checkCallExprBuiltinFunctionOverloads(
 	return nil
 }
 
+// resolveCallExprWildcardOverload marks string comparisons with constant wildcard patterns.
+//
+// Constants with escaped `\*` but no unescaped `*` are marked as well, so that they compare equal to the unescaped
+// string, as in `name = "a\*b"` which matches "a*b".
+func (c *Checker) resolveCallExprWildcardOverload(
+	e *expr.Expr,
+	functionOverload *expr.Decl_FunctionDecl_Overload,
+) *expr.Decl_FunctionDecl_Overload {
+	var overloadID string
+	switch functionOverload.GetOverloadId() {
+	case FunctionOverloadEqualsString:
+		overloadID = FunctionOverloadEqualsStringWildcard
+	case FunctionOverloadNotEqualsString:
+		overloadID = FunctionOverloadNotEqualsStringWildcard
+	default:
+		return functionOverload
+	}
+	constExpr := e.GetCallExpr().GetArgs()[1].GetConstExpr()
+	if constExpr == nil || !hasWildcardSyntax(constExpr.GetStringValue()) {
+		return functionOverload
+	}
+	return &expr.Decl_FunctionDecl_Overload{
+		OverloadId: overloadID,
+		Params:     functionOverload.GetParams(),
+		ResultType: functionOverload.GetResultType(),
+		Doc:        functionOverload.GetDoc(),
+	}
+}
+
+// resolveCallExprPresenceOverload resolves presence checks such as `address:*` to the presence overload for the type
+// of the left-hand side.
+func (c *Checker) resolveCallExprPresenceOverload(e *expr.Expr) (string, bool) {
+	callExpr := e.GetCallExpr()
+	if callExpr.GetFunction() != FunctionHas || len(callExpr.GetArgs()) != 2 {
+		return "", false
+	}
+	if !isPresenceWildcard(callExpr.GetArgs()[1]) {
+		return "", false
+	}
+	argType, ok := c.getType(callExpr.GetArgs()[0])
+	if !ok {
+		return "", false
+	}
+	switch kind := argType.GetTypeKind().(type) {
+	case *expr.Type_Primitive:
+		if kind.Primitive == expr.Type_STRING {
+			return FunctionOverloadHasStringPresence, true
+		}
+	case *expr.Type_MapType_:
+		return FunctionOverloadHasMapPresence, true
+	case *expr.Type_ListType_:
+		return FunctionOverloadHasListPresence, true
+	case *expr.Type_WellKnown, *expr.Type_Wrapper, *expr.Type_Dyn:
+		return FunctionOverloadHasMessagePresence, true
+	case *expr.Type_MessageType:
+		// Enum types are message types as well, but have no presence.
+		if _, ok := c.declarations.resolveMessage(kind.MessageType); ok {
+			return FunctionOverloadHasMessagePresence, true
+		}
+	}
+	return "", false
+}
+
 func (c *Checker) checkInt64Literal(e *expr.Expr) error {
 	return c.setType(e, TypeInt)
 }
@@ -227,21 +543,183 @@ func (c *Checker) checkBoolLiteral(e *expr.Expr) error {
 	return c.setType(e, TypeBool)
 }
 
-func (c *Checker) errorf(_ *expr.Expr, format string, args ...interface{}) error {
-	// TODO: Include the provided expr.
-	return &typeError{
-		message: fmt.Sprintf(format, args...),
-	}
+func (c *Checker) checkNullLiteral(e *expr.Expr) error {
+	return c.setType(e, TypeNull)
+}
+
+func (c *Checker) errorf(e *expr.Expr, format string, args ...interface{}) error {
+	return c.newTypeError(e, nil, fmt.Sprintf(format, args...))
+}
+
+func (c *Checker) wrapf(err error, e *expr.Expr, format string, args ...interface{}) error {
+	return c.newTypeError(e, err, fmt.Sprintf(format, args...))
 }
 
-func (c *Checker) wrapf(err error, _ *expr.Expr, format string, args ...interface{}) error {
-	// TODO: Include the provided expr.
+func (c *Checker) newTypeError(e *expr.Expr, err error, message string) error {
 	return &typeError{
-		message: fmt.Sprintf(format, args...),
+		filter:  c.filter,
+		span:    c.span(e),
+		message: message,
 		err:     err,
 	}
 }
 
+// span returns the source span of the provided expr, or the zero value if unknown.
+func (c *Checker) span(e *expr.Expr) Span {
+	start, last := int32(-1), int32(-1)
+	Walk(func(currExpr, _ *expr.Expr) bool {
+		if position, ok := c.position(currExpr); ok {
+			if start == -1 || position < start {
+				start = position
+			}
+			if position > last {
+				last = position
+			}
+		}
+		return true
+	}, e)
+	if start == -1 {
+		return Span{}
+	}
+	startPosition := c.sourcePosition(start)
+	if c.filter == "" {
+		return Span{Start: startPosition, End: startPosition}
+	}
+	return Span{Start: startPosition, End: tokenSpan(c.filter, c.sourcePosition(last)).End}
+}
+
+// sourcePosition returns the position of the provided offset, with byte columns when the filter is unknown.
+func (c *Checker) sourcePosition(offset int32) Position {
+	if c.filter != "" {
+		return positionOf(c.filter, int(offset))
+	}
+	position := Position{Offset: offset, Line: 1, Column: offset + 1}
+	// Line offsets are the offsets of newline characters.
+	for _, lineOffset := range c.sourceInfo.GetLineOffsets() {
+		if lineOffset >= offset {
+			break
+		}
+		position.Line++
+		position.Column = offset - lineOffset
+	}
+	return position
+}
+
+// checkDeprecation adds a warning if the provided name, or any of its parents, is deprecated.
+func (c *Checker) checkDeprecation(e *expr.Expr, name string) {
+	deprecatedName, reason, ok := c.declarations.lookupDeprecation(name)
+	if !ok {
+		return
+	}
+	message := fmt.Sprintf("'%s' is deprecated", deprecatedName)
+	if reason != "" {
+		message += ": " + reason
+	}
+	c.warnings = append(c.warnings, Warning{
+		Position: c.span(e).Start,
+		Message:  message,
+	})
+}
+
+// rewriteIdent rewrites the provided expr to an ident with the provided name, and records the original expr as a
+// macro call in the source info.
+//
+// Qualified names, such as `origin.display_name`, are rewritten to member expressions on an ident, as parsed.
+func (c *Checker) rewriteIdent(e *expr.Expr, name string) {
+	c.recordMacroCall(e)
+	names := strings.Split(name, ".")
+	position, hasPosition := c.position(e)
+	var operand *expr.Expr
+	for _, name := range names[:len(names)-1] {
+		if operand == nil {
+			operand = Text(name)
+		} else {
+			operand = Member(operand, name)
+		}
+		operand.Id = c.newID()
+		if hasPosition {
+			if c.sourceInfo.Positions == nil {
+				c.sourceInfo.Positions = map[int64]int32{}
+			}
+			c.sourceInfo.Positions[operand.GetId()] = position
+		}
+	}
+	if operand == nil {
+		e.ExprKind = Text(name).GetExprKind()
+		return
+	}
+	e.ExprKind = Member(operand, names[len(names)-1]).GetExprKind()
+}
+
+// rewriteReceiverCall rewrites receiver-style calls such as `display_name.matches("^ACME")`, which are parsed as calls
+// of qualified function names, to calls with the receiver as first arg, as in `matches(display_name, "^ACME")`.
+//
+// Calls are only rewritten when the qualified function name is undeclared, and the unqualified name is declared.
+func (c *Checker) rewriteReceiverCall(e *expr.Expr) {
+	function := e.GetCallExpr().GetFunction()
+	i := strings.LastIndexByte(function, '.')
+	if i <= 0 {
+		return
+	}
+	if _, ok := c.declarations.LookupFunction(function); ok {
+		return
+	}
+	if _, ok := c.declarations.LookupFunction(function[i+1:]); !ok {
+		return
+	}
+	position, hasPosition := c.position(e)
+	var receiver *expr.Expr
+	for _, name := range strings.Split(function[:i], ".") {
+		if receiver == nil {
+			receiver = Text(name)
+		} else {
+			receiver = Member(receiver, name)
+		}
+		receiver.Id = c.newID()
+		if hasPosition {
+			if c.sourceInfo.Positions == nil {
+				c.sourceInfo.Positions = map[int64]int32{}
+			}
+			c.sourceInfo.Positions[receiver.GetId()] = position
+		}
+	}
+	c.recordMacroCall(e)
+	e.ExprKind = Function(function[i+1:], append([]*expr.Expr{receiver}, e.GetCallExpr().GetArgs()...)...).GetExprKind()
+}
+
+// recordMacroCall records the provided expr as a macro call in the source info, before it is rewritten.
+func (c *Checker) recordMacroCall(e *expr.Expr) {
+	if c.sourceInfo == nil {
+		return
+	}
+	if c.sourceInfo.MacroCalls == nil {
+		c.sourceInfo.MacroCalls = map[int64]*expr.Expr{}
+	}
+	c.sourceInfo.MacroCalls[e.GetId()] = &expr.Expr{Id: e.GetId(), ExprKind: e.GetExprKind()}
+}
+
+// newID returns a new expr ID, that is not used by the checked expr.
+func (c *Checker) newID() int64 {
+	if c.nextID == 0 {
+		c.nextID = MaxID(c.expr) + 1
+	}
+	id := c.nextID
+	c.nextID++
+	return id
+}
+
+// position returns the source position of the provided expr, or of the expr it replaced when expanding macros.
+func (c *Checker) position(e *expr.Expr) (int32, bool) {
+	if position, ok := c.sourceInfo.GetPositions()[e.GetId()]; ok {
+		return position, true
+	}
+	if macroCall, ok := c.sourceInfo.GetMacroCalls()[e.GetId()]; ok {
+		position, ok := c.sourceInfo.GetPositions()[macroCall.GetId()]
+		return position, ok
+	}
+	return 0, false
+}
+
 func (c *Checker) setType(e *expr.Expr, t *expr.Type) error {
 	if existingT, ok := c.typeMap[e.GetId()]; ok && !proto.Equal(t, existingT) {
 		return c.errorf(e, "type conflict between %s and %s", t, existingT)
@@ -250,6 +728,14 @@ func (c *Checker) setType(e *expr.Expr, t *expr.Type) error {
 	return nil
 }
 
+// setReference records a reference from the provided expr to the provided ident declaration.
+func (c *Checker) setReference(e *expr.Expr, ident *expr.Decl) {
+	c.referenceMap[e.GetId()] = &expr.Reference{
+		Name:  ident.GetName(),
+		Value: ident.GetIdent().GetValue(),
+	}
+}
+
 func (c *Checker) getType(e *expr.Expr) (*expr.Type, bool) {
 	t, ok := c.typeMap[e.GetId()]
 	if !ok {
@@ -258,7 +744,19 @@ func (c *Checker) getType(e *expr.Expr) (*expr.Type, bool) {
 	return t, true
 }
 
-func toQualifiedName(e *expr.Expr) (string, bool) {
+// literal returns the constant value of the provided ident declaration, if it is a standard literal constant.
+func literal(ident *expr.Decl) (*expr.Constant, bool) {
+	for _, decl := range StandardConstantDeclarations() {
+		if proto.Equal(decl, ident) {
+			return decl.GetIdent().GetValue(), true
+		}
+	}
+	return nil, false
+}
+
+// QualifiedName returns the qualified name of the provided ident or chain of member expressions, such as
+// "origin.display_name" for `origin.display_name`.
+func QualifiedName(e *expr.Expr) (string, bool) {
 	switch kind := e.GetExprKind().(type) {
 	case *expr.Expr_IdentExpr:
 		return kind.IdentExpr.GetName(), true
@@ -266,7 +764,7 @@ func toQualifiedName(e *expr.Expr) (string, bool) {
 		if kind.SelectExpr.GetTestOnly() {
 			return "", false
 		}
-		parent, ok := toQualifiedName(kind.SelectExpr.GetOperand())
+		parent, ok := QualifiedName(kind.SelectExpr.GetOperand())
 		if !ok {
 			return "", false
 		}
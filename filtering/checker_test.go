@@ -3,6 +3,7 @@ package filtering
 import (
 	"testing"
 
+	freightv1 "go.einride.tech/aip/proto/gen/einride/example/freight/v1"
 	syntaxv1 "go.einride.tech/aip/proto/gen/einride/example/syntax/v1"
 	"gotest.tools/v3/assert"
 )
@@ -355,6 +356,291 @@ func TestChecker(t *testing.T) {
 			},
 		},
 
+		{
+			filter: `shipment.origin_site = "shippers/1/sites/1" AND shipment.create_time > "2022-08-12T22:22:22Z"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareMessageIdent("shipment", (&freightv1.Shipment{}).ProtoReflect().Descriptor()),
+			},
+		},
+
+		{
+			filter: `shipment.line_items_map.first.title = "Pallet" AND shipment.annotations:schedule`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareMessageIdent("shipment", (&freightv1.Shipment{}).ProtoReflect().Descriptor()),
+			},
+		},
+
+		{
+			filter: `site.lat_lng.latitude > 57.7`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareMessageIdent("site", (&freightv1.Site{}).ProtoReflect().Descriptor()),
+			},
+		},
+
+		{
+			filter: `site.lat_lng.latitude > 57.7`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("site", TypeMessage((&freightv1.Site{}).ProtoReflect().Descriptor())),
+			},
+		},
+
+		{
+			filter: `message.message.enum = ENUM_ONE`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareMessageIdent("message", (&syntaxv1.Message{}).ProtoReflect().Descriptor()),
+			},
+		},
+
+		{
+			filter: `origin = "shippers/1/sites/1" AND site.display_name = "Depot" AND lat > 57.7`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareMessageIdent("shipment", (&freightv1.Shipment{}).ProtoReflect().Descriptor()),
+				DeclareIdent("shipment.origin_site", TypeString),
+				DeclareAlias("origin", "shipment.origin_site"),
+				DeclareMessageIdent("destination", (&freightv1.Site{}).ProtoReflect().Descriptor()),
+				DeclareAlias("site", "destination"),
+				DeclareIdent("destination.lat_lng.latitude", TypeFloat),
+				DeclareAlias("lat", "destination.lat_lng.latitude"),
+			},
+		},
+
+		{
+			filter: `origin.display_name = "Depot"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("origin_site", TypeString),
+				DeclareAlias("origin", "origin_site"),
+			},
+			errorContains: "unsupported operand type",
+		},
+
+		{
+			filter: `shipment.origin = "shippers/1/sites/1"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareMessageIdent("shipment", (&freightv1.Shipment{}).ProtoReflect().Descriptor()),
+			},
+			errorContains: "no field 'origin' in message einride.example.freight.v1.Shipment",
+		},
+
+		{
+			filter: `shipment.line_items = "foo"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareMessageIdent("shipment", (&freightv1.Shipment{}).ProtoReflect().Descriptor()),
+			},
+			errorContains: "no matching overload",
+		},
+
+		{
+			filter: `repeated_int64:42 AND repeated_enum:ENUM_ONE AND map_string_int64:foo`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("repeated_int64", TypeList(TypeInt)),
+				DeclareIdent("repeated_enum", TypeList(TypeEnum(syntaxv1.Enum(0).Type()))),
+				DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+				DeclareIdent("map_string_int64", TypeMap(TypeString, TypeInt)),
+			},
+		},
+
+		{
+			filter: `repeated_int64:"foo"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("repeated_int64", TypeList(TypeInt)),
+			},
+			errorContains: "no matching overload",
+		},
+
+		{
+			filter: `repeated_enum:ENUM_FOO`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("repeated_enum", TypeList(TypeEnum(syntaxv1.Enum(0).Type()))),
+				DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+			},
+			errorContains: "no matching overload",
+		},
+
+		{
+			filter: `enum = "ENUM_ONE"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+			},
+			errorContains: "no matching overload",
+		},
+
+		{
+			filter: `is_enum_one("ENUM_ONE")`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+				DeclareFunction(
+					"is_enum_one",
+					NewFunctionOverload("is_enum_one_enum", TypeBool, TypeEnum(syntaxv1.Enum(0).Type())),
+				),
+			},
+			errorContains: "no matching overload",
+		},
+
+		{
+			filter: `enum = enum AND bool != bool`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+				DeclareIdent("bool", TypeBool),
+			},
+		},
+
+		{
+			filter: `repeated_int64 = repeated_int64`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("repeated_int64", TypeList(TypeInt)),
+			},
+			errorContains: "no matching overload",
+		},
+
+		{
+			filter: `map_string_int64 != map_string_int64`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("map_string_int64", TypeMap(TypeString, TypeInt)),
+			},
+			errorContains: "no matching overload",
+		},
+
+		{
+			filter: `message = message`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("message", TypeMessage((&syntaxv1.Message{}).ProtoReflect().Descriptor())),
+			},
+			errorContains: "no matching overload",
+		},
+
+		{
+			filter: `archived AND NOT deleted AND archived = true AND deleted != false`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareStandardConstants(),
+				DeclareIdent("archived", TypeBool),
+				DeclareIdent("deleted", TypeBool),
+			},
+		},
+
+		{
+			filter: `display_name:* AND labels:* AND tags:* AND create_time:* AND site:* AND site.lat_lng:*`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("display_name", TypeString),
+				DeclareIdent("labels", TypeMap(TypeString, TypeString)),
+				DeclareIdent("tags", TypeList(TypeInt)),
+				DeclareIdent("create_time", TypeTimestamp),
+				DeclareMessageIdent("site", (&freightv1.Site{}).ProtoReflect().Descriptor()),
+			},
+		},
+
+		{
+			filter: `archived = true`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("archived", TypeBool),
+			},
+			errorContains: "undeclared identifier 'true'",
+		},
+
+		{
+			filter: `archived = true`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("archived", TypeBool),
+				DeclareIdent("true", TypeBool),
+			},
+		},
+
+		{
+			filter: `enum:*`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+			},
+			errorContains: "no matching overload",
+		},
+
+		{
+			filter: `int64:*`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("int64", TypeInt),
+			},
+			errorContains: "no matching overload",
+		},
+
+		{
+			filter: `display_name`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("display_name", TypeString),
+			},
+			errorContains: "non-bool result type",
+		},
+
+		{
+			filter: `display_name.matches("^ACME.*") AND site.display_name.startsWith("A") AND lower(display_name) = "acme"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareStringFunctions(),
+				DeclareIdent("display_name", TypeString),
+				DeclareMessageIdent("site", (&freightv1.Site{}).ProtoReflect().Descriptor()),
+			},
+		},
+
+		{
+			filter: `endsWith(display_name, "Inc") OR contains(display_name, "Freight")`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareStringFunctions(),
+				DeclareIdent("display_name", TypeString),
+			},
+		},
+
+		{
+			filter: `display_name.matches("^ACME(")`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareStringFunctions(),
+				DeclareIdent("display_name", TypeString),
+			},
+			errorContains: "invalid regular expression: error parsing regexp: missing closing )",
+		},
+
+		{
+			filter: `display_name.matches("^ACME")`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("display_name", TypeString),
+			},
+			errorContains: "undeclared function 'display_name.matches'",
+		},
+
+		{
+			filter: `create_time.matches("^2022")`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareStringFunctions(),
+				DeclareIdent("create_time", TypeTimestamp),
+			},
+			errorContains: "no matching overload",
+		},
+
 		{
 			filter: `create_time = "2022-08-12 22:22:22"`,
 			declarations: []DeclarationOption{
@@ -412,6 +698,115 @@ func TestChecker(t *testing.T) {
 			},
 		},
 
+		{
+			filter: `weight > 100 AND weight != null AND nickname = "B*" AND nickname:"o" AND NOT active`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareStandardConstants(),
+				DeclareIdent("weight", TypeIntWrapper),
+				DeclareIdent("nickname", TypeStringWrapper),
+				DeclareIdent("active", TypeBoolWrapper),
+			},
+		},
+
+		{
+			filter: `weight < null`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareStandardConstants(),
+				DeclareIdent("weight", TypeIntWrapper),
+			},
+			errorContains: "no matching overload found for calling '<'",
+		},
+
+		{
+			filter: `weight = "heavy"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("weight", TypeIntWrapper),
+			},
+			errorContains: "no matching overload found for calling '='",
+		},
+
+		{
+			filter: `display_name = null`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareStandardConstants(),
+				DeclareIdent("display_name", TypeString),
+			},
+			errorContains: "no matching overload found for calling '='",
+		},
+
+		{
+			filter: `metadata.customer.tier = "gold" AND metadata.customer.seats > 10 AND metadata.note = null`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareStandardConstants(),
+				DeclareIdent("metadata", TypeStruct),
+			},
+		},
+
+		{
+			filter: `metadata:customer AND metadata:* AND metadata.customer:*`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("metadata", TypeStruct),
+			},
+		},
+
+		{
+			filter: `"gold" = metadata.tier`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("metadata", TypeStruct),
+			},
+			errorContains: "no matching overload found for calling '='",
+		},
+
+		{
+			filter: `line_items.title = "Pallet" AND line_items.weight_kg > 100.0 AND NOT line_items.title:"Box"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareMessageFields((&freightv1.Shipment{}).ProtoReflect().Descriptor()),
+			},
+		},
+
+		{
+			filter: `shipment.line_items.title = "Pallet"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareMessageIdent("shipment", (&freightv1.Shipment{}).ProtoReflect().Descriptor()),
+			},
+		},
+
+		{
+			filter: `line_items.sku = "ABC"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareMessageFields((&freightv1.Shipment{}).ProtoReflect().Descriptor()),
+			},
+			errorContains: "no field 'sku' in message einride.example.freight.v1.LineItem",
+		},
+
+		{
+			filter: `line_items.weight_kg = "heavy"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareMessageFields((&freightv1.Shipment{}).ProtoReflect().Descriptor()),
+			},
+			errorContains: "no matching overload found for calling '='",
+		},
+
+		{
+			filter: `tags.name = "foo"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("tags", TypeList(TypeString)),
+			},
+			errorContains: "unsupported select of 'name' from list",
+		},
+
 		{
 			filter:        "<",
 			errorContains: "unexpected token <",
@@ -461,3 +856,39 @@ func TestChecker(t *testing.T) {
 		})
 	}
 }
+
+func TestChecker_hasOverloadIDs(t *testing.T) {
+	t.Parallel()
+	declarations, err := NewDeclarations(
+		DeclareStandardFunctions(),
+		DeclareIdent("repeated_string", TypeList(TypeString)),
+		DeclareIdent("map_string_string", TypeMap(TypeString, TypeString)),
+		DeclareIdent("repeated_int64", TypeList(TypeInt)),
+		DeclareIdent("map_string_int64", TypeMap(TypeString, TypeInt)),
+	)
+	assert.NilError(t, err)
+	for _, tt := range []struct {
+		filter     string
+		overloadID string
+	}{
+		{filter: `repeated_string:foo`, overloadID: FunctionOverloadHasListString},
+		{filter: `map_string_string:foo`, overloadID: FunctionOverloadHasMapStringString},
+		{filter: `repeated_int64:42`, overloadID: FunctionOverloadHasList},
+		{filter: `map_string_int64:foo`, overloadID: FunctionOverloadHasMap},
+	} {
+		tt := tt
+		t.Run(tt.filter, func(t *testing.T) {
+			t.Parallel()
+			var parser Parser
+			parser.Init(tt.filter)
+			parsedExpr, err := parser.Parse()
+			assert.NilError(t, err)
+			var checker Checker
+			checker.Init(parsedExpr.GetExpr(), parsedExpr.GetSourceInfo(), declarations)
+			checkedExpr, err := checker.Check()
+			assert.NilError(t, err)
+			reference := checkedExpr.GetReferenceMap()[checkedExpr.GetExpr().GetId()]
+			assert.DeepEqual(t, []string{tt.overloadID}, reference.GetOverloadId())
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package filtering
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Predicate is a compiled filter, that returns true if the provided message matches the filter.
+type Predicate func(message proto.Message) (bool, error)
+
+// Compile compiles the filter into a reusable Predicate for messages of the provided type.
+//
+// The Predicate has the same semantics as Evaluate, but field descriptors are resolved, timestamp and duration
+// constants are parsed and constant sub-expressions are folded once, at compile time. The Predicate is safe for
+// concurrent use, and returns an error for messages of other types than the provided message type.
+func Compile(filter Filter, message protoreflect.MessageDescriptor, opts ...EvaluateOption) (Predicate, error) {
+	if filter.CheckedExpr == nil {
+		return func(proto.Message) (bool, error) {
+			return true, nil
+		}, nil
+	}
+	c := compiler{
+		evaluator: evaluator{
+			referenceMap: filter.CheckedExpr.GetReferenceMap(),
+			typeMap:      filter.CheckedExpr.GetTypeMap(),
+		},
+	}
+	for _, opt := range opts {
+		opt(&c.evaluator)
+	}
+	root, err := c.compile(filter.CheckedExpr.GetExpr(), message)
+	if err != nil {
+		return nil, fmt.Errorf("compile filter: %w", err)
+	}
+	if root.isConstant {
+		if _, ok := root.value.(bool); !ok {
+			return nil, fmt.Errorf("compile filter: non-bool result %v", root.value)
+		}
+	}
+	return func(m proto.Message) (bool, error) {
+		reflectMessage := m.ProtoReflect()
+		if reflectMessage.Descriptor().FullName() != message.FullName() {
+			return false, fmt.Errorf(
+				"evaluate filter: expected message %s but got %s",
+				message.FullName(),
+				reflectMessage.Descriptor().FullName(),
+			)
+		}
+		result, err := root.eval(reflectMessage)
+		if err != nil {
+			return false, fmt.Errorf("evaluate filter: %w", err)
+		}
+		b, ok := boolValue(result)
+		if !ok {
+			return false, fmt.Errorf("evaluate filter: non-bool result %v", result)
+		}
+		return b, nil
+	}, nil
+}
+
+// program evaluates a compiled expression against a message.
+type program func(message protoreflect.Message) (interface{}, error)
+
+// compiled is a compiled expression.
+type compiled struct {
+	eval program
+	// isConstant is true if the expression evaluates to value for all messages.
+	isConstant bool
+	value      interface{}
+	// message is the type of message values of the expression, if known at compile time.
+	message protoreflect.MessageDescriptor
+}
+
+func constantCompiled(value interface{}) compiled {
+	return compiled{
+		eval: func(protoreflect.Message) (interface{}, error) {
+			return value, nil
+		},
+		isConstant: true,
+		value:      value,
+	}
+}
+
+// compiler compiles expressions, using the evaluator for resolving constants and function implementations.
+type compiler struct {
+	evaluator
+}
+
+func (c *compiler) compile(e *expr.Expr, root protoreflect.MessageDescriptor) (compiled, error) {
+	if value, ok := c.referenceValue(e); ok {
+		return constantCompiled(value), nil
+	}
+	switch kind := e.GetExprKind().(type) {
+	case *expr.Expr_ConstExpr:
+		value, err := constantValue(kind.ConstExpr)
+		if err != nil {
+			return compiled{}, err
+		}
+		return constantCompiled(value), nil
+	case *expr.Expr_IdentExpr:
+		return compileField(root, kind.IdentExpr.GetName(), func(message protoreflect.Message) (interface{}, error) {
+			return message, nil
+		})
+	case *expr.Expr_SelectExpr:
+		return c.compileSelect(kind.SelectExpr, root)
+	case *expr.Expr_CallExpr:
+		return c.compileCall(e, root)
+	default:
+		return compiled{}, fmt.Errorf("unsupported expr kind %T", kind)
+	}
+}
+
+// compileField compiles the selection of the named field of the messages of the provided type, where operand
+// evaluates to the message to select the field from.
+func compileField(message protoreflect.MessageDescriptor, name string, operand program) (compiled, error) {
+	field := message.Fields().ByName(protoreflect.Name(name))
+	if field == nil {
+		return compiled{}, fmt.Errorf("no field '%s' in %s", name, message.FullName())
+	}
+	result := compiled{
+		eval: func(m protoreflect.Message) (interface{}, error) {
+			value, err := operand(m)
+			if err != nil {
+				return nil, err
+			}
+			switch value := value.(type) {
+			case nil:
+				return nil, nil
+			case protoreflect.Message:
+				return fieldValue(value, field), nil
+			default:
+				return nil, fmt.Errorf("unsupported select of '%s' on %T", name, value)
+			}
+		},
+	}
+	if field.Message() != nil && !field.IsList() && !field.IsMap() {
+		if _, ok := wellKnownType(field.Message()); !ok {
+			result.message = field.Message()
+		}
+	}
+	return result, nil
+}
+
+func (c *compiler) compileSelect(selectExpr *expr.Expr_Select, root protoreflect.MessageDescriptor) (compiled, error) {
+	operand, err := c.compile(selectExpr.GetOperand(), root)
+	if err != nil {
+		return compiled{}, err
+	}
+	if operand.message != nil {
+		return compileField(operand.message, selectExpr.GetField(), operand.eval)
+	}
+	field := selectExpr.GetField()
+	return compiled{
+		eval: func(message protoreflect.Message) (interface{}, error) {
+			value, err := operand.eval(message)
+			if err != nil {
+				return nil, err
+			}
+			return selectValue(value, field)
+		},
+	}, nil
+}
+
+func (c *compiler) compileCall(e *expr.Expr, root protoreflect.MessageDescriptor) (compiled, error) {
+	call := e.GetCallExpr()
+	args := make([]compiled, 0, len(call.GetArgs()))
+	allConstant := true
+	for _, arg := range call.GetArgs() {
+		compiledArg, err := c.compile(arg, root)
+		if err != nil {
+			return compiled{}, err
+		}
+		allConstant = allConstant && compiledArg.isConstant
+		args = append(args, compiledArg)
+	}
+	switch call.GetFunction() {
+	case FunctionAnd, FunctionFuzzyAnd:
+		return compileJunction(args, true)
+	case FunctionOr:
+		return compileJunction(args, false)
+	}
+	if c.isTimestampStringComparison(e) && len(args) == 2 && args[1].isConstant {
+		// Parse constant timestamp strings once, instead of for every message.
+		if s, ok := args[1].value.(string); ok {
+			value, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return compiled{}, fmt.Errorf("%s: %w", call.GetFunction(), err)
+			}
+			args[1] = constantCompiled(value)
+		}
+	}
+	if overloadID, ok := c.wildcardOverload(e); ok && len(args) == 2 && args[1].isConstant {
+		// Split constant wildcard patterns once, instead of for every message.
+		if pattern, ok := args[1].value.(string); ok {
+			return compileWildcardMatch(args[0], SplitWildcard(pattern), overloadID == FunctionOverloadEqualsStringWildcard), nil
+		}
+	}
+	if c.isRegexpMatch(e) && len(args) == 2 && args[1].isConstant {
+		// Compile constant regular expressions once, instead of for every message.
+		if pattern, ok := args[1].value.(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return compiled{}, fmt.Errorf("%s: %w", call.GetFunction(), err)
+			}
+			if !args[0].isConstant {
+				return compileRegexpMatch(args[0], re), nil
+			}
+		}
+	}
+	function, custom := c.resolveCall(e)
+	if allConstant && !custom {
+		values := make([]interface{}, 0, len(args))
+		for _, arg := range args {
+			values = append(values, arg.value)
+		}
+		value, err := function(values)
+		if err != nil {
+			return compiled{}, err
+		}
+		return constantCompiled(value), nil
+	}
+	return compiled{
+		eval: func(message protoreflect.Message) (interface{}, error) {
+			values := make([]interface{}, 0, len(args))
+			for _, arg := range args {
+				value, err := arg.eval(message)
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, value)
+			}
+			return c.call(e, function, values)
+		},
+	}, nil
+}
+
+// compileJunction compiles a short-circuiting AND, when the identity is true, or OR, when the identity is false.
+func compileJunction(args []compiled, identity bool) (compiled, error) {
+	operands := make([]program, 0, len(args))
+	for _, arg := range args {
+		if !arg.isConstant {
+			operands = append(operands, arg.eval)
+			continue
+		}
+		b, ok := arg.value.(bool)
+		if !ok {
+			return compiled{}, fmt.Errorf("expected bool but got %T", arg.value)
+		}
+		if b != identity {
+			return constantCompiled(b), nil
+		}
+	}
+	if len(operands) == 0 {
+		return constantCompiled(identity), nil
+	}
+	return compiled{
+		eval: func(message protoreflect.Message) (interface{}, error) {
+			for _, operand := range operands {
+				value, err := operand(message)
+				if err != nil {
+					return false, err
+				}
+				b, ok := boolValue(value)
+				if !ok {
+					return false, fmt.Errorf("expected bool but got %T", value)
+				}
+				if b != identity {
+					return b, nil
+				}
+			}
+			return identity, nil
+		},
+	}, nil
+}
+
+// compileWildcardMatch compiles a match of a string against the segments of a constant wildcard pattern.
+// Null values never match.
+func compileWildcardMatch(arg compiled, segments []string, equals bool) compiled {
+	return compileStringMatch(arg, func(value interface{}) (bool, error) {
+		if value == nil {
+			return !equals, nil
+		}
+		s, ok := value.(string)
+		if !ok {
+			return false, fmt.Errorf("expected string but got %T", value)
+		}
+		return matchWildcardSegments(segments, s) == equals, nil
+	})
+}
+
+// compileRegexpMatch compiles a match of a string against a constant regular expression. Null values never match.
+func compileRegexpMatch(arg compiled, re *regexp.Regexp) compiled {
+	return compileStringMatch(arg, func(value interface{}) (bool, error) {
+		if value == nil {
+			return false, nil
+		}
+		s, ok := value.(string)
+		if !ok {
+			return false, fmt.Errorf("%s: expected string but got %T", FunctionMatches, value)
+		}
+		return re.MatchString(s), nil
+	})
+}
+
+// compileStringMatch compiles a match of the value of arg, where projections match if any element matches.
+func compileStringMatch(arg compiled, match func(value interface{}) (bool, error)) compiled {
+	return compiled{
+		eval: func(message protoreflect.Message) (interface{}, error) {
+			value, err := arg.eval(message)
+			if err != nil {
+				return nil, err
+			}
+			elements, ok := value.(projection)
+			if !ok {
+				return match(value)
+			}
+			for _, element := range elements {
+				if ok, err := match(element); err != nil || ok {
+					return ok, err
+				}
+			}
+			return false, nil
+		},
+	}
+}
+
+func (c *compiler) wildcardOverload(e *expr.Expr) (string, bool) {
+	for _, overloadID := range c.referenceMap[e.GetId()].GetOverloadId() {
+		switch overloadID {
+		case FunctionOverloadEqualsStringWildcard, FunctionOverloadNotEqualsStringWildcard:
+			return overloadID, true
+		}
+	}
+	return "", false
+}
+
+func (c *compiler) isRegexpMatch(e *expr.Expr) bool {
+	for _, overloadID := range c.referenceMap[e.GetId()].GetOverloadId() {
+		if overloadID == FunctionOverloadMatchesString {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *compiler) isTimestampStringComparison(e *expr.Expr) bool {
+	for _, overloadID := range c.referenceMap[e.GetId()].GetOverloadId() {
+		switch overloadID {
+		case FunctionOverloadEqualsTimestampString,
+			FunctionOverloadNotEqualsTimestampString,
+			FunctionOverloadLessThanTimestampString,
+			FunctionOverloadLessEqualsTimestampString,
+			FunctionOverloadGreaterThanTimestampString,
+			FunctionOverloadGreaterEqualsTimestampString:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package filtering
+
+import (
+	"testing"
+	"time"
+
+	freightv1 "go.einride.tech/aip/proto/gen/einride/example/freight/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gotest.tools/v3/assert"
+)
+
+func TestCompile(t *testing.T) {
+	t.Parallel()
+	declarations, err := NewDeclarations(
+		DeclareStandardFunctions(),
+		DeclareIdent("name", TypeString),
+		DeclareIdent("create_time", TypeTimestamp),
+	)
+	assert.NilError(t, err)
+	shipment := &freightv1.Shipment{
+		Name:       "shippers/1/shipments/1",
+		CreateTime: timestamppb.New(time.Date(2022, 8, 12, 22, 22, 22, 0, time.UTC)),
+	}
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		predicate, err := Compile(Filter{}, shipment.ProtoReflect().Descriptor())
+		assert.NilError(t, err)
+		actual, err := predicate(&freightv1.Site{})
+		assert.NilError(t, err)
+		assert.Assert(t, actual)
+	})
+	t.Run("constant", func(t *testing.T) {
+		t.Parallel()
+		filter, err := ParseFilter(&mockRequest{filter: `duration("1h") < duration("30m") AND name = "foo"`}, declarations)
+		assert.NilError(t, err)
+		predicate, err := Compile(filter, shipment.ProtoReflect().Descriptor())
+		assert.NilError(t, err)
+		actual, err := predicate(shipment)
+		assert.NilError(t, err)
+		assert.Assert(t, !actual)
+	})
+	t.Run("unknown field", func(t *testing.T) {
+		t.Parallel()
+		filter, err := ParseFilter(&mockRequest{filter: `name = "foo"`}, declarations)
+		assert.NilError(t, err)
+		_, err = Compile(filter, (&freightv1.Shipper{}).ProtoReflect().Descriptor())
+		assert.NilError(t, err)
+		_, err = Compile(filter, (&freightv1.LineItem{}).ProtoReflect().Descriptor())
+		assert.ErrorContains(t, err, "compile filter: no field 'name' in einride.example.freight.v1.LineItem")
+	})
+	t.Run("wrong message type", func(t *testing.T) {
+		t.Parallel()
+		filter, err := ParseFilter(&mockRequest{filter: `name = "foo"`}, declarations)
+		assert.NilError(t, err)
+		predicate, err := Compile(filter, shipment.ProtoReflect().Descriptor())
+		assert.NilError(t, err)
+		_, err = predicate(&freightv1.Shipper{Name: "foo"})
+		assert.ErrorContains(
+			t,
+			err,
+			"expected message einride.example.freight.v1.Shipment but got einride.example.freight.v1.Shipper",
+		)
+	})
+}
+
+//nolint:gochecknoglobals
+var matchSink bool
+
+const benchmarkFilter = `name = "shippers/*/shipments/1" AND create_time > "2022-01-01T00:00:00Z" AND ` +
+	`(annotations:env OR NOT external_reference_id = "ACME-123")`
+
+func benchmarkShipmentFilter(b *testing.B) (Filter, *freightv1.Shipment) {
+	declarations, err := NewDeclarations(
+		DeclareStandardFunctions(),
+		DeclareIdent("name", TypeString),
+		DeclareIdent("create_time", TypeTimestamp),
+		DeclareIdent("external_reference_id", TypeString),
+		DeclareIdent("annotations", TypeMap(TypeString, TypeString)),
+	)
+	assert.NilError(b, err)
+	filter, err := ParseFilter(&mockRequest{filter: benchmarkFilter}, declarations)
+	assert.NilError(b, err)
+	return filter, &freightv1.Shipment{
+		Name:                "shippers/1/shipments/1",
+		CreateTime:          timestamppb.New(time.Date(2022, 8, 12, 22, 22, 22, 0, time.UTC)),
+		ExternalReferenceId: "ACME-123",
+		Annotations:         map[string]string{"env": "prod"},
+	}
+}
+
+func BenchmarkEvaluate(b *testing.B) {
+	filter, shipment := benchmarkShipmentFilter(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		match, _ := Evaluate(filter, shipment)
+		matchSink = match
+	}
+}
+
+func BenchmarkCompile_predicate(b *testing.B) {
+	filter, shipment := benchmarkShipmentFilter(b)
+	predicate, err := Compile(filter, shipment.ProtoReflect().Descriptor())
+	assert.NilError(b, err)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		match, _ := predicate(shipment)
+		matchSink = match
+	}
+}
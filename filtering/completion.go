@@ -0,0 +1,546 @@
+package filtering
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// CompletionKind is the kind of a Completion.
+type CompletionKind string
+
+const (
+	// CompletionKindIdent is the kind of completions of declared idents, aliases and message fields.
+	CompletionKindIdent CompletionKind = "IDENT"
+	// CompletionKindMapKey is the kind of completions of map keys provided by WithMapKeys.
+	CompletionKindMapKey CompletionKind = "MAP_KEY"
+	// CompletionKindComparator is the kind of completions of comparators, such as `=` and `:`.
+	CompletionKindComparator CompletionKind = "COMPARATOR"
+	// CompletionKindEnumConstant is the kind of completions of declared constants, such as enum values.
+	CompletionKindEnumConstant CompletionKind = "ENUM_CONSTANT"
+	// CompletionKindFunction is the kind of completions of declared functions.
+	CompletionKindFunction CompletionKind = "FUNCTION"
+	// CompletionKindKeyword is the kind of completions of the keywords AND, OR and NOT.
+	CompletionKindKeyword CompletionKind = "KEYWORD"
+)
+
+// Completion is a candidate for completing a filter at a position.
+type Completion struct {
+	// Kind of the completion.
+	Kind CompletionKind
+	// Text to replace the span with.
+	//
+	// The text of functions includes the opening parenthesis of the call, such as `timestamp(`.
+	Text string
+	// Span of the filter to replace, which is the partially typed token at the position, if any.
+	Span Span
+	// Type of the completion, if known.
+	Type *expr.Type
+	// Doc is the documentation of the completion, if any.
+	Doc string
+}
+
+// CompletionOption configures Complete.
+type CompletionOption func(*completer)
+
+// WithMapKeys is a CompletionOption that provides known keys of the map at the provided path, such as the keys of
+// `labels` in `labels.env = "prod"` and `labels:env`.
+func WithMapKeys(path string, keys ...string) CompletionOption {
+	return func(c *completer) {
+		c.mapKeys[path] = append(c.mapKeys[path], keys...)
+	}
+}
+
+// Complete returns the candidate completions of the provided filter at the provided byte offset, for typeahead in
+// filter inputs.
+//
+// The filter may be incomplete. Completions are returned for restrictions (idents, functions and NOT), member
+// expressions (message fields, dotted idents and map keys), comparators valid for the type of the comparable, args
+// of the type of the comparable (enum constants, idents and functions), and the keywords AND and OR after complete
+// restrictions. Deprecated idents are not completed.
+//
+// Only completions that start with the partially typed token at the offset are returned.
+func Complete(filter string, offset int, declarations *Declarations, opts ...CompletionOption) []Completion {
+	if offset < 0 || offset > len(filter) {
+		return nil
+	}
+	c := completer{
+		declarations: declarations,
+		mapKeys:      make(map[string][]string),
+		seen:         make(map[Completion]bool),
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	tokens, ok := lexCompletionTokens(filter[:offset])
+	if !ok {
+		// The offset is within a string or after invalid input.
+		return nil
+	}
+	position := positionOf(filter, offset)
+	c.span = Span{Start: position, End: position}
+	if n := len(tokens); n > 0 && tokens[n-1].Type.IsName() {
+		c.prefix = tokens[n-1].Value
+		c.span.Start = tokens[n-1].Position
+		end := offset
+		for end < len(filter) {
+			r, size := utf8.DecodeRuneInString(filter[end:])
+			if !isText(r) {
+				break
+			}
+			end += size
+		}
+		c.span.End = positionOf(filter, end)
+		tokens = tokens[:n-1]
+	}
+	c.complete(tokens)
+	return c.result
+}
+
+type completer struct {
+	declarations *Declarations
+	mapKeys      map[string][]string
+	prefix       string
+	span         Span
+	result       []Completion
+	seen         map[Completion]bool
+}
+
+// complete adds the completions after the provided tokens.
+func (c *completer) complete(tokens []Token) {
+	n := len(tokens)
+	if n == 0 {
+		c.completeRestriction()
+		return
+	}
+	switch last := tokens[n-1]; {
+	case last.Type == TokenTypeDot:
+		if path, ok := memberPath(tokens[:n-1]); ok {
+			c.completeMember(path)
+		}
+	case last.Type.IsComparator():
+		c.completeArg(trimWhitespace(tokens[:n-1]), last.Type)
+	case last.Type == TokenTypeLeftParen, last.Type == TokenTypeComma:
+		c.completeRestriction()
+	case last.Type == TokenTypeWhitespace:
+		if n == 1 {
+			c.completeRestriction()
+			return
+		}
+		switch previous := tokens[n-2]; {
+		case previous.Type.IsComparator():
+			c.completeArg(trimWhitespace(tokens[:n-2]), previous.Type)
+		case previous.Type.IsKeyword(), previous.Type == TokenTypeLeftParen, previous.Type == TokenTypeComma:
+			c.completeRestriction()
+		case isOperandEnd(previous.Type):
+			operand := tokens[:n-1]
+			start := operandStart(operand)
+			// Comparators are completed after the comparable of a restriction, but not after its arg.
+			before := trimWhitespace(operand[:start])
+			if len(before) == 0 || !before[len(before)-1].Type.IsComparator() {
+				t, _ := c.operandType(operand)
+				c.completeComparators(t)
+			}
+			c.addKeyword(TokenTypeAnd)
+			c.addKeyword(TokenTypeOr)
+			c.completeRestriction()
+		}
+	}
+}
+
+// completeRestriction adds the completions at the start of a restriction.
+func (c *completer) completeRestriction() {
+	c.completeIdents(nil)
+	c.completeFunctions(nil)
+	c.addKeyword(TokenTypeNot)
+}
+
+// completeMember adds the completions of the fields of the member at the provided path.
+func (c *completer) completeMember(path []string) {
+	qualifiedPath := strings.Join(path, ".")
+	for _, decl := range c.declarations.Idents() {
+		if decl.GetIdent().GetValue() != nil || !strings.HasPrefix(decl.GetName(), qualifiedPath+".") {
+			continue
+		}
+		if _, _, ok := c.declarations.lookupDeprecation(decl.GetName()); ok {
+			continue
+		}
+		field := strings.TrimPrefix(decl.GetName(), qualifiedPath+".")
+		if i := strings.IndexByte(field, '.'); i != -1 {
+			c.add(Completion{Kind: CompletionKindIdent, Text: field[:i]})
+			continue
+		}
+		c.add(Completion{
+			Kind: CompletionKindIdent,
+			Text: field,
+			Type: decl.GetIdent().GetType(),
+			Doc:  decl.GetIdent().GetDoc(),
+		})
+	}
+	t, ok := c.pathType(path)
+	if !ok {
+		return
+	}
+	switch t.GetTypeKind().(type) {
+	case *expr.Type_MapType_:
+		for _, key := range c.mapKeys[qualifiedPath] {
+			c.add(Completion{Kind: CompletionKindMapKey, Text: key, Type: t.GetMapType().GetValueType()})
+		}
+	case *expr.Type_MessageType:
+		message, ok := c.declarations.resolveMessage(t.GetMessageType())
+		if !ok {
+			return
+		}
+		fields := message.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			if fieldType, ok := fieldType(fields.Get(i)); ok {
+				c.add(Completion{Kind: CompletionKindIdent, Text: string(fields.Get(i).Name()), Type: fieldType})
+			}
+		}
+	}
+}
+
+// completeArg adds the completions of the arg of a restriction with the provided comparable and comparator.
+func (c *completer) completeArg(comparable []Token, comparator TokenType) {
+	t, ok := c.operandType(comparable)
+	if ok && comparator == TokenTypeHas {
+		switch t.GetTypeKind().(type) {
+		case *expr.Type_MapType_:
+			if path, ok := memberPath(comparable); ok {
+				for _, key := range c.mapKeys[strings.Join(path, ".")] {
+					c.add(Completion{Kind: CompletionKindMapKey, Text: key, Type: t.GetMapType().GetKeyType()})
+				}
+			}
+			t = t.GetMapType().GetKeyType()
+		case *expr.Type_ListType_:
+			t = t.GetListType().GetElemType()
+		}
+	}
+	c.completeConstants(t)
+	c.completeIdents(t)
+	c.completeFunctions(t)
+}
+
+// completeComparators adds the comparators with an overload for the provided comparable type, or all comparators if
+// the type is nil.
+func (c *completer) completeComparators(t *expr.Type) {
+	for _, comparator := range []TokenType{
+		TokenTypeEquals,
+		TokenTypeNotEquals,
+		TokenTypeLessThan,
+		TokenTypeLessEquals,
+		TokenTypeGreaterThan,
+		TokenTypeGreaterEquals,
+		TokenTypeHas,
+	} {
+		function, ok := c.declarations.LookupFunction(comparator.Function())
+		if !ok {
+			continue
+		}
+		for _, overload := range function.GetFunction().GetOverloads() {
+			if t == nil || len(overload.GetParams()) == 2 &&
+				unifyType(overload.GetParams()[0], t, overload.GetTypeParams(), map[string]*expr.Type{}) {
+				c.add(Completion{Kind: CompletionKindComparator, Text: string(comparator)})
+				break
+			}
+		}
+	}
+}
+
+// completeConstants adds the declared constants of the provided type, or all constants if the type is nil.
+func (c *completer) completeConstants(t *expr.Type) {
+	for _, decl := range c.declarations.Idents() {
+		if decl.GetIdent().GetValue() == nil || t != nil && !proto.Equal(t, decl.GetIdent().GetType()) {
+			continue
+		}
+		c.add(Completion{
+			Kind: CompletionKindEnumConstant,
+			Text: decl.GetName(),
+			Type: decl.GetIdent().GetType(),
+			Doc:  decl.GetIdent().GetDoc(),
+		})
+	}
+}
+
+// completeIdents adds the declared idents and aliases of the provided type, or all idents if the type is nil.
+func (c *completer) completeIdents(t *expr.Type) {
+	for _, decl := range c.declarations.Idents() {
+		if decl.GetIdent().GetValue() != nil || t != nil && !proto.Equal(t, decl.GetIdent().GetType()) {
+			continue
+		}
+		if _, _, ok := c.declarations.lookupDeprecation(decl.GetName()); ok {
+			continue
+		}
+		c.add(Completion{
+			Kind: CompletionKindIdent,
+			Text: decl.GetName(),
+			Type: decl.GetIdent().GetType(),
+			Doc:  decl.GetIdent().GetDoc(),
+		})
+	}
+	aliases := c.declarations.Aliases()
+	for _, alias := range sortedKeys(aliases) {
+		target, ok := c.declarations.LookupIdent(aliases[alias])
+		if !ok || t != nil && !proto.Equal(t, target.GetIdent().GetType()) {
+			continue
+		}
+		if _, _, ok := c.declarations.lookupDeprecation(alias); ok {
+			continue
+		}
+		c.add(Completion{
+			Kind: CompletionKindIdent,
+			Text: alias,
+			Type: target.GetIdent().GetType(),
+			Doc:  target.GetIdent().GetDoc(),
+		})
+	}
+}
+
+// completeFunctions adds the declared functions with an overload of the provided result type, or all functions if
+// the type is nil. Operators are not completed.
+func (c *completer) completeFunctions(t *expr.Type) {
+	for _, decl := range c.declarations.Functions() {
+		if !isFunctionName(decl.GetName()) {
+			continue
+		}
+		resultType, ok := functionResultType(decl)
+		if t != nil {
+			if !hasOverloadWithResultType(decl, t) {
+				continue
+			}
+			resultType, ok = t, true
+		}
+		completion := Completion{Kind: CompletionKindFunction, Text: decl.GetName() + "("}
+		if ok {
+			completion.Type = resultType
+		}
+		for _, overload := range decl.GetFunction().GetOverloads() {
+			if overload.GetDoc() != "" {
+				completion.Doc = overload.GetDoc()
+				break
+			}
+		}
+		c.add(completion)
+	}
+}
+
+func (c *completer) addKeyword(keyword TokenType) {
+	c.add(Completion{Kind: CompletionKindKeyword, Text: string(keyword)})
+}
+
+// add adds the provided completion, unless it does not start with the prefix or has already been added.
+func (c *completer) add(completion Completion) {
+	if !strings.HasPrefix(completion.Text, c.prefix) {
+		return
+	}
+	completion.Span = c.span
+	key := Completion{Kind: completion.Kind, Text: completion.Text}
+	if c.seen[key] {
+		return
+	}
+	c.seen[key] = true
+	c.result = append(c.result, completion)
+}
+
+// operandType returns the type of the operand at the end of the provided tokens, if it can be determined.
+func (c *completer) operandType(tokens []Token) (*expr.Type, bool) {
+	tokens = trimWhitespace(tokens)
+	if n := len(tokens); n > 0 && tokens[n-1].Type == TokenTypeRightParen {
+		start := operandStart(tokens)
+		name, ok := functionName(tokens[start:])
+		if !ok {
+			return nil, false
+		}
+		function, ok := c.declarations.LookupFunction(name)
+		if !ok {
+			return nil, false
+		}
+		return functionResultType(function)
+	}
+	path, ok := memberPath(tokens)
+	if !ok {
+		return nil, false
+	}
+	return c.pathType(path)
+}
+
+// pathType returns the type of the member expression with the provided path, resolving aliases and selecting fields
+// of declared messages and values of maps.
+func (c *completer) pathType(path []string) (*expr.Type, bool) {
+	for i := len(path); i > 0; i-- {
+		name := strings.Join(path[:i], ".")
+		if target, ok := c.declarations.resolveAlias(name); ok {
+			name = target
+		}
+		decl, ok := c.declarations.LookupIdent(name)
+		if !ok || decl.GetIdent().GetValue() != nil {
+			continue
+		}
+		t := decl.GetIdent().GetType()
+		for _, field := range path[i:] {
+			if t, ok = c.selectType(t, field); !ok {
+				return nil, false
+			}
+		}
+		return t, true
+	}
+	return nil, false
+}
+
+// selectType returns the type of selecting the provided field of a value of the provided type.
+func (c *completer) selectType(t *expr.Type, field string) (*expr.Type, bool) {
+	switch t.GetTypeKind().(type) {
+	case *expr.Type_MapType_:
+		return t.GetMapType().GetValueType(), true
+	case *expr.Type_MessageType:
+		message, ok := c.declarations.resolveMessage(t.GetMessageType())
+		if !ok {
+			return nil, false
+		}
+		fieldDescriptor := message.Fields().ByName(protoreflect.Name(field))
+		if fieldDescriptor == nil {
+			return nil, false
+		}
+		return fieldType(fieldDescriptor)
+	default:
+		return nil, false
+	}
+}
+
+// lexCompletionTokens lexes all tokens of the provided filter, and returns false if the filter can not be lexed.
+func lexCompletionTokens(filter string) ([]Token, bool) {
+	var lexer Lexer
+	lexer.Init(filter)
+	var tokens []Token
+	for {
+		token, err := lexer.Lex()
+		if err != nil {
+			return tokens, errors.Is(err, io.EOF)
+		}
+		tokens = append(tokens, token)
+	}
+}
+
+// memberPath returns the path of the member expression at the end of the provided tokens.
+func memberPath(tokens []Token) ([]string, bool) {
+	var path []string
+	i := len(tokens) - 1
+	for ; i >= 0; i -= 2 {
+		if !tokens[i].Type.IsField() {
+			return nil, false
+		}
+		path = append(path, tokens[i].Unquote())
+		if i == 0 || tokens[i-1].Type != TokenTypeDot {
+			break
+		}
+	}
+	if i < 0 || !tokens[i].Type.IsValue() {
+		return nil, false
+	}
+	for left, right := 0, len(path)-1; left < right; left, right = left+1, right-1 {
+		path[left], path[right] = path[right], path[left]
+	}
+	return path, true
+}
+
+// operandStart returns the index of the first token of the operand at the end of the provided tokens.
+func operandStart(tokens []Token) int {
+	i := len(tokens) - 1
+	if i >= 0 && tokens[i].Type == TokenTypeRightParen {
+		for depth := 0; i >= 0; i-- {
+			switch tokens[i].Type {
+			case TokenTypeRightParen:
+				depth++
+			case TokenTypeLeftParen:
+				depth--
+			}
+			if depth == 0 {
+				break
+			}
+		}
+		if i <= 0 || !tokens[i-1].Type.IsName() {
+			return max(i, 0)
+		}
+		i--
+	}
+	for i > 0 && tokens[i-1].Type == TokenTypeDot && i > 1 && tokens[i-2].Type.IsField() {
+		i -= 2
+	}
+	for i > 0 && tokens[i-1].Type == TokenTypeMinus {
+		i--
+	}
+	return max(i, 0)
+}
+
+// functionName returns the name of the function call of the provided tokens.
+func functionName(tokens []Token) (string, bool) {
+	var name strings.Builder
+	for _, token := range tokens {
+		switch {
+		case token.Type == TokenTypeLeftParen:
+			return name.String(), name.Len() > 0
+		case token.Type.IsName(), token.Type == TokenTypeDot:
+			_, _ = name.WriteString(token.Value)
+		default:
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// functionResultType returns the result type of the provided function, if all its overloads have the same result
+// type.
+func functionResultType(function *expr.Decl) (*expr.Type, bool) {
+	var result *expr.Type
+	for _, overload := range function.GetFunction().GetOverloads() {
+		if result != nil && !proto.Equal(result, overload.GetResultType()) {
+			return nil, false
+		}
+		result = overload.GetResultType()
+	}
+	return result, result != nil
+}
+
+func hasOverloadWithResultType(function *expr.Decl, t *expr.Type) bool {
+	for _, overload := range function.GetFunction().GetOverloads() {
+		if proto.Equal(t, overload.GetResultType()) {
+			return true
+		}
+	}
+	return false
+}
+
+// isFunctionName returns true if the provided function name can be used in a function call.
+func isFunctionName(name string) bool {
+	if name == "" || name == FunctionFuzzyAnd || TokenType(name).IsKeyword() {
+		return false
+	}
+	for _, r := range name {
+		if !isText(r) && r != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// isOperandEnd returns true if a token of the provided type can end an operand.
+func isOperandEnd(t TokenType) bool {
+	switch t {
+	case TokenTypeText, TokenTypeString, TokenTypeNumber, TokenTypeHexNumber, TokenTypeRightParen:
+		return true
+	default:
+		return false
+	}
+}
+
+func trimWhitespace(tokens []Token) []Token {
+	for len(tokens) > 0 && tokens[len(tokens)-1].Type == TokenTypeWhitespace {
+		tokens = tokens[:len(tokens)-1]
+	}
+	return tokens
+}
@@ -0,0 +1,212 @@
+package filtering
+
+import (
+	"strings"
+	"testing"
+
+	freightv1 "go.einride.tech/aip/proto/gen/einride/example/freight/v1"
+	syntaxv1 "go.einride.tech/aip/proto/gen/einride/example/syntax/v1"
+	"gotest.tools/v3/assert"
+)
+
+func TestComplete(t *testing.T) {
+	t.Parallel()
+	declarations, err := NewDeclarations(
+		DeclareStandardFunctions(),
+		DeclareIdent("create_time", TypeTimestamp),
+		DeclareIdent("display_name", TypeString),
+		DeclareIdent("labels", TypeMap(TypeString, TypeString)),
+		DeclareIdent("lat_lng.latitude", TypeFloat),
+		DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+		DeclareMessageIdent("site", (&freightv1.Site{}).ProtoReflect().Descriptor()),
+		DeclareAlias("name", "display_name"),
+		DeclareAlias("created", "create_time"),
+		DeprecateIdent("created", "use create_time"),
+		DescribeIdent("create_time", "The creation time of the resource."),
+	)
+	assert.NilError(t, err)
+	restriction := []string{
+		"IDENT create_time",
+		"IDENT display_name",
+		"IDENT enum",
+		"IDENT labels",
+		"IDENT lat_lng.latitude",
+		"IDENT site",
+		"IDENT name",
+		"FUNCTION duration(",
+		"FUNCTION timestamp(",
+		"KEYWORD NOT",
+	}
+	for _, tt := range []struct {
+		name     string
+		filter   string
+		expected []string
+	}{
+		{
+			name:     "empty",
+			filter:   "|",
+			expected: restriction,
+		},
+		{
+			name:     "partial ident",
+			filter:   "cr|",
+			expected: []string{"IDENT create_time"},
+		},
+		{
+			name:     "partial ident in composite",
+			filter:   "(d|",
+			expected: []string{"IDENT display_name", "FUNCTION duration("},
+		},
+		{
+			name:     "after AND",
+			filter:   `display_name = "foo" AND |`,
+			expected: restriction,
+		},
+		{
+			name:   "comparators of timestamp",
+			filter: "create_time |",
+			expected: append([]string{
+				"COMPARATOR =",
+				"COMPARATOR !=",
+				"COMPARATOR <",
+				"COMPARATOR <=",
+				"COMPARATOR >",
+				"COMPARATOR >=",
+				"KEYWORD AND",
+				"KEYWORD OR",
+			}, restriction...),
+		},
+		{
+			name:     "comparators of enum",
+			filter:   "enum |=",
+			expected: append([]string{"COMPARATOR =", "COMPARATOR !=", "KEYWORD AND", "KEYWORD OR"}, restriction...),
+		},
+		{
+			name:   "enum constants",
+			filter: "enum = |",
+			expected: []string{
+				"ENUM_CONSTANT ENUM_ONE",
+				"ENUM_CONSTANT ENUM_TWO",
+				"ENUM_CONSTANT ENUM_UNSPECIFIED",
+				"IDENT enum",
+			},
+		},
+		{
+			name:     "partial enum constant",
+			filter:   "enum = ENUM_T|",
+			expected: []string{"ENUM_CONSTANT ENUM_TWO"},
+		},
+		{
+			name:     "timestamp arg",
+			filter:   "create_time > |",
+			expected: []string{"IDENT create_time", "FUNCTION timestamp("},
+		},
+		{
+			name:     "after arg",
+			filter:   `create_time > timestamp("2022-01-01T00:00:00Z") |`,
+			expected: append([]string{"KEYWORD AND", "KEYWORD OR"}, restriction...),
+		},
+		{
+			name:     "partial keyword",
+			filter:   "enum = ENUM_ONE A|",
+			expected: []string{"KEYWORD AND"},
+		},
+		{
+			name:   "message fields",
+			filter: "site.|",
+			expected: []string{
+				"IDENT name",
+				"IDENT create_time",
+				"IDENT update_time",
+				"IDENT delete_time",
+				"IDENT display_name",
+				"IDENT lat_lng",
+				"IDENT personnel_count",
+			},
+		},
+		{
+			name:     "nested message fields",
+			filter:   "site.lat_lng.l|",
+			expected: []string{"IDENT latitude", "IDENT longitude"},
+		},
+		{
+			name:   "comparators of message field",
+			filter: "site.personnel_count |",
+			expected: append([]string{
+				"COMPARATOR =",
+				"COMPARATOR !=",
+				"COMPARATOR <",
+				"COMPARATOR <=",
+				"COMPARATOR >",
+				"COMPARATOR >=",
+				"KEYWORD AND",
+				"KEYWORD OR",
+			}, restriction...),
+		},
+		{
+			name:     "dotted ident",
+			filter:   "lat_lng.|",
+			expected: []string{"IDENT latitude"},
+		},
+		{
+			name:     "map keys",
+			filter:   "labels.|",
+			expected: []string{"MAP_KEY env", "MAP_KEY team"},
+		},
+		{
+			name:     "has map keys",
+			filter:   "labels:|",
+			expected: []string{"MAP_KEY env", "MAP_KEY team", "IDENT display_name", "IDENT name"},
+		},
+		{
+			name:     "alias",
+			filter:   "name = |",
+			expected: []string{"IDENT display_name", "IDENT name"},
+		},
+		{
+			name:     "in string",
+			filter:   `display_name = "fo|`,
+			expected: nil,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			offset := strings.IndexByte(tt.filter, '|')
+			filter := tt.filter[:offset] + tt.filter[offset+1:]
+			completions := Complete(filter, offset, declarations, WithMapKeys("labels", "env", "team"))
+			var actual []string
+			for _, completion := range completions {
+				actual = append(actual, string(completion.Kind)+" "+completion.Text)
+			}
+			assert.DeepEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestComplete_span(t *testing.T) {
+	t.Parallel()
+	declarations, err := NewDeclarations(
+		DeclareStandardFunctions(),
+		DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+		DeclareIdent("create_time", TypeTimestamp),
+		DescribeIdent("create_time", "The creation time of the resource."),
+	)
+	assert.NilError(t, err)
+	completions := Complete("enum = ENUM_ONE AND", len("enum = ENUM_O"), declarations)
+	assert.Equal(t, 1, len(completions))
+	assert.Equal(t, "ENUM_ONE", completions[0].Text)
+	assert.Equal(t, Span{
+		Start: Position{Offset: 7, Line: 1, Column: 8},
+		End:   Position{Offset: 15, Line: 1, Column: 16},
+	}, completions[0].Span)
+	completions = Complete("create_time > ", len("create_time > "), declarations)
+	assert.Equal(t, 2, len(completions))
+	assert.Equal(t, "create_time", completions[0].Text)
+	assert.Equal(t, "The creation time of the resource.", completions[0].Doc)
+	assert.Equal(t, TypeTimestamp, completions[0].Type)
+	assert.Equal(t, Span{
+		Start: Position{Offset: 14, Line: 1, Column: 15},
+		End:   Position{Offset: 14, Line: 1, Column: 15},
+	}, completions[0].Span)
+}
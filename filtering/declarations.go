@@ -2,10 +2,13 @@ package filtering
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
 )
 
 // NewStringConstant creates a new string constant.
@@ -38,6 +41,23 @@ func NewFunctionOverload(id string, result *expr.Type, params ...*expr.Type) *ex
 	}
 }
 
+// NewParameterizedFunctionOverload creates a new function overload with type parameters.
+//
+// The type parameters can be used in the result and parameter types with TypeParam.
+func NewParameterizedFunctionOverload(
+	id string,
+	typeParams []string,
+	result *expr.Type,
+	params ...*expr.Type,
+) *expr.Decl_FunctionDecl_Overload {
+	return &expr.Decl_FunctionDecl_Overload{
+		OverloadId: id,
+		TypeParams: typeParams,
+		ResultType: result,
+		Params:     params,
+	}
+}
+
 // NewIdentDeclaration creates a new ident declaration.
 func NewIdentDeclaration(name string, identType *expr.Type) *expr.Decl {
 	return &expr.Decl{
@@ -68,12 +88,23 @@ type Declarations struct {
 	idents    map[string]*expr.Decl
 	functions map[string]*expr.Decl
 	enums     map[string]protoreflect.EnumType
+	messages  map[string]protoreflect.MessageDescriptor
+	// enumTypes contains all declared enum types, by full name.
+	enumTypes map[string]protoreflect.EnumType
+	// aliases maps alias names to the names they resolve to.
+	aliases map[string]string
+	// deprecations maps deprecated names to the reason for the deprecation.
+	deprecations map[string]string
+	// params are the types of template parameters, by name without the @ prefix.
+	params map[string]*expr.Type
+	// customOverloads contains the IDs of the overloads declared with DeclareFunction.
+	customOverloads map[string]bool
 }
 
 // DeclarationOption configures Declarations.
 type DeclarationOption func(*Declarations) error
 
-// DeclareStandardFunction is a DeclarationOption that declares all standard functions and their overloads.
+// DeclareStandardFunctions is a DeclarationOption that declares all standard functions and their overloads.
 func DeclareStandardFunctions() DeclarationOption {
 	return func(declarations *Declarations) error {
 		for _, declaration := range StandardFunctionDeclarations() {
@@ -85,9 +116,58 @@ func DeclareStandardFunctions() DeclarationOption {
 	}
 }
 
+// DeclareStandardConstants is a DeclarationOption that declares the standard literal constants `true`, `false` and
+// `null`, as in `archived = false` and `weight = null`.
+func DeclareStandardConstants() DeclarationOption {
+	return func(declarations *Declarations) error {
+		for _, declaration := range StandardConstantDeclarations() {
+			if err := declarations.declare(declaration); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// DeclareStringFunctions is a DeclarationOption that declares the optional standard string functions `matches`,
+// `startsWith`, `endsWith`, `lower` and `contains`, and their overloads.
+func DeclareStringFunctions() DeclarationOption {
+	return func(declarations *Declarations) error {
+		for _, declaration := range StringFunctionDeclarations() {
+			if err := declarations.declare(declaration); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
 // DeclareFunction is a DeclarationOption that declares a single function and its overloads.
+//
+// The overloads are custom overloads, which need implementations registered with a FunctionRegistry, unless they are
+// overloads declared by DeclareStandardFunctions or DeclareStringFunctions.
 func DeclareFunction(name string, overloads ...*expr.Decl_FunctionDecl_Overload) DeclarationOption {
 	return func(declarations *Declarations) error {
+		if err := declarations.declareFunction(name, overloads...); err != nil {
+			return err
+		}
+		for _, overload := range overloads {
+			declarations.customOverloads[overload.GetOverloadId()] = true
+		}
+		return nil
+	}
+}
+
+// DeclareStandardFunctionOverloads is a DeclarationOption that declares overloads of a standard function, such as
+// `=`, that are implemented by the library's implementation of the function.
+//
+// Unlike DeclareFunction, the overloads need no registered implementations. This is used by macros that declare
+// overloads for filters that are rewritten before they are evaluated.
+func DeclareStandardFunctionOverloads(name string, overloads ...*expr.Decl_FunctionDecl_Overload) DeclarationOption {
+	return func(declarations *Declarations) error {
+		if !isStandardFunction(name) {
+			return fmt.Errorf("%s is not a standard function", name)
+		}
 		return declarations.declareFunction(name, overloads...)
 	}
 }
@@ -99,18 +179,81 @@ func DeclareIdent(name string, t *expr.Type) DeclarationOption {
 	}
 }
 
+// DeclareEnumIdent is a DeclarationOption that declares a single ident with an enum type.
 func DeclareEnumIdent(name string, enumType protoreflect.EnumType) DeclarationOption {
 	return func(declarations *Declarations) error {
 		return declarations.declareEnumIdent(name, enumType)
 	}
 }
 
+// DeclareMessageIdent is a DeclarationOption that declares a single ident with a message type.
+//
+// The fields of the message, and of all messages reachable from it, can be selected with member expressions such
+// as `shipment.origin_site.display_name`. The enum types of all reachable enum fields are declared as well.
+func DeclareMessageIdent(name string, message protoreflect.MessageDescriptor) DeclarationOption {
+	return func(declarations *Declarations) error {
+		return declarations.declareMessageIdent(name, message)
+	}
+}
+
+// DeclareAlias is a DeclarationOption that declares an alias for a declared ident.
+//
+// The checker resolves the alias, and member expressions of the alias such as `origin.display_name`, to the target
+// and rewrites the expression to use the target name. The original expression is recorded in the macro calls of the
+// source info.
+func DeclareAlias(alias, target string) DeclarationOption {
+	return func(declarations *Declarations) error {
+		return declarations.declareAlias(alias, target)
+	}
+}
+
+// DescribeIdent is a DeclarationOption that sets the documentation of a declared ident.
+func DescribeIdent(name, doc string) DeclarationOption {
+	return func(declarations *Declarations) error {
+		decl, ok := declarations.idents[name]
+		if !ok {
+			return fmt.Errorf("description of undeclared ident %s", name)
+		}
+		decl.GetIdent().Doc = doc
+		return nil
+	}
+}
+
+// DeprecateIdent is a DeclarationOption that deprecates a declared ident or alias.
+//
+// Deprecated names are still accepted by the checker, but each usage is reported as a Warning.
+func DeprecateIdent(name, reason string) DeclarationOption {
+	return func(declarations *Declarations) error {
+		return declarations.deprecateIdent(name, reason)
+	}
+}
+
+// DeclareParam is a DeclarationOption that declares a template parameter, referenced as `@name` in templates parsed
+// by ParseTemplate.
+//
+// Parameters are not idents, and can not be referenced by filters parsed by ParseFilter.
+func DeclareParam(name string, t *expr.Type) DeclarationOption {
+	return func(declarations *Declarations) error {
+		if _, ok := declarations.params[name]; ok {
+			return fmt.Errorf("redeclaration of param @%s", name)
+		}
+		declarations.params[name] = t
+		return nil
+	}
+}
+
 // NewDeclarations creates a new set of Declarations for filter expression type-checking.
 func NewDeclarations(opts ...DeclarationOption) (*Declarations, error) {
 	d := &Declarations{
-		idents:    make(map[string]*expr.Decl),
-		functions: make(map[string]*expr.Decl),
-		enums:     make(map[string]protoreflect.EnumType),
+		idents:          make(map[string]*expr.Decl),
+		functions:       make(map[string]*expr.Decl),
+		enums:           make(map[string]protoreflect.EnumType),
+		messages:        make(map[string]protoreflect.MessageDescriptor),
+		enumTypes:       make(map[string]protoreflect.EnumType),
+		aliases:         make(map[string]string),
+		deprecations:    make(map[string]string),
+		params:          make(map[string]*expr.Type),
+		customOverloads: make(map[string]bool),
 	}
 	for _, opt := range opts {
 		if err := opt(d); err != nil {
@@ -135,14 +278,145 @@ func (d *Declarations) LookupEnumIdent(name string) (protoreflect.EnumType, bool
 	return result, ok
 }
 
+// Idents returns all declared idents, including constants, ordered by name.
+func (d *Declarations) Idents() []*expr.Decl {
+	return sortedDecls(d.idents)
+}
+
+// Functions returns all declared functions, ordered by name.
+func (d *Declarations) Functions() []*expr.Decl {
+	return sortedDecls(d.functions)
+}
+
+// EnumTypes returns all declared enum types, including enum types of fields of declared messages, ordered by full
+// name.
+func (d *Declarations) EnumTypes() []protoreflect.EnumType {
+	result := make([]protoreflect.EnumType, 0, len(d.enumTypes))
+	for _, enumType := range d.enumTypes {
+		result = append(result, enumType)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Descriptor().FullName() < result[j].Descriptor().FullName()
+	})
+	return result
+}
+
+// Messages returns all message types declared by DeclareMessageIdent, ordered by full name.
+func (d *Declarations) Messages() []protoreflect.MessageDescriptor {
+	result := make([]protoreflect.MessageDescriptor, 0, len(d.messages))
+	for _, message := range d.messages {
+		result = append(result, message)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FullName() < result[j].FullName()
+	})
+	return result
+}
+
+// Aliases returns all aliases declared by DeclareAlias, as a map from alias to target.
+func (d *Declarations) Aliases() map[string]string {
+	result := make(map[string]string, len(d.aliases))
+	for alias, target := range d.aliases {
+		result[alias] = target
+	}
+	return result
+}
+
+// Deprecations returns all idents deprecated by DeprecateIdent, as a map from name to reason.
+func (d *Declarations) Deprecations() map[string]string {
+	result := make(map[string]string, len(d.deprecations))
+	for name, reason := range d.deprecations {
+		result[name] = reason
+	}
+	return result
+}
+
+// LookupParam looks up the type of a template parameter declared by DeclareParam.
+func (d *Declarations) LookupParam(name string) (*expr.Type, bool) {
+	result, ok := d.params[name]
+	return result, ok
+}
+
+// LookupMessage looks up a message type declared by DeclareMessageIdent by its full name.
+func (d *Declarations) LookupMessage(fullName string) (protoreflect.MessageDescriptor, bool) {
+	result, ok := d.messages[fullName]
+	return result, ok
+}
+
+// resolveMessage looks up a declared message type, falling back to the global registry of protobuf files.
+func (d *Declarations) resolveMessage(fullName string) (protoreflect.MessageDescriptor, bool) {
+	if message, ok := d.LookupMessage(fullName); ok {
+		return message, true
+	}
+	descriptor, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(fullName))
+	if err != nil {
+		return nil, false
+	}
+	message, ok := descriptor.(protoreflect.MessageDescriptor)
+	return message, ok
+}
+
 func (d *Declarations) declareIdent(name string, t *expr.Type) error {
 	if _, ok := d.idents[name]; ok {
 		return fmt.Errorf("redeclaration of %s", name)
 	}
+	if _, ok := d.aliases[name]; ok {
+		return fmt.Errorf("redeclaration of alias %s", name)
+	}
 	d.idents[name] = NewIdentDeclaration(name, t)
 	return nil
 }
 
+func (d *Declarations) declareAlias(alias, target string) error {
+	if _, ok := d.idents[alias]; ok {
+		return fmt.Errorf("redeclaration of %s", alias)
+	}
+	if _, ok := d.aliases[alias]; ok {
+		return fmt.Errorf("redeclaration of alias %s", alias)
+	}
+	if _, ok := d.idents[target]; !ok {
+		return fmt.Errorf("alias %s: undeclared ident %s", alias, target)
+	}
+	d.aliases[alias] = target
+	return nil
+}
+
+func (d *Declarations) deprecateIdent(name, reason string) error {
+	_, isIdent := d.idents[name]
+	_, isAlias := d.aliases[name]
+	if !isIdent && !isAlias {
+		return fmt.Errorf("deprecation of undeclared ident %s", name)
+	}
+	d.deprecations[name] = reason
+	return nil
+}
+
+// resolveAlias resolves the provided name, or its longest aliased parent, to the target of the alias.
+func (d *Declarations) resolveAlias(name string) (string, bool) {
+	if target, ok := d.aliases[name]; ok {
+		return target, true
+	}
+	for i := strings.LastIndexByte(name, '.'); i > 0; i = strings.LastIndexByte(name[:i], '.') {
+		if target, ok := d.aliases[name[:i]]; ok {
+			return target + name[i:], true
+		}
+	}
+	return "", false
+}
+
+// lookupDeprecation looks up the deprecation of the provided name, or of its longest deprecated parent.
+func (d *Declarations) lookupDeprecation(name string) (deprecatedName, reason string, ok bool) {
+	if reason, ok := d.deprecations[name]; ok {
+		return name, reason, true
+	}
+	for i := strings.LastIndexByte(name, '.'); i > 0; i = strings.LastIndexByte(name[:i], '.') {
+		if reason, ok := d.deprecations[name[:i]]; ok {
+			return name[:i], reason, true
+		}
+	}
+	return "", "", false
+}
+
 func (d *Declarations) declareConstant(name string, constantType *expr.Type, constantValue *expr.Constant) error {
 	constantDecl := NewConstantDeclaration(name, constantType, constantValue)
 	if existingIdent, ok := d.idents[name]; ok {
@@ -160,10 +434,16 @@ func (d *Declarations) declareEnumIdent(name string, enumType protoreflect.EnumT
 		return fmt.Errorf("redeclaration of %s", name)
 	}
 	d.enums[name] = enumType
-	enumIdentType := TypeEnum(enumType)
-	if err := d.declareIdent(name, enumIdentType); err != nil {
+	if err := d.declareIdent(name, TypeEnum(enumType)); err != nil {
 		return err
 	}
+	return d.declareEnumType(enumType)
+}
+
+// declareEnumType declares the equality overloads and value constants of the provided enum type.
+func (d *Declarations) declareEnumType(enumType protoreflect.EnumType) error {
+	d.enumTypes[string(enumType.Descriptor().FullName())] = enumType
+	enumIdentType := TypeEnum(enumType)
 	for _, fn := range []string{
 		FunctionEquals,
 		FunctionNotEquals,
@@ -185,6 +465,52 @@ func (d *Declarations) declareEnumIdent(name string, enumType protoreflect.EnumT
 	return nil
 }
 
+// isEnumOverload returns true if the overload with the provided ID is an equality overload of a declared enum type.
+func (d *Declarations) isEnumOverload(overloadID string) bool {
+	for name := range d.enumTypes {
+		if overloadID == FunctionEquals+"_"+name || overloadID == FunctionNotEquals+"_"+name {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Declarations) declareMessageIdent(name string, message protoreflect.MessageDescriptor) error {
+	if err := d.declareIdent(name, TypeMessage(message)); err != nil {
+		return err
+	}
+	return d.declareMessageType(message)
+}
+
+// declareMessageType declares the provided message type and all message and enum types reachable from it.
+func (d *Declarations) declareMessageType(message protoreflect.MessageDescriptor) error {
+	if _, ok := d.messages[string(message.FullName())]; ok {
+		return nil
+	}
+	d.messages[string(message.FullName())] = message
+	fields := message.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if field.IsMap() {
+			field = field.MapValue()
+		}
+		switch field.Kind() {
+		case protoreflect.EnumKind:
+			if err := d.declareEnumType(fieldEnumType(field)); err != nil {
+				return err
+			}
+		case protoreflect.MessageKind:
+			if _, ok := wellKnownType(field.Message()); ok {
+				continue
+			}
+			if err := d.declareMessageType(field.Message()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (d *Declarations) declareFunction(name string, overloads ...*expr.Decl_FunctionDecl_Overload) error {
 	decl, ok := d.functions[name]
 	if !ok {
@@ -207,6 +533,27 @@ NewOverloadLoop:
 	return nil
 }
 
+// isStandardFunction returns true if the function with the provided name is declared by DeclareStandardFunctions.
+func isStandardFunction(name string) bool {
+	for _, decl := range StandardFunctionDeclarations() {
+		if decl.GetName() == name {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedDecls(decls map[string]*expr.Decl) []*expr.Decl {
+	result := make([]*expr.Decl, 0, len(decls))
+	for _, decl := range decls {
+		result = append(result, decl)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].GetName() < result[j].GetName()
+	})
+	return result
+}
+
 func (d *Declarations) declare(decl *expr.Decl) error {
 	switch decl.GetDeclKind().(type) {
 	case *expr.Decl_Function:
@@ -6,14 +6,94 @@ import (
 	"strings"
 )
 
-type filterError interface {
-	Position() Position
+// ErrorCode is a stable machine-readable code for a filter error.
+type ErrorCode string
+
+const (
+	// ErrorCodeLexical is the code for errors when lexing a filter, such as unterminated strings.
+	ErrorCodeLexical ErrorCode = "LEXICAL_ERROR"
+	// ErrorCodeSyntax is the code for errors when parsing a filter, such as unexpected tokens.
+	ErrorCodeSyntax ErrorCode = "SYNTAX_ERROR"
+	// ErrorCodeType is the code for errors when type-checking a filter, such as undeclared identifiers.
+	ErrorCodeType ErrorCode = "TYPE_ERROR"
+	// ErrorCodeLimitExceeded is the code for filters that exceed a configured parser limit.
+	ErrorCodeLimitExceeded ErrorCode = "LIMIT_EXCEEDED"
+)
+
+// Span is a range of a filter expression.
+type Span struct {
+	// Start is the position of the first character in the span.
+	Start Position
+	// End is the position after the last character in the span.
+	End Position
+}
+
+// Error is implemented by all errors from lexing, parsing and type-checking filters.
+//
+// Errors are wrapped by errors describing the enclosing parts of the filter, and errors.As can be used to find the
+// outermost Error. Use RenderError to render the innermost Error.
+type Error interface {
+	error
+	// Code returns the code of the error.
+	Code() ErrorCode
+	// Message returns the message of the error, without any wrapped errors.
 	Message() string
+	// Filter returns the filter with the error, or the empty string if unknown.
 	Filter() string
+	// Position returns the start position of the error, or the zero value if unknown.
+	Position() Position
+	// Span returns the span of the tokens with the error, or the zero value if unknown.
+	Span() Span
+}
+
+// RenderError renders the innermost Error in the provided error chain as the line of the filter with the error,
+// with carets under the offending tokens.
+//
+// If the error has no known position, only the message is rendered.
+func RenderError(err error) string {
+	innermost, ok := innermostError(err)
+	if !ok {
+		return ""
+	}
+	span := innermost.Span()
+	if span.Start.Line == 0 || innermost.Filter() == "" {
+		return innermost.Message()
+	}
+	lines := strings.Split(innermost.Filter(), "\n")
+	if int(span.Start.Line) > len(lines) {
+		return innermost.Message()
+	}
+	line := lines[span.Start.Line-1]
+	carets := 1
+	if span.End.Line == span.Start.Line && span.End.Column > span.Start.Column {
+		carets = int(span.End.Column - span.Start.Column)
+	}
+	var b strings.Builder
+	_, _ = b.WriteString(line)
+	_ = b.WriteByte('\n')
+	_, _ = b.WriteString(strings.Repeat(" ", int(span.Start.Column-1)))
+	_, _ = b.WriteString(strings.Repeat("^", carets))
+	_ = b.WriteByte(' ')
+	_, _ = b.WriteString(innermost.Message())
+	_, _ = b.WriteString(" (")
+	_, _ = b.WriteString(span.Start.String())
+	_ = b.WriteByte(')')
+	return b.String()
+}
+
+// innermostError returns the innermost Error in the provided error chain.
+func innermostError(err error) (Error, bool) {
+	var innermost Error
+	for ; err != nil; err = errors.Unwrap(err) {
+		if filterErr, ok := err.(Error); ok {
+			innermost = filterErr
+		}
+	}
+	return innermost, innermost != nil
 }
 
 func appendFilterError(s *strings.Builder, err error) {
-	var errFilter filterError
+	var errFilter Error
 	if !errors.As(err, &errFilter) {
 		return
 	}
@@ -31,13 +111,34 @@ func appendFilterError(s *strings.Builder, err error) {
 	appendFilterError(s, errors.Unwrap(err))
 }
 
+// tokenSpan returns the span of the token at the provided position in the provided filter.
+func tokenSpan(filter string, position Position) Span {
+	if position.Line == 0 || int(position.Offset) > len(filter) {
+		return Span{}
+	}
+	var lexer Lexer
+	lexer.Init(filter[position.Offset:])
+	token, err := lexer.Lex()
+	if err != nil || token.Value == "" {
+		return Span{Start: position, End: position}
+	}
+	return Span{
+		Start: position,
+		End:   positionOf(filter, int(position.Offset)+len(token.Value)),
+	}
+}
+
 type lexError struct {
 	filter   string
 	position Position
 	message  string
 }
 
-var _ filterError = &lexError{}
+var _ Error = &lexError{}
+
+func (l *lexError) Code() ErrorCode {
+	return ErrorCodeLexical
+}
 
 func (l *lexError) Filter() string {
 	return l.filter
@@ -47,6 +148,10 @@ func (l *lexError) Position() Position {
 	return l.position
 }
 
+func (l *lexError) Span() Span {
+	return tokenSpan(l.filter, l.position)
+}
+
 func (l *lexError) Message() string {
 	return l.message
 }
@@ -62,6 +167,12 @@ type parseError struct {
 	err      error
 }
 
+var _ Error = &parseError{}
+
+func (p *parseError) Code() ErrorCode {
+	return ErrorCodeSyntax
+}
+
 func (p *parseError) Filter() string {
 	return p.filter
 }
@@ -70,6 +181,10 @@ func (p *parseError) Position() Position {
 	return p.position
 }
 
+func (p *parseError) Span() Span {
+	return tokenSpan(p.filter, p.position)
+}
+
 func (p *parseError) Message() string {
 	return p.message
 }
@@ -88,10 +203,38 @@ func (p *parseError) Error() string {
 }
 
 type typeError struct {
+	filter  string
+	span    Span
 	message string
 	err     error
 }
 
+var _ Error = &typeError{}
+
+func (t *typeError) Code() ErrorCode {
+	return ErrorCodeType
+}
+
+func (t *typeError) Filter() string {
+	return t.filter
+}
+
+func (t *typeError) Position() Position {
+	return t.span.Start
+}
+
+func (t *typeError) Span() Span {
+	return t.span
+}
+
+func (t *typeError) Message() string {
+	return t.message
+}
+
+func (t *typeError) Unwrap() error {
+	return t.err
+}
+
 func (t *typeError) Error() string {
 	if t.err != nil {
 		return fmt.Sprintf("%s: %v", t.message, t.err)
@@ -0,0 +1,109 @@
+package filtering
+
+import (
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestError(t *testing.T) {
+	t.Parallel()
+	declarations, err := NewDeclarations(
+		DeclareStandardFunctions(),
+		DeclareIdent("a", TypeInt),
+		DeclareIdent("b", TypeString),
+	)
+	assert.NilError(t, err)
+	for _, tt := range []struct {
+		filter           string
+		limits           Limits
+		expectedCode     ErrorCode
+		expectedSpan     Span
+		expectedRendered string
+	}{
+		{
+			filter:       `a = 1 AND b = "foo`,
+			expectedCode: ErrorCodeSyntax,
+			expectedSpan: Span{
+				Start: Position{Offset: 0, Line: 1, Column: 1},
+				End:   Position{Offset: 1, Line: 1, Column: 2},
+			},
+			expectedRendered: "a = 1 AND b = \"foo\n              ^ unterminated string (1:15)",
+		},
+		{
+			filter:       `a = 1 AND c = "foo"`,
+			expectedCode: ErrorCodeType,
+			expectedSpan: Span{
+				Start: Position{Offset: 0, Line: 1, Column: 1},
+				End:   Position{Offset: 19, Line: 1, Column: 20},
+			},
+			expectedRendered: "a = 1 AND c = \"foo\"\n          ^ undeclared identifier 'c' (1:11)",
+		},
+		{
+			filter:       "a = 1 AND\nb = 2",
+			expectedCode: ErrorCodeType,
+			expectedSpan: Span{
+				Start: Position{Offset: 0, Line: 1, Column: 1},
+				End:   Position{Offset: 15, Line: 2, Column: 6},
+			},
+			expectedRendered: "b = 2\n" +
+				"^^^^^ no matching overload found for calling '=' with [primitive:STRING primitive:INT64] (2:1)",
+		},
+		{
+			filter:       `a = 1 OR a = 2 OR a = 3`,
+			limits:       Limits{MaxRestrictions: 2},
+			expectedCode: ErrorCodeSyntax,
+			expectedSpan: Span{
+				Start: Position{Offset: 0, Line: 1, Column: 1},
+				End:   Position{Offset: 1, Line: 1, Column: 2},
+			},
+			expectedRendered: "a = 1 OR a = 2 OR a = 3\n                  ^ exceeded max restrictions of 2 (1:19)",
+		},
+	} {
+		tt := tt
+		t.Run(tt.filter, func(t *testing.T) {
+			t.Parallel()
+			_, err := ParseFilter(&mockRequest{filter: tt.filter}, declarations, WithLimits(tt.limits))
+			var filterErr Error
+			assert.Assert(t, errors.As(err, &filterErr))
+			assert.Equal(t, tt.expectedCode, filterErr.Code())
+			assert.Equal(t, tt.filter, filterErr.Filter())
+			assert.Equal(t, tt.expectedSpan, filterErr.Span())
+			assert.Equal(t, tt.expectedRendered, RenderError(err))
+		})
+	}
+}
+
+func TestError_checker(t *testing.T) {
+	t.Parallel()
+	declarations, err := NewDeclarations(DeclareStandardFunctions(), DeclareIdent("s", TypeString))
+	assert.NilError(t, err)
+	const filter = `s = 1`
+	var parser Parser
+	parser.Init(filter)
+	parsedExpr, err := parser.Parse()
+	assert.NilError(t, err)
+	t.Run("without filter", func(t *testing.T) {
+		var checker Checker
+		checker.Init(parsedExpr.GetExpr(), parsedExpr.GetSourceInfo(), declarations)
+		_, err := checker.Check()
+		var filterErr Error
+		assert.Assert(t, errors.As(err, &filterErr))
+		start := Position{Offset: 0, Line: 1, Column: 1}
+		assert.Equal(t, start, filterErr.Position())
+		assert.Equal(t, Span{Start: start, End: start}, filterErr.Span())
+	})
+	t.Run("with filter", func(t *testing.T) {
+		var checker Checker
+		checker.Init(parsedExpr.GetExpr(), parsedExpr.GetSourceInfo(), declarations, WithFilter(filter))
+		_, err := checker.Check()
+		var filterErr Error
+		assert.Assert(t, errors.As(err, &filterErr))
+		assert.Equal(t, filter, filterErr.Filter())
+		assert.Equal(t, Span{
+			Start: Position{Offset: 0, Line: 1, Column: 1},
+			End:   Position{Offset: 5, Line: 1, Column: 6},
+		}, filterErr.Span())
+	})
+}
@@ -0,0 +1,864 @@
+package filtering
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Evaluate evaluates the filter against the provided message, and returns true if the message matches the filter.
+//
+// Identifiers in the filter are resolved as fields of the message, and member expressions select fields of nested
+// messages or keys of maps. Enum fields are compared by value name. An empty filter matches all messages.
+//
+// Member expressions of repeated message fields select the field of each element, and restrictions on them are true
+// if any element matches, as in `line_items.sku = "ABC"`.
+//
+// Custom functions are called using the implementations in the FunctionRegistry provided by WithFunctions.
+func Evaluate(filter Filter, message proto.Message, opts ...EvaluateOption) (bool, error) {
+	if filter.CheckedExpr == nil {
+		return true, nil
+	}
+	e := evaluator{
+		referenceMap: filter.CheckedExpr.GetReferenceMap(),
+		typeMap:      filter.CheckedExpr.GetTypeMap(),
+		message:      message.ProtoReflect(),
+	}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	result, err := e.eval(filter.CheckedExpr.GetExpr())
+	if err != nil {
+		return false, fmt.Errorf("evaluate filter: %w", err)
+	}
+	b, ok := boolValue(result)
+	if !ok {
+		return false, fmt.Errorf("evaluate filter: non-bool result %v", result)
+	}
+	return b, nil
+}
+
+// EvaluateOption configures Evaluate.
+type EvaluateOption func(*evaluator)
+
+// WithFunctions is an EvaluateOption that calls custom functions using the implementations in the provided registry.
+func WithFunctions(registry *FunctionRegistry) EvaluateOption {
+	return func(e *evaluator) {
+		e.functions = registry
+	}
+}
+
+// evaluator is a tree-walking filter expression evaluator.
+//
+// Values are represented as nil (null), bool, int64, uint64, float64, string, time.Time, time.Duration,
+// protoreflect.Message, listValue and mapValue. Values of unsigned int fields are uint64, and are compared with int64
+// values without overflow. Values of google.protobuf.Struct and ListValue fields are represented as
+// map[string]interface{} and []interface{}, and fields selected from repeated message fields as projection.
+type evaluator struct {
+	referenceMap map[int64]*expr.Reference
+	typeMap      map[int64]*expr.Type
+	message      protoreflect.Message
+	functions    *FunctionRegistry
+}
+
+// listValue is a repeated field value.
+type listValue struct {
+	field protoreflect.FieldDescriptor
+	list  protoreflect.List
+}
+
+// mapValue is a map field value.
+type mapValue struct {
+	field protoreflect.FieldDescriptor
+	m     protoreflect.Map
+}
+
+// projection is the value of a field selected from the elements of a repeated message field, such as
+// `line_items.sku`, with the value of the field of each element.
+type projection []interface{}
+
+func (e *evaluator) eval(exp *expr.Expr) (interface{}, error) {
+	switch kind := exp.GetExprKind().(type) {
+	case *expr.Expr_ConstExpr:
+		return constantValue(kind.ConstExpr)
+	case *expr.Expr_IdentExpr:
+		if value, ok := e.referenceValue(exp); ok {
+			return value, nil
+		}
+		return selectField(e.message, kind.IdentExpr.GetName())
+	case *expr.Expr_SelectExpr:
+		if value, ok := e.referenceValue(exp); ok {
+			return value, nil
+		}
+		operand, err := e.eval(kind.SelectExpr.GetOperand())
+		if err != nil {
+			return nil, err
+		}
+		return selectValue(operand, kind.SelectExpr.GetField())
+	case *expr.Expr_CallExpr:
+		return e.evalCall(exp)
+	default:
+		return nil, fmt.Errorf("unsupported expr kind %T", kind)
+	}
+}
+
+func (e *evaluator) referenceValue(exp *expr.Expr) (interface{}, bool) {
+	constant := e.referenceMap[exp.GetId()].GetValue()
+	if constant == nil {
+		return nil, false
+	}
+	value, err := constantValue(constant)
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+func (e *evaluator) evalCall(exp *expr.Expr) (interface{}, error) {
+	call := exp.GetCallExpr()
+	switch call.GetFunction() {
+	case FunctionAnd, FunctionFuzzyAnd:
+		for _, arg := range call.GetArgs() {
+			b, err := e.evalBool(arg)
+			if err != nil || !b {
+				return false, err
+			}
+		}
+		return true, nil
+	case FunctionOr:
+		for _, arg := range call.GetArgs() {
+			b, err := e.evalBool(arg)
+			if err != nil || b {
+				return b, err
+			}
+		}
+		return false, nil
+	}
+	args := make([]interface{}, 0, len(call.GetArgs()))
+	for _, arg := range call.GetArgs() {
+		value, err := e.eval(arg)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+	}
+	function, _ := e.resolveCall(exp)
+	return e.call(exp, function, args)
+}
+
+// call calls the function of the provided call expr on the provided args, expanding any projections in the args.
+//
+// NOT is called on projections without expansion, so that `NOT line_items.fragile` is true if no line item is
+// fragile.
+func (e *evaluator) call(exp *expr.Expr, function callFunc, args []interface{}) (interface{}, error) {
+	if exp.GetCallExpr().GetFunction() == FunctionNot {
+		return function(args)
+	}
+	for _, arg := range args {
+		if _, ok := arg.(projection); ok {
+			return callProjected(function, e.typeMap[exp.GetId()].GetPrimitive() == expr.Type_BOOL, args)
+		}
+	}
+	return function(args)
+}
+
+// callProjected calls the function on each combination of the elements of the projections in the provided args.
+//
+// Existential calls, with bool results, are true if the call is true for any combination, as in
+// `line_items.sku = "ABC"`. Other calls result in a projection of the results, as in `lower(line_items.sku)`.
+func callProjected(function callFunc, existential bool, args []interface{}) (interface{}, error) {
+	for i, arg := range args {
+		p, ok := arg.(projection)
+		if !ok {
+			continue
+		}
+		result := make(projection, 0, len(p))
+		for _, element := range p {
+			elementArgs := append([]interface{}(nil), args...)
+			elementArgs[i] = element
+			value, err := callProjected(function, existential, elementArgs)
+			if err != nil {
+				return nil, err
+			}
+			if !existential {
+				result = appendProjected(result, value)
+				continue
+			}
+			if b, ok := value.(bool); ok && b {
+				return true, nil
+			}
+		}
+		if existential {
+			return false, nil
+		}
+		return result, nil
+	}
+	return function(args)
+}
+
+// appendProjected appends the provided value to the projection, flattening projections.
+func appendProjected(p projection, value interface{}) projection {
+	if values, ok := value.(projection); ok {
+		return append(p, values...)
+	}
+	return append(p, value)
+}
+
+// boolValue returns the provided value as a bool, where projections are true if any element is true.
+func boolValue(value interface{}) (bool, bool) {
+	switch value := value.(type) {
+	case bool:
+		return value, true
+	case projection:
+		result := false
+		for _, element := range value {
+			b, ok := boolValue(element)
+			if !ok {
+				return false, false
+			}
+			result = result || b
+		}
+		return result, true
+	default:
+		return false, false
+	}
+}
+
+// callFunc is the implementation of a function call on evaluated argument values.
+type callFunc func(args []interface{}) (interface{}, error)
+
+// resolveCall resolves the implementation of the provided call expr from its overloads, and whether the
+// implementation is a custom function.
+func (e *evaluator) resolveCall(exp *expr.Expr) (callFunc, bool) {
+	function := exp.GetCallExpr().GetFunction()
+	for _, overloadID := range e.referenceMap[exp.GetId()].GetOverloadId() {
+		switch overloadID {
+		case FunctionOverloadEqualsStringWildcard:
+			return func(args []interface{}) (interface{}, error) {
+				return matchWildcardArgs(function, args)
+			}, false
+		case FunctionOverloadNotEqualsStringWildcard:
+			return func(args []interface{}) (interface{}, error) {
+				match, err := matchWildcardArgs(function, args)
+				return !match, err
+			}, false
+		case FunctionOverloadHasMessagePresence,
+			FunctionOverloadHasStringPresence,
+			FunctionOverloadHasMapPresence,
+			FunctionOverloadHasListPresence:
+			return func(args []interface{}) (interface{}, error) {
+				if len(args) != 2 {
+					return nil, fmt.Errorf("%s: expected 2 args but got %d", function, len(args))
+				}
+				return present(args[0]), nil
+			}, false
+		case FunctionOverloadMatchesString,
+			FunctionOverloadStartsWithString,
+			FunctionOverloadEndsWithString,
+			FunctionOverloadLowerString,
+			FunctionOverloadContainsString:
+			overloadID := overloadID
+			return func(args []interface{}) (interface{}, error) {
+				return callStringFunction(overloadID, args)
+			}, false
+		case FunctionOverloadEqualsDyn,
+			FunctionOverloadNotEqualsDyn,
+			FunctionOverloadLessThanDyn,
+			FunctionOverloadLessEqualsDyn,
+			FunctionOverloadGreaterThanDyn,
+			FunctionOverloadGreaterEqualsDyn:
+			return func(args []interface{}) (interface{}, error) {
+				if len(args) != 2 {
+					return nil, fmt.Errorf("%s: expected 2 args but got %d", function, len(args))
+				}
+				return compareDyn(function, args[0], args[1])
+			}, false
+		}
+		if implementation, ok := e.functions.LookupFunction(overloadID); ok {
+			return func(args []interface{}) (interface{}, error) {
+				return callImplementation(function, implementation, args)
+			}, true
+		}
+	}
+	return func(args []interface{}) (interface{}, error) {
+		return callFunction(function, args)
+	}, false
+}
+
+func (e *evaluator) evalBool(exp *expr.Expr) (bool, error) {
+	value, err := e.eval(exp)
+	if err != nil {
+		return false, err
+	}
+	b, ok := boolValue(value)
+	if !ok {
+		return false, fmt.Errorf("expected bool but got %T", value)
+	}
+	return b, nil
+}
+
+// callFunction calls the standard function with the provided name on the provided argument values.
+func callFunction(function string, args []interface{}) (interface{}, error) {
+	switch function {
+	case FunctionNot:
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s: expected 1 arg but got %d", function, len(args))
+		}
+		b, ok := boolValue(args[0])
+		if !ok {
+			return nil, fmt.Errorf("%s: expected bool but got %T", function, args[0])
+		}
+		return !b, nil
+	case FunctionTimestamp:
+		s, err := stringArg(function, args)
+		if err != nil {
+			return nil, err
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", function, err)
+		}
+		return t, nil
+	case FunctionDuration:
+		s, err := stringArg(function, args)
+		if err != nil {
+			return nil, err
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", function, err)
+		}
+		return d, nil
+	case FunctionHas:
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%s: expected 2 args but got %d", function, len(args))
+		}
+		return has(args[0], args[1])
+	case FunctionEquals,
+		FunctionNotEquals,
+		FunctionLessThan,
+		FunctionLessEquals,
+		FunctionGreaterThan,
+		FunctionGreaterEquals:
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%s: expected 2 args but got %d", function, len(args))
+		}
+		return compare(function, args[0], args[1])
+	default:
+		return nil, fmt.Errorf("no implementation of function '%s'", function)
+	}
+}
+
+// callImplementation calls the custom function implementation on the provided argument values.
+func callImplementation(
+	function string,
+	implementation FunctionImplementation,
+	args []interface{},
+) (interface{}, error) {
+	for i, arg := range args {
+		switch arg := arg.(type) {
+		case listValue:
+			args[i] = arg.list
+		case mapValue:
+			args[i] = arg.m
+		}
+	}
+	result, err := implementation(args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", function, err)
+	}
+	return result, nil
+}
+
+// matchWildcardArgs matches a string value against a wildcard pattern. Null values never match.
+func matchWildcardArgs(function string, args []interface{}) (bool, error) {
+	if len(args) != 2 {
+		return false, fmt.Errorf("%s: expected 2 args but got %d", function, len(args))
+	}
+	if args[0] == nil {
+		return false, nil
+	}
+	s, ok := args[0].(string)
+	if !ok {
+		return false, fmt.Errorf("%s: expected string but got %T", function, args[0])
+	}
+	pattern, ok := args[1].(string)
+	if !ok {
+		return false, fmt.Errorf("%s: expected string but got %T", function, args[1])
+	}
+	return MatchWildcard(pattern, s), nil
+}
+
+func stringArg(function string, args []interface{}) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("%s: expected 1 arg but got %d", function, len(args))
+	}
+	s, ok := args[0].(string)
+	if !ok {
+		return "", fmt.Errorf("%s: expected string but got %T", function, args[0])
+	}
+	return s, nil
+}
+
+// compare the provided values using the comparison function with the provided name.
+//
+// Comparisons involving null values are false, except for != which is the negation of =.
+func compare(function string, lhs, rhs interface{}) (bool, error) {
+	if lhs == nil || rhs == nil {
+		switch function {
+		case FunctionEquals:
+			return lhs == nil && rhs == nil, nil
+		case FunctionNotEquals:
+			return lhs != nil || rhs != nil, nil
+		default:
+			return false, nil
+		}
+	}
+	if _, ok := lhs.(time.Time); ok {
+		// Timestamps can be compared with RFC3339 strings.
+		if s, ok := rhs.(string); ok {
+			parsed, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return false, fmt.Errorf("%s: %w", function, err)
+			}
+			rhs = parsed
+		}
+	}
+	var cmp int
+	switch lhs := lhs.(type) {
+	case bool:
+		r, ok := rhs.(bool)
+		if !ok {
+			return false, fmt.Errorf("%s: mismatched types %T and %T", function, lhs, rhs)
+		}
+		if function != FunctionEquals && function != FunctionNotEquals {
+			return false, fmt.Errorf("%s: unsupported for bool", function)
+		}
+		if lhs != r {
+			cmp = 1
+		}
+	case int64:
+		switch r := rhs.(type) {
+		case int64:
+			cmp = compareOrdered(lhs, r)
+		case uint64:
+			cmp = -compareUnsigned(r, lhs)
+		default:
+			return false, fmt.Errorf("%s: mismatched types %T and %T", function, lhs, rhs)
+		}
+	case uint64:
+		switch r := rhs.(type) {
+		case uint64:
+			cmp = compareOrdered(lhs, r)
+		case int64:
+			cmp = compareUnsigned(lhs, r)
+		default:
+			return false, fmt.Errorf("%s: mismatched types %T and %T", function, lhs, rhs)
+		}
+	case float64:
+		r, ok := rhs.(float64)
+		if !ok {
+			return false, fmt.Errorf("%s: mismatched types %T and %T", function, lhs, rhs)
+		}
+		cmp = compareOrdered(lhs, r)
+	case string:
+		r, ok := rhs.(string)
+		if !ok {
+			return false, fmt.Errorf("%s: mismatched types %T and %T", function, lhs, rhs)
+		}
+		cmp = strings.Compare(lhs, r)
+	case time.Time:
+		r, ok := rhs.(time.Time)
+		if !ok {
+			return false, fmt.Errorf("%s: mismatched types %T and %T", function, lhs, rhs)
+		}
+		cmp = lhs.Compare(r)
+	case time.Duration:
+		r, ok := rhs.(time.Duration)
+		if !ok {
+			return false, fmt.Errorf("%s: mismatched types %T and %T", function, lhs, rhs)
+		}
+		cmp = compareOrdered(lhs, r)
+	default:
+		return false, fmt.Errorf("%s: unsupported type %T", function, lhs)
+	}
+	switch function {
+	case FunctionEquals:
+		return cmp == 0, nil
+	case FunctionNotEquals:
+		return cmp != 0, nil
+	case FunctionLessThan:
+		return cmp < 0, nil
+	case FunctionLessEquals:
+		return cmp <= 0, nil
+	case FunctionGreaterThan:
+		return cmp > 0, nil
+	case FunctionGreaterEquals:
+		return cmp >= 0, nil
+	default:
+		return false, fmt.Errorf("unsupported comparison function '%s'", function)
+	}
+}
+
+// compareDyn compares a dyn value with the provided value, using the comparison function with the provided name.
+//
+// Ints are compared with numbers of dyn values as floats. Comparisons of values of different types are false, except
+// for != which is true.
+func compareDyn(function string, lhs, rhs interface{}) (bool, error) {
+	if _, ok := rhs.(float64); ok {
+		lhs = intToFloat(lhs)
+	}
+	if _, ok := lhs.(float64); ok {
+		rhs = intToFloat(rhs)
+	}
+	if lhs != nil && rhs != nil && reflect.TypeOf(lhs) != reflect.TypeOf(rhs) && !(isInt(lhs) && isInt(rhs)) {
+		return function == FunctionNotEquals, nil
+	}
+	return compare(function, lhs, rhs)
+}
+
+// intToFloat converts signed and unsigned int values to float64, and returns other values unchanged.
+func intToFloat(value interface{}) interface{} {
+	switch value := value.(type) {
+	case int64:
+		return float64(value)
+	case uint64:
+		return float64(value)
+	default:
+		return value
+	}
+}
+
+// isInt returns true if the provided value is a signed or unsigned int.
+func isInt(value interface{}) bool {
+	switch value.(type) {
+	case int64, uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// compareUnsigned compares an unsigned value with a signed value, without overflow.
+func compareUnsigned(lhs uint64, rhs int64) int {
+	if rhs < 0 {
+		return 1
+	}
+	return compareOrdered(lhs, uint64(rhs))
+}
+
+func compareOrdered[T int64 | uint64 | float64 | time.Duration](lhs, rhs T) int {
+	switch {
+	case lhs < rhs:
+		return -1
+	case lhs > rhs:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// has implements the standard `:` function.
+//
+// For strings, has is true if the string contains the provided substring. For lists, has is true if the list
+// contains the provided element. For maps, has is true if the map contains the provided key.
+func has(lhs, rhs interface{}) (bool, error) {
+	switch lhs := lhs.(type) {
+	case nil:
+		return false, nil
+	case string:
+		rhs, ok := rhs.(string)
+		if !ok {
+			return false, fmt.Errorf("%s: mismatched types %T and %T", FunctionHas, lhs, rhs)
+		}
+		return strings.Contains(lhs, rhs), nil
+	case listValue:
+		for i := 0; i < lhs.list.Len(); i++ {
+			equal, err := compare(FunctionEquals, scalarValue(lhs.field, lhs.list.Get(i)), rhs)
+			if err != nil {
+				return false, err
+			}
+			if equal {
+				return true, nil
+			}
+		}
+		return false, nil
+	case mapValue:
+		key, err := mapKey(lhs.field, rhs)
+		if err != nil {
+			return false, err
+		}
+		return lhs.m.Has(key), nil
+	case map[string]interface{}:
+		key, ok := rhs.(string)
+		if !ok {
+			return false, fmt.Errorf("%s: mismatched types %T and %T", FunctionHas, lhs, rhs)
+		}
+		_, ok = lhs[key]
+		return ok, nil
+	default:
+		return false, fmt.Errorf("%s: unsupported type %T", FunctionHas, lhs)
+	}
+}
+
+// present implements presence checks such as `address:*`.
+//
+// Messages are present if set, strings if non-empty and lists and maps if they have at least one element. Null
+// values are never present.
+func present(value interface{}) bool {
+	switch value := value.(type) {
+	case nil:
+		return false
+	case string:
+		return value != ""
+	case listValue:
+		return value.list.Len() > 0
+	case mapValue:
+		return value.m.Len() > 0
+	case map[string]interface{}:
+		return len(value) > 0
+	case []interface{}:
+		return len(value) > 0
+	case protoreflect.Message:
+		return value.IsValid()
+	default:
+		return true
+	}
+}
+
+// selectValue selects the field with the provided name from the provided operand value.
+func selectValue(operand interface{}, field string) (interface{}, error) {
+	switch operand := operand.(type) {
+	case nil:
+		return nil, nil
+	case protoreflect.Message:
+		return selectField(operand, field)
+	case mapValue:
+		key, err := mapKey(operand.field, field)
+		if err != nil {
+			return nil, err
+		}
+		if !operand.m.Has(key) {
+			return nil, nil
+		}
+		return scalarValue(operand.field.MapValue(), operand.m.Get(key)), nil
+	case map[string]interface{}:
+		return operand[field], nil
+	case listValue:
+		if operand.field.Message() == nil {
+			return nil, fmt.Errorf("unsupported select of '%s' on repeated field %s", field, operand.field.FullName())
+		}
+		result := make(projection, 0, operand.list.Len())
+		for i := 0; i < operand.list.Len(); i++ {
+			value, err := selectField(operand.list.Get(i).Message(), field)
+			if err != nil {
+				return nil, err
+			}
+			result = appendProjected(result, value)
+		}
+		return result, nil
+	case projection:
+		result := make(projection, 0, len(operand))
+		for _, element := range operand {
+			value, err := selectValue(element, field)
+			if err != nil {
+				return nil, err
+			}
+			result = appendProjected(result, value)
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("unsupported select of '%s' on %T", field, operand)
+	}
+}
+
+// selectField selects the field with the provided name from the provided message.
+func selectField(message protoreflect.Message, name string) (interface{}, error) {
+	field := message.Descriptor().Fields().ByName(protoreflect.Name(name))
+	if field == nil {
+		return nil, fmt.Errorf("no field '%s' in %s", name, message.Descriptor().FullName())
+	}
+	return fieldValue(message, field), nil
+}
+
+// fieldValue returns the value of the provided field of the provided message.
+func fieldValue(message protoreflect.Message, field protoreflect.FieldDescriptor) interface{} {
+	switch {
+	case field.IsList():
+		return listValue{field: field, list: message.Get(field).List()}
+	case field.IsMap():
+		return mapValue{field: field, m: message.Get(field).Map()}
+	case field.Message() != nil && !message.Has(field):
+		// Unset well-known types, such as timestamps and wrappers, are null. Other unset messages have default field
+		// values.
+		if _, ok := wellKnownType(field.Message()); ok {
+			return nil
+		}
+		return message.Get(field).Message()
+	default:
+		return scalarValue(field, message.Get(field))
+	}
+}
+
+// scalarValue converts a singular protobuf value to an evaluator value.
+func scalarValue(field protoreflect.FieldDescriptor, value protoreflect.Value) interface{} {
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		return value.Bool()
+	case protoreflect.Int32Kind,
+		protoreflect.Sint32Kind,
+		protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind,
+		protoreflect.Sint64Kind,
+		protoreflect.Sfixed64Kind:
+		return value.Int()
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return value.Uint()
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return value.Float()
+	case protoreflect.StringKind:
+		return value.String()
+	case protoreflect.BytesKind:
+		return string(value.Bytes())
+	case protoreflect.EnumKind:
+		if enumValue := field.Enum().Values().ByNumber(value.Enum()); enumValue != nil {
+			return string(enumValue.Name())
+		}
+		return int64(value.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageValue(value.Message())
+	default:
+		return nil
+	}
+}
+
+// messageValue converts a message to an evaluator value, unwrapping well-known types.
+func messageValue(message protoreflect.Message) interface{} {
+	switch message.Descriptor().FullName() {
+	case "google.protobuf.Timestamp":
+		fields := message.Descriptor().Fields()
+		return time.Unix(
+			message.Get(fields.ByName("seconds")).Int(),
+			message.Get(fields.ByName("nanos")).Int(),
+		).UTC()
+	case "google.protobuf.Duration":
+		fields := message.Descriptor().Fields()
+		return time.Duration(message.Get(fields.ByName("seconds")).Int())*time.Second +
+			time.Duration(message.Get(fields.ByName("nanos")).Int())
+	case "google.protobuf.Int64Value",
+		"google.protobuf.Int32Value",
+		"google.protobuf.UInt64Value",
+		"google.protobuf.UInt32Value",
+		"google.protobuf.DoubleValue",
+		"google.protobuf.FloatValue",
+		"google.protobuf.StringValue",
+		"google.protobuf.BoolValue":
+		field := message.Descriptor().Fields().ByName("value")
+		return scalarValue(field, message.Get(field))
+	case "google.protobuf.Struct", "google.protobuf.Value", "google.protobuf.ListValue":
+		return jsonValue(message)
+	default:
+		return message
+	}
+}
+
+// jsonValue converts a google.protobuf.Struct, Value or ListValue message to an evaluator value.
+//
+// Structs are converted to map[string]interface{}, list values to []interface{}, numbers to float64 and null values
+// to nil.
+func jsonValue(message protoreflect.Message) interface{} {
+	fields := message.Descriptor().Fields()
+	switch message.Descriptor().FullName() {
+	case "google.protobuf.Struct":
+		m := message.Get(fields.ByName("fields")).Map()
+		result := make(map[string]interface{}, m.Len())
+		m.Range(func(key protoreflect.MapKey, value protoreflect.Value) bool {
+			result[key.String()] = jsonValue(value.Message())
+			return true
+		})
+		return result
+	case "google.protobuf.ListValue":
+		list := message.Get(fields.ByName("values")).List()
+		result := make([]interface{}, 0, list.Len())
+		for i := 0; i < list.Len(); i++ {
+			result = append(result, jsonValue(list.Get(i).Message()))
+		}
+		return result
+	default:
+		field := message.WhichOneof(message.Descriptor().Oneofs().ByName("kind"))
+		if field == nil {
+			return nil
+		}
+		switch field.Name() {
+		case "null_value":
+			return nil
+		case "struct_value", "list_value":
+			return jsonValue(message.Get(field).Message())
+		default:
+			return scalarValue(field, message.Get(field))
+		}
+	}
+}
+
+// mapKey converts an evaluator value to a key of the provided map field.
+func mapKey(field protoreflect.FieldDescriptor, key interface{}) (protoreflect.MapKey, error) {
+	switch field.MapKey().Kind() {
+	case protoreflect.StringKind:
+		if s, ok := key.(string); ok {
+			return protoreflect.ValueOfString(s).MapKey(), nil
+		}
+	case protoreflect.BoolKind:
+		if b, ok := key.(bool); ok {
+			return protoreflect.ValueOfBool(b).MapKey(), nil
+		}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		if i, ok := key.(int64); ok {
+			return protoreflect.ValueOfInt32(int32(i)).MapKey(), nil
+		}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		if i, ok := key.(int64); ok {
+			return protoreflect.ValueOfInt64(i).MapKey(), nil
+		}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		if i, ok := key.(int64); ok {
+			return protoreflect.ValueOfUint32(uint32(i)).MapKey(), nil
+		}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		switch i := key.(type) {
+		case uint64:
+			return protoreflect.ValueOfUint64(i).MapKey(), nil
+		case int64:
+			if i >= 0 {
+				return protoreflect.ValueOfUint64(uint64(i)).MapKey(), nil
+			}
+		}
+	}
+	return protoreflect.MapKey{}, fmt.Errorf("invalid key %v for map field %s", key, field.FullName())
+}
+
+// constantValue converts a constant to an evaluator value.
+func constantValue(constant *expr.Constant) (interface{}, error) {
+	switch kind := constant.GetConstantKind().(type) {
+	case *expr.Constant_NullValue:
+		return nil, nil
+	case *expr.Constant_BoolValue:
+		return kind.BoolValue, nil
+	case *expr.Constant_Int64Value:
+		return kind.Int64Value, nil
+	case *expr.Constant_Uint64Value:
+		return kind.Uint64Value, nil
+	case *expr.Constant_DoubleValue:
+		return kind.DoubleValue, nil
+	case *expr.Constant_StringValue:
+		return kind.StringValue, nil
+	case *expr.Constant_BytesValue:
+		return string(kind.BytesValue), nil
+	default:
+		return nil, fmt.Errorf("unsupported constant kind %T", kind)
+	}
+}
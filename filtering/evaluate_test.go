@@ -0,0 +1,579 @@
+package filtering
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	freightv1 "go.einride.tech/aip/proto/gen/einride/example/freight/v1"
+	syntaxv1 "go.einride.tech/aip/proto/gen/einride/example/syntax/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gotest.tools/v3/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	t.Parallel()
+	shipment := &freightv1.Shipment{
+		Name:                "shippers/1/shipments/1",
+		CreateTime:          timestamppb.New(time.Date(2022, 8, 12, 22, 22, 22, 0, time.UTC)),
+		OriginSite:          "shippers/1/sites/1",
+		ExternalReferenceId: "ACME-123",
+		Annotations:         map[string]string{"env": "prod"},
+	}
+	message := &syntaxv1.Message{
+		Int64:          42,
+		Double:         2.5,
+		Bool:           true,
+		String_:        "hello world",
+		Enum:           syntaxv1.Enum_ENUM_ONE,
+		RepeatedString: []string{"a", "b"},
+		Message:        &syntaxv1.Message{String_: "nested"},
+	}
+	for _, tt := range []struct {
+		name          string
+		filter        string
+		declarations  []DeclarationOption
+		message       proto.Message
+		expected      bool
+		errorContains string
+	}{
+		{
+			name:     "empty",
+			filter:   "",
+			message:  message,
+			expected: true,
+		},
+		{
+			name:   "string equals",
+			filter: `name = "shippers/1/shipments/1"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("name", TypeString),
+			},
+			message:  shipment,
+			expected: true,
+		},
+		{
+			name:   "string not equals",
+			filter: `name != "shippers/1/shipments/1"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("name", TypeString),
+			},
+			message:  shipment,
+			expected: false,
+		},
+		{
+			name:   "wildcard",
+			filter: `name = "shippers/*/shipments/*" AND name != "*/2" AND name = "shippers/1*1" AND name != "shippers\*"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("name", TypeString),
+			},
+			message:  shipment,
+			expected: true,
+		},
+		{
+			name:   "and or not",
+			filter: `int64 > 40 AND (double < 1.0 OR NOT string = "foo")`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("int64", TypeInt),
+				DeclareIdent("double", TypeFloat),
+				DeclareIdent("string", TypeString),
+			},
+			message:  message,
+			expected: true,
+		},
+		{
+			name:   "bool",
+			filter: `bool`,
+			declarations: []DeclarationOption{
+				DeclareIdent("bool", TypeBool),
+			},
+			message:  message,
+			expected: true,
+		},
+		{
+			name:   "bool literals",
+			filter: `bool = true AND bool != false AND NOT bool = false`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareStandardConstants(),
+				DeclareIdent("bool", TypeBool),
+			},
+			message:  message,
+			expected: true,
+		},
+		{
+			name:   "presence",
+			filter: `message:* AND string:* AND repeated_string:* AND NOT message.message:*`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareMessageIdent("message", (&syntaxv1.Message{}).ProtoReflect().Descriptor()),
+				DeclareIdent("string", TypeString),
+				DeclareIdent("repeated_string", TypeList(TypeString)),
+			},
+			message:  message,
+			expected: true,
+		},
+		{
+			name:   "unsigned ints",
+			filter: `uint64 > 0 AND uint64 > 9223372036854775807 AND uint64 != -1 AND NOT uint64 < 0 AND repeated_uint64:1`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("uint64", TypeInt),
+				DeclareIdent("repeated_uint64", TypeList(TypeInt)),
+			},
+			message:  &syntaxv1.Message{Uint64: math.MaxUint64, RepeatedUint64: []uint64{1, math.MaxUint64}},
+			expected: true,
+		},
+		{
+			name: "absence",
+			filter: `NOT message:* AND NOT string:* AND NOT repeated_string:* AND NOT map_string_string:* AND
+				NOT message.message:*`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareMessageIdent("message", (&syntaxv1.Message{}).ProtoReflect().Descriptor()),
+				DeclareIdent("string", TypeString),
+				DeclareIdent("repeated_string", TypeList(TypeString)),
+				DeclareIdent("map_string_string", TypeMap(TypeString, TypeString)),
+			},
+			message:  &syntaxv1.Message{},
+			expected: true,
+		},
+		{
+			name:   "quoted wildcard",
+			filter: `string:"*" AND map_string_string:"*" AND NOT repeated_string:"*"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("string", TypeString),
+				DeclareIdent("repeated_string", TypeList(TypeString)),
+				DeclareIdent("map_string_string", TypeMap(TypeString, TypeString)),
+			},
+			message: &syntaxv1.Message{
+				String_:         "a*b",
+				RepeatedString:  []string{"a"},
+				MapStringString: map[string]string{"*": "b"},
+			},
+			expected: true,
+		},
+		{
+			name:   "quoted wildcard absence",
+			filter: `string:* AND NOT string:"*"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("string", TypeString),
+			},
+			message:  &syntaxv1.Message{String_: "ab"},
+			expected: true,
+		},
+		{
+			name:   "timestamp presence",
+			filter: `create_time:* AND annotations:* AND NOT delete_time:*`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("create_time", TypeTimestamp),
+				DeclareIdent("delete_time", TypeTimestamp),
+				DeclareIdent("annotations", TypeMap(TypeString, TypeString)),
+			},
+			message:  shipment,
+			expected: true,
+		},
+		{
+			name: "string functions",
+			filter: `string.matches("^hel+o") AND startsWith(string, "hello") AND string.endsWith("world") AND
+				contains(lower(string), "o w") AND NOT string.matches("^world") AND NOT message.string.startsWith("hello")`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareStringFunctions(),
+				DeclareIdent("string", TypeString),
+				DeclareMessageIdent("message", (&syntaxv1.Message{}).ProtoReflect().Descriptor()),
+			},
+			message:  message,
+			expected: true,
+		},
+		{
+			name:   "has string",
+			filter: `string:"world"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("string", TypeString),
+			},
+			message:  message,
+			expected: true,
+		},
+		{
+			name:   "has list",
+			filter: `repeated_string:"b" AND NOT repeated_string:"c"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("repeated_string", TypeList(TypeString)),
+			},
+			message:  message,
+			expected: true,
+		},
+		{
+			name:   "has typed list and map",
+			filter: `repeated_int64:2 AND repeated_enum:ENUM_TWO AND NOT repeated_enum:ENUM_ONE AND map_string_message:b`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("repeated_int64", TypeList(TypeInt)),
+				DeclareIdent("repeated_enum", TypeList(TypeEnum(syntaxv1.Enum(0).Type()))),
+				DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+				DeclareIdent("map_string_message", TypeMap(TypeString, TypeMessage(message.ProtoReflect().Descriptor()))),
+			},
+			message: &syntaxv1.Message{
+				RepeatedInt64:    []int64{1, 2},
+				RepeatedEnum:     []syntaxv1.Enum{syntaxv1.Enum_ENUM_TWO},
+				MapStringMessage: map[string]*syntaxv1.Message{"a": {}, "b": {}},
+			},
+			expected: true,
+		},
+		{
+			name:   "has map key",
+			filter: `annotations:env AND NOT annotations:team`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("annotations", TypeMap(TypeString, TypeString)),
+			},
+			message:  shipment,
+			expected: true,
+		},
+		{
+			name:   "map select",
+			filter: `annotations.env = "prod"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("annotations", TypeMap(TypeString, TypeString)),
+			},
+			message:  shipment,
+			expected: true,
+		},
+		{
+			name:   "map select missing key",
+			filter: `annotations.team = "freight"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("annotations", TypeMap(TypeString, TypeString)),
+			},
+			message:  shipment,
+			expected: false,
+		},
+		{
+			name:   "qualified ident",
+			filter: `message.string = "nested"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("message.string", TypeString),
+			},
+			message:  message,
+			expected: true,
+		},
+		{
+			name:   "qualified alias",
+			filter: `msg_str = "nested" AND msg_int = 0`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareMessageIdent("message", (&syntaxv1.Message{}).ProtoReflect().Descriptor()),
+				DeclareIdent("message.string", TypeString),
+				DeclareAlias("msg_str", "message.string"),
+				DeclareIdent("message.message.int64", TypeInt),
+				DeclareAlias("msg_int", "message.message.int64"),
+			},
+			message:  message,
+			expected: true,
+		},
+		{
+			name:   "message ident",
+			filter: `message.string = "nested" AND message.enum = ENUM_UNSPECIFIED AND message.message.int64 = 0`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareMessageIdent("message", (&syntaxv1.Message{}).ProtoReflect().Descriptor()),
+			},
+			message:  message,
+			expected: true,
+		},
+		{
+			name:   "enum",
+			filter: `enum = ENUM_ONE AND enum != ENUM_TWO`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+			},
+			message:  message,
+			expected: true,
+		},
+		{
+			name:   "timestamp",
+			filter: `create_time > timestamp("2022-01-01T00:00:00Z") AND create_time < "2023-01-01T00:00:00Z"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("create_time", TypeTimestamp),
+			},
+			message:  shipment,
+			expected: true,
+		},
+		{
+			name:   "timestamp string equals",
+			filter: `create_time = "2022-08-13T00:22:22+02:00"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("create_time", TypeTimestamp),
+			},
+			message:  shipment,
+			expected: true,
+		},
+		{
+			name:   "unset timestamp",
+			filter: `delete_time < timestamp("2022-01-01T00:00:00Z")`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("delete_time", TypeTimestamp),
+			},
+			message:  shipment,
+			expected: false,
+		},
+		{
+			name:   "duration",
+			filter: `duration("1h") > duration("30m")`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+			},
+			message:  shipment,
+			expected: true,
+		},
+		{
+			name:   "unknown field",
+			filter: `foo = "bar"`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("foo", TypeString),
+			},
+			message:       shipment,
+			errorContains: "no field 'foo' in einride.example.freight.v1.Shipment",
+		},
+		{
+			name:   "unimplemented function",
+			filter: `regex(name, "^shippers/")`,
+			declarations: []DeclarationOption{
+				DeclareIdent("name", TypeString),
+				DeclareFunction("regex", NewFunctionOverload("regex_string", TypeBool, TypeString, TypeString)),
+			},
+			message:       shipment,
+			errorContains: "no implementation of function 'regex'",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			declarations, err := NewDeclarations(tt.declarations...)
+			assert.NilError(t, err)
+			filter, err := ParseFilter(&mockRequest{filter: tt.filter}, declarations)
+			assert.NilError(t, err)
+			actual, err := Evaluate(filter, tt.message)
+			var compiledActual bool
+			predicate, compileErr := Compile(filter, tt.message.ProtoReflect().Descriptor())
+			if compileErr == nil {
+				compiledActual, compileErr = predicate(tt.message)
+			}
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				assert.ErrorContains(t, compileErr, tt.errorContains)
+				return
+			}
+			assert.NilError(t, err)
+			assert.NilError(t, compileErr)
+			assert.Equal(t, tt.expected, actual)
+			assert.Equal(t, tt.expected, compiledActual)
+		})
+	}
+}
+
+func TestEvaluate_wellKnownTypes(t *testing.T) {
+	t.Parallel()
+	descriptor := newWellKnownTypesMessage(t)
+	declarations, err := NewDeclarations(
+		DeclareStandardFunctions(),
+		DeclareStandardConstants(),
+		DeclareMessageFields(descriptor),
+	)
+	assert.NilError(t, err)
+	metadata, err := structpb.NewStruct(map[string]interface{}{
+		"customer": map[string]interface{}{"tier": "gold", "seats": 12},
+		"regions":  []interface{}{"eu", "us"},
+		"note":     nil,
+	})
+	assert.NilError(t, err)
+	message := dynamicpb.NewMessage(descriptor)
+	for name, value := range map[string]proto.Message{
+		"weight":     wrapperspb.Int64(120),
+		"count":      wrapperspb.UInt32(3),
+		"score":      wrapperspb.Float(0.5),
+		"nickname":   wrapperspb.String("Bob"),
+		"active":     wrapperspb.Bool(false),
+		"metadata":   metadata,
+		"attributes": structpb.NewStringValue("red"),
+		"tags":       &structpb.ListValue{Values: []*structpb.Value{structpb.NewStringValue("a")}},
+	} {
+		field := descriptor.Fields().ByName(protoreflect.Name(name))
+		message.Set(field, protoreflect.ValueOfMessage(value.ProtoReflect()))
+	}
+	unset := dynamicpb.NewMessage(descriptor)
+	for _, tt := range []struct {
+		filter   string
+		message  proto.Message
+		expected bool
+	}{
+		{
+			filter:   `weight = 120 AND weight > 100 AND weight != null AND count <= 3 AND score < 1.0 AND weight:*`,
+			message:  message,
+			expected: true,
+		},
+		{
+			filter:   `weight = null AND NOT weight:* AND NOT weight > 0 AND NOT weight <= 0 AND weight != 120`,
+			message:  unset,
+			expected: true,
+		},
+		{
+			filter:   `nickname = "B*" AND nickname:"o" AND active = false AND NOT active AND active != null`,
+			message:  message,
+			expected: true,
+		},
+		{
+			filter:   `nickname = null AND NOT nickname = "B*" AND NOT nickname:"o" AND active = null`,
+			message:  unset,
+			expected: true,
+		},
+		{
+			filter: `metadata.customer.tier = "gold" AND metadata.customer.seats >= 10 AND metadata.customer.seats = 12 AND
+				metadata.customer.seats < 12.5 AND attributes = "red"`,
+			message:  message,
+			expected: true,
+		},
+		{
+			filter:   `metadata.customer.tier != 5 AND NOT metadata.customer.tier = 5 AND NOT metadata.customer.tier > 5`,
+			message:  message,
+			expected: true,
+		},
+		{
+			filter: `metadata.note = null AND metadata.missing = null AND metadata.customer.missing.tier = null AND
+				metadata:customer AND NOT metadata:missing AND metadata.customer:* AND metadata.regions:* AND tags:*`,
+			message:  message,
+			expected: true,
+		},
+		{
+			filter:   `metadata.customer.tier = "gold"`,
+			message:  unset,
+			expected: false,
+		},
+		{
+			filter:   `NOT metadata:* AND NOT metadata:customer AND attributes = null AND NOT tags:*`,
+			message:  unset,
+			expected: true,
+		},
+	} {
+		tt := tt
+		t.Run(tt.filter, func(t *testing.T) {
+			t.Parallel()
+			filter, err := ParseFilter(&mockRequest{filter: tt.filter}, declarations)
+			assert.NilError(t, err)
+			actual, err := Evaluate(filter, tt.message)
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expected, actual)
+			predicate, err := Compile(filter, descriptor)
+			assert.NilError(t, err)
+			compiledActual, err := predicate(tt.message)
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expected, compiledActual)
+		})
+	}
+}
+
+func TestEvaluate_repeatedMessageFields(t *testing.T) {
+	t.Parallel()
+	descriptor := (&freightv1.Shipment{}).ProtoReflect().Descriptor()
+	declarations, err := NewDeclarations(
+		DeclareStandardFunctions(),
+		DeclareStringFunctions(),
+		DeclareMessageFields(descriptor),
+	)
+	assert.NilError(t, err)
+	shipment := &freightv1.Shipment{
+		LineItems: []*freightv1.LineItem{
+			{Title: "Pallet", WeightKg: 120},
+			{Title: "Box", WeightKg: 5},
+		},
+	}
+	for _, tt := range []struct {
+		filter   string
+		message  proto.Message
+		expected bool
+	}{
+		{
+			filter:   `line_items.title = "Box"`,
+			message:  shipment,
+			expected: true,
+		},
+		{
+			filter:   `line_items.title = "Crate"`,
+			message:  shipment,
+			expected: false,
+		},
+		{
+			filter:   `line_items.title = "Pallet" AND line_items.weight_kg < 10.0`,
+			message:  shipment,
+			expected: true,
+		},
+		{
+			filter:   `line_items.weight_kg > 200.0 OR line_items.title = "P*"`,
+			message:  shipment,
+			expected: true,
+		},
+		{
+			filter:   `line_items.title != "Box" AND line_items.title:"al" AND lower(line_items.title) = "box"`,
+			message:  shipment,
+			expected: true,
+		},
+		{
+			filter:   `NOT line_items.title = "Box"`,
+			message:  shipment,
+			expected: false,
+		},
+		{
+			filter:   `NOT line_items.title = "Crate" AND line_items:*`,
+			message:  shipment,
+			expected: true,
+		},
+		{
+			filter:   `line_items.title = "Box"`,
+			message:  &freightv1.Shipment{},
+			expected: false,
+		},
+		{
+			filter:   `NOT line_items.title = "Box" AND NOT line_items:*`,
+			message:  &freightv1.Shipment{},
+			expected: true,
+		},
+	} {
+		tt := tt
+		t.Run(tt.filter, func(t *testing.T) {
+			t.Parallel()
+			filter, err := ParseFilter(&mockRequest{filter: tt.filter}, declarations)
+			assert.NilError(t, err)
+			actual, err := Evaluate(filter, tt.message)
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expected, actual)
+			predicate, err := Compile(filter, descriptor)
+			assert.NilError(t, err)
+			compiledActual, err := predicate(tt.message)
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expected, compiledActual)
+		})
+	}
+}
@@ -44,6 +44,28 @@ func Float(value float64) *expr.Expr {
 	}
 }
 
+func Bool(value bool) *expr.Expr {
+	return &expr.Expr{
+		ExprKind: &expr.Expr_ConstExpr{
+			ConstExpr: &expr.Constant{
+				ConstantKind: &expr.Constant_BoolValue{
+					BoolValue: value,
+				},
+			},
+		},
+	}
+}
+
+func Null() *expr.Expr {
+	return &expr.Expr{
+		ExprKind: &expr.Expr_ConstExpr{
+			ConstExpr: &expr.Constant{
+				ConstantKind: &expr.Constant_NullValue{},
+			},
+		},
+	}
+}
+
 func Duration(value time.Duration) *expr.Expr {
 	return Function(FunctionDuration, String(value.String()))
 }
@@ -7,4 +7,22 @@ import (
 // Filter represents a parsed and type-checked filter.
 type Filter struct {
 	CheckedExpr *expr.CheckedExpr
+	// Warnings from type-checking the filter, such as usages of deprecated identifiers.
+	Warnings []Warning
+}
+
+// Warning is a non-fatal problem with a filter, such as usage of a deprecated identifier.
+type Warning struct {
+	// Position is the position in the filter of the expression with the warning.
+	Position Position
+	// Message describes the warning.
+	Message string
+}
+
+// String returns a string representation of the warning, prefixed by its position if known.
+func (w Warning) String() string {
+	if w.Position.Line == 0 {
+		return w.Message
+	}
+	return w.Position.String() + ": " + w.Message
 }
@@ -19,6 +19,15 @@ const (
 	FunctionTimestamp     = "timestamp"
 )
 
+// String function names.
+const (
+	FunctionMatches    = "matches"
+	FunctionStartsWith = "startsWith"
+	FunctionEndsWith   = "endsWith"
+	FunctionLower      = "lower"
+	FunctionContains   = "contains"
+)
+
 // StandardFunctionDeclarations returns declarations for all standard functions and their standard overloads.
 func StandardFunctionDeclarations() []*expr.Decl {
 	return []*expr.Decl{
@@ -37,6 +46,15 @@ func StandardFunctionDeclarations() []*expr.Decl {
 	}
 }
 
+// StandardConstantDeclarations returns declarations for the standard literal constants `true`, `false` and `null`.
+func StandardConstantDeclarations() []*expr.Decl {
+	return []*expr.Decl{
+		NewConstantDeclaration("true", TypeBool, &expr.Constant{ConstantKind: &expr.Constant_BoolValue{BoolValue: true}}),
+		NewConstantDeclaration("false", TypeBool, &expr.Constant{ConstantKind: &expr.Constant_BoolValue{BoolValue: false}}),
+		NewConstantDeclaration("null", TypeNull, &expr.Constant{ConstantKind: &expr.Constant_NullValue{}}),
+	}
+}
+
 // Timestamp overloads.
 const (
 	FunctionOverloadTimestampString = FunctionTimestamp + "_string"
@@ -68,16 +86,51 @@ const (
 	FunctionOverloadHasString          = FunctionHas + "_string"
 	FunctionOverloadHasMapStringString = FunctionHas + "_map_string_string"
 	FunctionOverloadHasListString      = FunctionHas + "_list_string"
+	// FunctionOverloadHasMap is the type-parametric overload for maps with other key types than strings.
+	FunctionOverloadHasMap = FunctionHas + "_map"
+	// FunctionOverloadHasList is the type-parametric overload for lists with other element types than strings.
+	FunctionOverloadHasList = FunctionHas + "_list"
+	// FunctionOverloadHasMessagePresence is selected by the checker for presence checks such as `address:*` of
+	// message, timestamp, duration, wrapper and dyn values, which are true if the value is set.
+	FunctionOverloadHasMessagePresence = FunctionHas + "_message_presence"
+	// FunctionOverloadHasStringPresence is selected by the checker for presence checks such as `display_name:*` of
+	// string values, which are true if the string is non-empty.
+	FunctionOverloadHasStringPresence = FunctionHas + "_string_presence"
+	// FunctionOverloadHasMapPresence is selected by the checker for presence checks such as `labels:*` of map
+	// values, which are true if the map is non-empty.
+	FunctionOverloadHasMapPresence = FunctionHas + "_map_presence"
+	// FunctionOverloadHasListPresence is selected by the checker for presence checks such as `tags:*` of list
+	// values, which are true if the list is non-empty.
+	FunctionOverloadHasListPresence = FunctionHas + "_list_presence"
 )
 
+// PresenceWildcard is the argument of the standard `:` function that checks for presence, as in `address:*`.
+//
+// The unquoted wildcard is parsed as an ident. A quoted wildcard, as in `address:"*"`, is a string constant that is
+// matched literally.
+const PresenceWildcard = "*"
+
+// isPresenceWildcard returns true if the provided expression is the unquoted wildcard of a presence check.
+func isPresenceWildcard(e *expr.Expr) bool {
+	return e.GetIdentExpr().GetName() == PresenceWildcard
+}
+
 // StandardFunctionHas returns a declaration for the standard `:` function and all its standard overloads.
+//
+// Presence checks such as `address:*` need no declared overloads, and are resolved by the checker to one of the
+// presence overloads based on the type of the left-hand side.
 func StandardFunctionHas() *expr.Decl {
 	return NewFunctionDeclaration(
 		FunctionHas,
 		NewFunctionOverload(FunctionOverloadHasString, TypeBool, TypeString, TypeString),
-		// TODO: Remove this after implementing support for type parameters.
 		NewFunctionOverload(FunctionOverloadHasMapStringString, TypeBool, TypeMap(TypeString, TypeString), TypeString),
 		NewFunctionOverload(FunctionOverloadHasListString, TypeBool, TypeList(TypeString), TypeString),
+		NewParameterizedFunctionOverload(
+			FunctionOverloadHasMap, []string{"K", "V"}, TypeBool, TypeMap(TypeParam("K"), TypeParam("V")), TypeParam("K"),
+		),
+		NewParameterizedFunctionOverload(
+			FunctionOverloadHasList, []string{"T"}, TypeBool, TypeList(TypeParam("T")), TypeParam("T"),
+		),
 	)
 }
 
@@ -128,6 +181,8 @@ const (
 	FunctionOverloadLessThanTimestamp       = FunctionLessThan + "_timestamp"
 	FunctionOverloadLessThanTimestampString = FunctionLessThan + "_timestamp_string"
 	FunctionOverloadLessThanDuration        = FunctionLessThan + "_duration"
+	// FunctionOverloadLessThanDyn is the overload for comparing dyn values with values of any type.
+	FunctionOverloadLessThanDyn = FunctionLessThan + "_dyn"
 )
 
 // StandardFunctionLessThan returns a declaration for the standard '<' function and all its standard overloads.
@@ -140,6 +195,7 @@ func StandardFunctionLessThan() *expr.Decl {
 		NewFunctionOverload(FunctionOverloadLessThanTimestamp, TypeBool, TypeTimestamp, TypeTimestamp),
 		NewFunctionOverload(FunctionOverloadLessThanTimestampString, TypeBool, TypeTimestamp, TypeString),
 		NewFunctionOverload(FunctionOverloadLessThanDuration, TypeBool, TypeDuration, TypeDuration),
+		NewParameterizedFunctionOverload(FunctionOverloadLessThanDyn, []string{"T"}, TypeBool, TypeDyn, TypeParam("T")),
 	)
 }
 
@@ -151,6 +207,8 @@ const (
 	FunctionOverloadGreaterThanTimestamp       = FunctionGreaterThan + "_timestamp"
 	FunctionOverloadGreaterThanTimestampString = FunctionGreaterThan + "_timestamp_string"
 	FunctionOverloadGreaterThanDuration        = FunctionGreaterThan + "_duration"
+	// FunctionOverloadGreaterThanDyn is the overload for comparing dyn values with values of any type.
+	FunctionOverloadGreaterThanDyn = FunctionGreaterThan + "_dyn"
 )
 
 // StandardFunctionGreaterThan returns a declaration for the standard '>' function and all its standard overloads.
@@ -163,6 +221,7 @@ func StandardFunctionGreaterThan() *expr.Decl {
 		NewFunctionOverload(FunctionOverloadGreaterThanTimestamp, TypeBool, TypeTimestamp, TypeTimestamp),
 		NewFunctionOverload(FunctionOverloadGreaterThanTimestampString, TypeBool, TypeTimestamp, TypeString),
 		NewFunctionOverload(FunctionOverloadGreaterThanDuration, TypeBool, TypeDuration, TypeDuration),
+		NewParameterizedFunctionOverload(FunctionOverloadGreaterThanDyn, []string{"T"}, TypeBool, TypeDyn, TypeParam("T")),
 	)
 }
 
@@ -174,6 +233,8 @@ const (
 	FunctionOverloadLessEqualsTimestamp       = FunctionLessEquals + "_timestamp"
 	FunctionOverloadLessEqualsTimestampString = FunctionLessEquals + "_timestamp_string"
 	FunctionOverloadLessEqualsDuration        = FunctionLessEquals + "_duration"
+	// FunctionOverloadLessEqualsDyn is the overload for comparing dyn values with values of any type.
+	FunctionOverloadLessEqualsDyn = FunctionLessEquals + "_dyn"
 )
 
 // StandardFunctionLessEquals returns a declaration for the standard '<=' function and all its standard overloads.
@@ -186,6 +247,7 @@ func StandardFunctionLessEquals() *expr.Decl {
 		NewFunctionOverload(FunctionOverloadLessEqualsTimestamp, TypeBool, TypeTimestamp, TypeTimestamp),
 		NewFunctionOverload(FunctionOverloadLessEqualsTimestampString, TypeBool, TypeTimestamp, TypeString),
 		NewFunctionOverload(FunctionOverloadLessEqualsDuration, TypeBool, TypeDuration, TypeDuration),
+		NewParameterizedFunctionOverload(FunctionOverloadLessEqualsDyn, []string{"T"}, TypeBool, TypeDyn, TypeParam("T")),
 	)
 }
 
@@ -197,6 +259,8 @@ const (
 	FunctionOverloadGreaterEqualsTimestamp       = FunctionGreaterEquals + "_timestamp"
 	FunctionOverloadGreaterEqualsTimestampString = FunctionGreaterEquals + "_timestamp_string"
 	FunctionOverloadGreaterEqualsDuration        = FunctionGreaterEquals + "_duration"
+	// FunctionOverloadGreaterEqualsDyn is the overload for comparing dyn values with values of any type.
+	FunctionOverloadGreaterEqualsDyn = FunctionGreaterEquals + "_dyn"
 )
 
 // StandardFunctionGreaterEquals returns a declaration for the standard '>=' function and all its standard overloads.
@@ -209,6 +273,7 @@ func StandardFunctionGreaterEquals() *expr.Decl {
 		NewFunctionOverload(FunctionOverloadGreaterEqualsTimestamp, TypeBool, TypeTimestamp, TypeTimestamp),
 		NewFunctionOverload(FunctionOverloadGreaterEqualsTimestampString, TypeBool, TypeTimestamp, TypeString),
 		NewFunctionOverload(FunctionOverloadGreaterEqualsDuration, TypeBool, TypeDuration, TypeDuration),
+		NewParameterizedFunctionOverload(FunctionOverloadGreaterEqualsDyn, []string{"T"}, TypeBool, TypeDyn, TypeParam("T")),
 	)
 }
 
@@ -221,6 +286,20 @@ const (
 	FunctionOverloadEqualsTimestamp       = FunctionEquals + "_timestamp"
 	FunctionOverloadEqualsTimestampString = FunctionEquals + "_timestamp_string"
 	FunctionOverloadEqualsDuration        = FunctionEquals + "_duration"
+	// FunctionOverloadEqualsBoolWrapper and the other wrapper overloads compare wrapper values with null, as in
+	// `weight = null`. Wrapper values are compared with primitive values using the primitive overloads.
+	FunctionOverloadEqualsBoolWrapper   = FunctionEquals + "_bool_wrapper"
+	FunctionOverloadEqualsIntWrapper    = FunctionEquals + "_int_wrapper"
+	FunctionOverloadEqualsFloatWrapper  = FunctionEquals + "_float_wrapper"
+	FunctionOverloadEqualsStringWrapper = FunctionEquals + "_string_wrapper"
+	// FunctionOverloadEqualsDyn is the overload for comparing dyn values with values of any type.
+	FunctionOverloadEqualsDyn = FunctionEquals + "_dyn"
+	// FunctionOverloadEquals is the type-parametric overload for any two values of the same comparable type, such
+	// as enums. Lists, maps and messages are not comparable.
+	FunctionOverloadEquals = FunctionEquals + "_T"
+	// FunctionOverloadEqualsStringWildcard is selected by the checker in place of FunctionOverloadEqualsString when
+	// the right-hand side is a constant wildcard pattern.
+	FunctionOverloadEqualsStringWildcard = FunctionEquals + "_string_wildcard"
 )
 
 // StandardFunctionEquals returns a declaration for the standard '=' function and all its standard overloads.
@@ -234,6 +313,12 @@ func StandardFunctionEquals() *expr.Decl {
 		NewFunctionOverload(FunctionOverloadEqualsTimestamp, TypeBool, TypeTimestamp, TypeTimestamp),
 		NewFunctionOverload(FunctionOverloadEqualsTimestampString, TypeBool, TypeTimestamp, TypeString),
 		NewFunctionOverload(FunctionOverloadEqualsDuration, TypeBool, TypeDuration, TypeDuration),
+		NewFunctionOverload(FunctionOverloadEqualsBoolWrapper, TypeBool, TypeBoolWrapper, TypeBoolWrapper),
+		NewFunctionOverload(FunctionOverloadEqualsIntWrapper, TypeBool, TypeIntWrapper, TypeIntWrapper),
+		NewFunctionOverload(FunctionOverloadEqualsFloatWrapper, TypeBool, TypeFloatWrapper, TypeFloatWrapper),
+		NewFunctionOverload(FunctionOverloadEqualsStringWrapper, TypeBool, TypeStringWrapper, TypeStringWrapper),
+		NewParameterizedFunctionOverload(FunctionOverloadEqualsDyn, []string{"T"}, TypeBool, TypeDyn, TypeParam("T")),
+		NewParameterizedFunctionOverload(FunctionOverloadEquals, []string{"T"}, TypeBool, TypeParam("T"), TypeParam("T")),
 	)
 }
 
@@ -246,6 +331,20 @@ const (
 	FunctionOverloadNotEqualsTimestamp       = FunctionNotEquals + "_timestamp"
 	FunctionOverloadNotEqualsTimestampString = FunctionNotEquals + "_timestamp_string"
 	FunctionOverloadNotEqualsDuration        = FunctionNotEquals + "_duration"
+	// FunctionOverloadNotEqualsBoolWrapper and the other wrapper overloads compare wrapper values with null, as in
+	// `weight != null`. Wrapper values are compared with primitive values using the primitive overloads.
+	FunctionOverloadNotEqualsBoolWrapper   = FunctionNotEquals + "_bool_wrapper"
+	FunctionOverloadNotEqualsIntWrapper    = FunctionNotEquals + "_int_wrapper"
+	FunctionOverloadNotEqualsFloatWrapper  = FunctionNotEquals + "_float_wrapper"
+	FunctionOverloadNotEqualsStringWrapper = FunctionNotEquals + "_string_wrapper"
+	// FunctionOverloadNotEqualsDyn is the overload for comparing dyn values with values of any type.
+	FunctionOverloadNotEqualsDyn = FunctionNotEquals + "_dyn"
+	// FunctionOverloadNotEquals is the type-parametric overload for any two values of the same comparable type, such
+	// as enums. Lists, maps and messages are not comparable.
+	FunctionOverloadNotEquals = FunctionNotEquals + "_T"
+	// FunctionOverloadNotEqualsStringWildcard is selected by the checker in place of FunctionOverloadNotEqualsString when
+	// the right-hand side is a constant wildcard pattern.
+	FunctionOverloadNotEqualsStringWildcard = FunctionNotEquals + "_string_wildcard"
 )
 
 // StandardFunctionNotEquals returns a declaration for the standard '!=' function and all its standard overloads.
@@ -259,5 +358,96 @@ func StandardFunctionNotEquals() *expr.Decl {
 		NewFunctionOverload(FunctionOverloadNotEqualsTimestamp, TypeBool, TypeTimestamp, TypeTimestamp),
 		NewFunctionOverload(FunctionOverloadNotEqualsTimestampString, TypeBool, TypeTimestamp, TypeString),
 		NewFunctionOverload(FunctionOverloadNotEqualsDuration, TypeBool, TypeDuration, TypeDuration),
+		NewFunctionOverload(FunctionOverloadNotEqualsBoolWrapper, TypeBool, TypeBoolWrapper, TypeBoolWrapper),
+		NewFunctionOverload(FunctionOverloadNotEqualsIntWrapper, TypeBool, TypeIntWrapper, TypeIntWrapper),
+		NewFunctionOverload(FunctionOverloadNotEqualsFloatWrapper, TypeBool, TypeFloatWrapper, TypeFloatWrapper),
+		NewFunctionOverload(FunctionOverloadNotEqualsStringWrapper, TypeBool, TypeStringWrapper, TypeStringWrapper),
+		NewParameterizedFunctionOverload(FunctionOverloadNotEqualsDyn, []string{"T"}, TypeBool, TypeDyn, TypeParam("T")),
+		NewParameterizedFunctionOverload(FunctionOverloadNotEquals, []string{"T"}, TypeBool, TypeParam("T"), TypeParam("T")),
+	)
+}
+
+// StringFunctionDeclarations returns declarations for all string functions and their overloads.
+//
+// The string functions are optional standard functions, declared by DeclareStringFunctions. They can be called as
+// functions, as in `matches(display_name, "^ACME")`, or with the string as receiver, as in
+// `display_name.matches("^ACME")`.
+func StringFunctionDeclarations() []*expr.Decl {
+	return []*expr.Decl{
+		StringFunctionMatches(),
+		StringFunctionStartsWith(),
+		StringFunctionEndsWith(),
+		StringFunctionLower(),
+		StringFunctionContains(),
+	}
+}
+
+// Matches overloads.
+const (
+	FunctionOverloadMatchesString = FunctionMatches + "_string"
+)
+
+// StringFunctionMatches returns a declaration for the `matches` function, which is true if the string contains a
+// match of the RE2 regular expression. Constant regular expressions are validated by the checker.
+func StringFunctionMatches() *expr.Decl {
+	return NewFunctionDeclaration(
+		FunctionMatches,
+		NewFunctionOverload(FunctionOverloadMatchesString, TypeBool, TypeString, TypeString),
+	)
+}
+
+// StartsWith overloads.
+const (
+	FunctionOverloadStartsWithString = FunctionStartsWith + "_string"
+)
+
+// StringFunctionStartsWith returns a declaration for the `startsWith` function, which is true if the string starts
+// with the prefix.
+func StringFunctionStartsWith() *expr.Decl {
+	return NewFunctionDeclaration(
+		FunctionStartsWith,
+		NewFunctionOverload(FunctionOverloadStartsWithString, TypeBool, TypeString, TypeString),
+	)
+}
+
+// EndsWith overloads.
+const (
+	FunctionOverloadEndsWithString = FunctionEndsWith + "_string"
+)
+
+// StringFunctionEndsWith returns a declaration for the `endsWith` function, which is true if the string ends with
+// the suffix.
+func StringFunctionEndsWith() *expr.Decl {
+	return NewFunctionDeclaration(
+		FunctionEndsWith,
+		NewFunctionOverload(FunctionOverloadEndsWithString, TypeBool, TypeString, TypeString),
+	)
+}
+
+// Lower overloads.
+const (
+	FunctionOverloadLowerString = FunctionLower + "_string"
+)
+
+// StringFunctionLower returns a declaration for the `lower` function, which returns the string in lower case, for
+// case-insensitive comparisons such as `lower(display_name) = "acme"`.
+func StringFunctionLower() *expr.Decl {
+	return NewFunctionDeclaration(
+		FunctionLower,
+		NewFunctionOverload(FunctionOverloadLowerString, TypeString, TypeString),
+	)
+}
+
+// Contains overloads.
+const (
+	FunctionOverloadContainsString = FunctionContains + "_string"
+)
+
+// StringFunctionContains returns a declaration for the `contains` function, which is true if the string contains the
+// substring.
+func StringFunctionContains() *expr.Decl {
+	return NewFunctionDeclaration(
+		FunctionContains,
+		NewFunctionOverload(FunctionOverloadContainsString, TypeBool, TypeString, TypeString),
 	)
 }
@@ -137,6 +137,21 @@ func (l *Lexer) nextRune() (rune, error) {
 	return r, nil
 }
 
+// skipRune skips the current token and the next rune, to resume lexing after an error.
+func (l *Lexer) skipRune() {
+	if r, n := utf8.DecodeRuneInString(l.remainingFilter()); n > 0 {
+		if r == '\n' {
+			l.lineOffsets = append(l.lineOffsets, l.tokenEnd.Offset)
+			l.tokenEnd.Line++
+			l.tokenEnd.Column = 1
+		} else {
+			l.tokenEnd.Column++
+		}
+		l.tokenEnd.Offset += int32(n)
+	}
+	l.tokenStart = l.tokenEnd
+}
+
 func (l *Lexer) sniff(wantFns ...func(rune) bool) bool {
 	remaining := l.remainingFilter()
 	for _, wantFn := range wantFns {
@@ -0,0 +1,97 @@
+package filtering
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Limits configures complexity limits for parsing filters.
+//
+// A zero value means that the corresponding limit is not enforced.
+type Limits struct {
+	// MaxLength is the max length of the filter, in bytes.
+	MaxLength int
+	// MaxDepth is the max nesting depth of parentheses and function calls.
+	MaxDepth int
+	// MaxRestrictions is the max number of restrictions, such as `a = 1` or `a`, in the filter.
+	MaxRestrictions int
+	// MaxFunctionArgs is the max number of args to a function call.
+	MaxFunctionArgs int
+	// MaxStringLength is the max length of a string literal, in bytes.
+	MaxStringLength int
+}
+
+// ParserOption configures a Parser.
+type ParserOption func(*Parser)
+
+// WithLimits is a ParserOption that enforces the provided complexity limits.
+func WithLimits(limits Limits) ParserOption {
+	return func(parser *Parser) {
+		parser.limits = limits
+	}
+}
+
+// LimitError is returned when a filter exceeds one of the configured Limits.
+type LimitError struct {
+	filter   string
+	position Position
+	limit    string
+	max      int
+}
+
+var _ Error = &LimitError{}
+
+// Code returns ErrorCodeLimitExceeded.
+func (l *LimitError) Code() ErrorCode {
+	return ErrorCodeLimitExceeded
+}
+
+// Filter returns the filter that exceeded the limit.
+func (l *LimitError) Filter() string {
+	return l.filter
+}
+
+// Position returns the position in the filter where the limit was exceeded.
+func (l *LimitError) Position() Position {
+	return l.position
+}
+
+// Span returns the span of the token where the limit was exceeded.
+func (l *LimitError) Span() Span {
+	return tokenSpan(l.filter, l.position)
+}
+
+// Limit returns the name of the exceeded limit, such as "max depth".
+func (l *LimitError) Limit() string {
+	return l.limit
+}
+
+// Max returns the configured value of the exceeded limit.
+func (l *LimitError) Max() int {
+	return l.max
+}
+
+// Message returns a description of the exceeded limit.
+func (l *LimitError) Message() string {
+	return fmt.Sprintf("exceeded %s of %d", l.limit, l.max)
+}
+
+func (l *LimitError) Error() string {
+	return fmt.Sprintf("%s: %s", l.position, l.Message())
+}
+
+// positionOf returns the position of the provided byte offset in the provided filter.
+func positionOf(filter string, offset int) Position {
+	position := Position{Line: 1, Column: 1}
+	for int(position.Offset) < offset && int(position.Offset) < len(filter) {
+		r, n := utf8.DecodeRuneInString(filter[position.Offset:])
+		if r == '\n' {
+			position.Line++
+			position.Column = 1
+		} else {
+			position.Column++
+		}
+		position.Offset += int32(n)
+	}
+	return position
+}
@@ -19,7 +19,7 @@ func ApplyMacros(filter Filter, declarations *Declarations, macros ...Macro) (Fi
 }
 
 func applyMacros(exp *expr.Expr, sourceInfo *expr.SourceInfo, macros ...Macro) {
-	nextID := maxID(exp) + 1
+	nextID := MaxID(exp) + 1
 	Walk(func(currExpr, parentExpr *expr.Expr) bool {
 		cursor := &Cursor{
 			sourceInfo: sourceInfo,
@@ -75,14 +75,3 @@ func (c *Cursor) Replace(newExpr *expr.Expr) {
 	c.currExpr.ExprKind = newExpr.GetExprKind()
 	c.replaced = true
 }
-
-func maxID(exp *expr.Expr) int64 {
-	var max int64
-	Walk(func(_, _ *expr.Expr) bool {
-		if exp.GetId() > max {
-			max = exp.GetId()
-		}
-		return true
-	}, exp)
-	return max
-}
@@ -0,0 +1,49 @@
+package macros
+
+import (
+	"strings"
+
+	"go.einride.tech/aip/filtering"
+	"go.einride.tech/aip/filtering/exprs"
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+)
+
+// Lower function and overloads, declared by CaseInsensitive.
+const (
+	FunctionLower               = filtering.FunctionLower
+	FunctionOverloadLowerString = filtering.FunctionOverloadLowerString
+)
+
+// CaseInsensitive returns a Macro that makes text matching of the string fields with the provided qualified names
+// case-insensitive.
+//
+// Equality, inequality and has comparisons with constant strings, such as `display_name = "Factory*"`, are rewritten
+// to compare the lowercase field with the lowercase constant, as in `lower(display_name) = "factory*"`. The macro
+// declares the `lower` function, which is implemented by the library.
+func CaseInsensitive(names ...string) Macro {
+	return Macro{
+		declarations: []filtering.DeclarationOption{
+			filtering.DeclareFunction(
+				FunctionLower,
+				filtering.NewFunctionOverload(FunctionOverloadLowerString, filtering.TypeString, filtering.TypeString),
+			),
+		},
+		apply: func(cursor *filtering.Cursor, _ map[int64]*expr.Type) {
+			var function, value string
+			var field *expr.Expr
+			if !exprs.MatchAnyFunction(&function, matchNames(names, &field), exprs.MatchAnyString(&value))(cursor.Expr()) {
+				return
+			}
+			switch function {
+			case filtering.FunctionEquals, filtering.FunctionNotEquals, filtering.FunctionHas:
+			default:
+				return
+			}
+			cursor.Replace(filtering.Function(
+				function,
+				filtering.Function(FunctionLower, clone(field)),
+				filtering.String(strings.ToLower(value)),
+			))
+		},
+	}
+}
@@ -0,0 +1,131 @@
+package macros
+
+import (
+	"testing"
+
+	"go.einride.tech/aip/filtering"
+	"go.einride.tech/aip/filtering/sqlfilter"
+	freightv1 "go.einride.tech/aip/proto/gen/einride/example/freight/v1"
+	"gotest.tools/v3/assert"
+)
+
+func TestCaseInsensitive(t *testing.T) {
+	t.Parallel()
+	macro := CaseInsensitive("external_reference_id", "annotations.team")
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		filtering.DeclareIdent("name", filtering.TypeString),
+		filtering.DeclareIdent("external_reference_id", filtering.TypeString),
+		filtering.DeclareIdent("annotations", filtering.TypeMap(filtering.TypeString, filtering.TypeString)),
+		filtering.DeclareAlias("reference", "external_reference_id"),
+		Declare(macro),
+	)
+	assert.NilError(t, err)
+	registry, err := filtering.NewFunctionRegistry(declarations, ImplementFunctions(macro))
+	assert.NilError(t, err)
+	shipment := &freightv1.Shipment{
+		Name:                "shippers/1/shipments/1",
+		ExternalReferenceId: "ACME-123",
+		Annotations:         map[string]string{"team": "Freight"},
+	}
+	for _, tt := range []struct {
+		name     string
+		filter   string
+		expected string
+		matches  bool
+	}{
+		{
+			name:     "equals",
+			filter:   `annotations.team = "FREIGHT"`,
+			expected: `lower(annotations.team) = "freight"`,
+			matches:  true,
+		},
+		{
+			name:     "not equals wildcard",
+			filter:   `annotations.team != "FR*"`,
+			expected: `lower(annotations.team) != "fr*"`,
+			matches:  false,
+		},
+		{
+			name:     "has",
+			filter:   `annotations.team:"EIG"`,
+			expected: `lower(annotations.team):"eig"`,
+			matches:  true,
+		},
+		{
+			name:     "alias",
+			filter:   `reference = "acme-*"`,
+			expected: `lower(external_reference_id) = "acme-*"`,
+			matches:  true,
+		},
+		{
+			name:     "presence",
+			filter:   `external_reference_id:*`,
+			expected: `external_reference_id:*`,
+			matches:  true,
+		},
+		{
+			name:     "quoted wildcard",
+			filter:   `external_reference_id:"*"`,
+			expected: `lower(external_reference_id):"*"`,
+			matches:  false,
+		},
+		{
+			name:     "other field",
+			filter:   `name = "SHIPPERS/1/SHIPMENTS/1"`,
+			expected: `name = "SHIPPERS/1/SHIPMENTS/1"`,
+			matches:  false,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			filter, err := filtering.ParseFilter(&mockRequest{filter: tt.filter}, declarations)
+			assert.NilError(t, err)
+			actual, err := Apply(filter, declarations, macro)
+			assert.NilError(t, err)
+			assertUnparse(t, tt.expected, actual)
+			matches, err := filtering.Evaluate(actual, shipment, filtering.WithFunctions(registry))
+			assert.NilError(t, err)
+			assert.Equal(t, tt.matches, matches)
+		})
+	}
+}
+
+func TestCaseInsensitive_sql(t *testing.T) {
+	t.Parallel()
+	macro := CaseInsensitive("display_name")
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		filtering.DeclareIdent("display_name", filtering.TypeString),
+		Declare(macro),
+	)
+	assert.NilError(t, err)
+	registry, err := filtering.NewFunctionRegistry(declarations, ImplementFunctions(macro))
+	assert.NilError(t, err)
+	filter, err := filtering.ParseFilter(&mockRequest{filter: `display_name = "Factory*"`}, declarations)
+	assert.NilError(t, err)
+	filter, err = Apply(filter, declarations, macro)
+	assert.NilError(t, err)
+	sql, args, err := sqlfilter.Transpile(filter, sqlfilter.WithFunctions(registry))
+	assert.NilError(t, err)
+	assert.Equal(t, `LOWER("display_name") LIKE $1 ESCAPE '\'`, sql)
+	assert.DeepEqual(t, []interface{}{"factory%"}, args)
+}
+
+func TestCaseInsensitive_qualifiedAlias(t *testing.T) {
+	t.Parallel()
+	macro := CaseInsensitive("origin.display_name")
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		filtering.DeclareIdent("origin.display_name", filtering.TypeString),
+		filtering.DeclareAlias("origin_name", "origin.display_name"),
+		Declare(macro),
+	)
+	assert.NilError(t, err)
+	filter, err := filtering.ParseFilter(&mockRequest{filter: `origin_name = "Factory"`}, declarations)
+	assert.NilError(t, err)
+	filter, err = Apply(filter, declarations, macro)
+	assert.NilError(t, err)
+	assertUnparse(t, `lower(origin.display_name) = "factory"`, filter)
+}
@@ -0,0 +1,7 @@
+// Package macros provides reusable macros for rewriting type-checked AIP filters.
+//
+// Each Macro declares the additional declarations it needs, which are declared with Declare, and the implementations
+// of any custom functions it introduces, which are registered with ImplementFunctions. Macros are applied with Apply.
+//
+// See: https://google.aip.dev/160 (Filtering)
+package macros
@@ -0,0 +1,69 @@
+package macros
+
+import (
+	"go.einride.tech/aip/filtering"
+	"go.einride.tech/aip/filtering/exprs"
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// EnumNumbers returns a Macro that maps the value names of the provided enum type to their numbers, for backends that
+// store enum values as numbers.
+//
+// Equality and inequality comparisons of enum fields, such as `status = STATUS_ACTIVE`, are rewritten to compare
+// with the number of the value, as in `status = 1`, and has comparisons of repeated enum fields are rewritten
+// likewise. The macro declares overloads of `=`, `!=` and `:` for comparing values of the enum type with ints, which
+// also allows filters to compare enum fields with numbers.
+//
+// The rewritten filters can be transpiled to SQL and inspected by backends, but can not be evaluated, since
+// evaluated enum values are value names.
+func EnumNumbers(enumType protoreflect.EnumType) Macro {
+	enum := filtering.TypeEnum(enumType)
+	list := filtering.TypeList(enum)
+	return Macro{
+		declarations: []filtering.DeclarationOption{
+			filtering.DeclareStandardFunctionOverloads(
+				filtering.FunctionEquals,
+				filtering.NewFunctionOverload(
+					filtering.FunctionEquals+"_"+enum.GetMessageType()+"_int", filtering.TypeBool, enum, filtering.TypeInt,
+				),
+			),
+			filtering.DeclareStandardFunctionOverloads(
+				filtering.FunctionNotEquals,
+				filtering.NewFunctionOverload(
+					filtering.FunctionNotEquals+"_"+enum.GetMessageType()+"_int", filtering.TypeBool, enum, filtering.TypeInt,
+				),
+			),
+			filtering.DeclareStandardFunctionOverloads(
+				filtering.FunctionHas,
+				filtering.NewFunctionOverload(
+					filtering.FunctionHas+"_list_"+enum.GetMessageType()+"_int", filtering.TypeBool, list, filtering.TypeInt,
+				),
+			),
+		},
+		apply: func(cursor *filtering.Cursor, types map[int64]*expr.Type) {
+			var function, valueName string
+			var field *expr.Expr
+			// Enum values are parsed as idents, except as args of the has function where they are parsed as strings.
+			matchComparison := exprs.MatchAnyFunction(&function, matchType(types, enum, &field), exprs.MatchAnyText(&valueName))
+			matchHas := exprs.MatchFunction(
+				filtering.FunctionHas, matchType(types, list, &field), exprs.MatchAnyString(&valueName),
+			)
+			switch {
+			case matchComparison(cursor.Expr()):
+				if function != filtering.FunctionEquals && function != filtering.FunctionNotEquals {
+					return
+				}
+			case matchHas(cursor.Expr()):
+				function = filtering.FunctionHas
+			default:
+				return
+			}
+			value := enumType.Descriptor().Values().ByName(protoreflect.Name(valueName))
+			if value == nil {
+				return
+			}
+			cursor.Replace(filtering.Function(function, clone(field), filtering.Int(int64(value.Number()))))
+		},
+	}
+}
@@ -0,0 +1,61 @@
+package macros
+
+import (
+	"testing"
+
+	"go.einride.tech/aip/filtering"
+	"go.einride.tech/aip/filtering/sqlfilter"
+	syntaxv1 "go.einride.tech/aip/proto/gen/einride/example/syntax/v1"
+	"gotest.tools/v3/assert"
+)
+
+func TestEnumNumbers(t *testing.T) {
+	t.Parallel()
+	macro := EnumNumbers(syntaxv1.Enum(0).Type())
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		filtering.DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+		filtering.DeclareIdent("repeated_enum", filtering.TypeList(filtering.TypeEnum(syntaxv1.Enum(0).Type()))),
+		filtering.DeclareIdent("string", filtering.TypeString),
+		Declare(macro),
+	)
+	assert.NilError(t, err)
+	for _, tt := range []struct {
+		filter   string
+		expected string
+	}{
+		{filter: `enum = ENUM_ONE`, expected: `enum = 1`},
+		{filter: `enum != ENUM_TWO AND repeated_enum:ENUM_ONE`, expected: `enum != 2 AND repeated_enum:1`},
+		{filter: `enum = 2`, expected: `enum = 2`},
+		{filter: `string = "ENUM_ONE"`, expected: `string = "ENUM_ONE"`},
+	} {
+		tt := tt
+		t.Run(tt.filter, func(t *testing.T) {
+			t.Parallel()
+			filter, err := filtering.ParseFilter(&mockRequest{filter: tt.filter}, declarations)
+			assert.NilError(t, err)
+			actual, err := Apply(filter, declarations, macro)
+			assert.NilError(t, err)
+			assertUnparse(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestEnumNumbers_sql(t *testing.T) {
+	t.Parallel()
+	macro := EnumNumbers(syntaxv1.Enum(0).Type())
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		filtering.DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+		Declare(macro),
+	)
+	assert.NilError(t, err)
+	filter, err := filtering.ParseFilter(&mockRequest{filter: `enum = ENUM_TWO`}, declarations)
+	assert.NilError(t, err)
+	filter, err = Apply(filter, declarations, macro)
+	assert.NilError(t, err)
+	sql, args, err := sqlfilter.Transpile(filter)
+	assert.NilError(t, err)
+	assert.Equal(t, `"enum" = $1`, sql)
+	assert.DeepEqual(t, []interface{}{int64(2)}, args)
+}
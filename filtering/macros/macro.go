@@ -0,0 +1,143 @@
+package macros
+
+import (
+	"sort"
+	"strings"
+
+	"go.einride.tech/aip/filtering"
+	"go.einride.tech/aip/filtering/exprs"
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Macro is a reusable filter macro, together with the declarations and function implementations it needs.
+type Macro struct {
+	// declarations are the additional declarations needed to check filters before and after the macro is applied.
+	declarations []filtering.DeclarationOption
+	// functions are the Go implementations of the custom function overloads introduced by the macro.
+	functions map[string]filtering.FunctionImplementation
+	// sqlFunctions are the SQL renderings of the custom function overloads introduced by the macro.
+	sqlFunctions map[string]filtering.SQLFunction
+	// apply rewrites the expression at the cursor, given the types of the checked filter.
+	apply func(cursor *filtering.Cursor, types map[int64]*expr.Type)
+}
+
+// Declare is a DeclarationOption that declares the additional declarations needed by the provided macros.
+//
+// The declarations are needed both to check the filters that the macros are applied to, and the rewritten filters.
+func Declare(macros ...Macro) filtering.DeclarationOption {
+	return func(declarations *filtering.Declarations) error {
+		for _, macro := range macros {
+			for _, opt := range macro.declarations {
+				if err := opt(declarations); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// ImplementFunctions is a FunctionRegistryOption that registers the Go implementations and SQL renderings of the
+// custom functions introduced by the provided macros.
+//
+// Functions implemented by the library, such as the `lower` function declared by CaseInsensitive, need no
+// registered implementations.
+func ImplementFunctions(macros ...Macro) filtering.FunctionRegistryOption {
+	return func(registry *filtering.FunctionRegistry) error {
+		functions := map[string]filtering.FunctionImplementation{}
+		sqlFunctions := map[string]filtering.SQLFunction{}
+		for _, macro := range macros {
+			for overloadID, implementation := range macro.functions {
+				functions[overloadID] = implementation
+			}
+			for overloadID, sql := range macro.sqlFunctions {
+				sqlFunctions[overloadID] = sql
+			}
+		}
+		for _, overloadID := range sortedKeys(functions) {
+			if err := filtering.ImplementFunction(overloadID, functions[overloadID])(registry); err != nil {
+				return err
+			}
+		}
+		for _, overloadID := range sortedKeys(sqlFunctions) {
+			if err := filtering.ImplementSQLFunction(overloadID, sqlFunctions[overloadID])(registry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Apply applies the provided macros to the filter and type-checks the result against the provided declarations,
+// which should include the declarations of the macros.
+//
+// The filter is rewritten in place. An empty filter is returned as is.
+func Apply(filter filtering.Filter, declarations *filtering.Declarations, macros ...Macro) (filtering.Filter, error) {
+	if filter.CheckedExpr == nil {
+		return filter, nil
+	}
+	// The type map of the checked filter is valid for all expressions not yet rewritten, since rewritten expressions
+	// are given new IDs.
+	types := filter.CheckedExpr.GetTypeMap()
+	filteringMacros := make([]filtering.Macro, 0, len(macros))
+	for _, macro := range macros {
+		macro := macro
+		filteringMacros = append(filteringMacros, func(cursor *filtering.Cursor) {
+			macro.apply(cursor, types)
+		})
+	}
+	return filtering.ApplyMacros(filter, declarations, filteringMacros...)
+}
+
+// matchNames matches idents and member expressions with any of the provided qualified names, such as
+// `origin.display_name`. The matched expression is populated in argument e.
+func matchNames(names []string, e **expr.Expr) exprs.Matcher {
+	matchers := make([]exprs.Matcher, 0, len(names))
+	for _, name := range names {
+		matchers = append(matchers, matchName(name))
+	}
+	return func(exp *expr.Expr) bool {
+		for _, matcher := range matchers {
+			if matcher(exp) {
+				*e = exp
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// matchName matches an ident or member expression with the provided qualified name.
+func matchName(name string) exprs.Matcher {
+	i := strings.LastIndexByte(name, '.')
+	if i == -1 {
+		return exprs.MatchText(name)
+	}
+	return exprs.MatchMember(matchName(name[:i]), name[i+1:])
+}
+
+// matchType matches an expression with the provided checked type.
+func matchType(types map[int64]*expr.Type, t *expr.Type, e **expr.Expr) exprs.Matcher {
+	return func(exp *expr.Expr) bool {
+		if !proto.Equal(types[exp.GetId()], t) {
+			return false
+		}
+		*e = exp
+		return true
+	}
+}
+
+// clone returns a copy of the provided expression, for use in a replacement expression.
+func clone(e *expr.Expr) *expr.Expr {
+	return proto.Clone(e).(*expr.Expr)
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	result := make([]string, 0, len(m))
+	for key := range m {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,80 @@
+package macros
+
+import (
+	"testing"
+
+	"go.einride.tech/aip/filtering"
+	"gotest.tools/v3/assert"
+)
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+	macros := []Macro{
+		CaseInsensitive("display_name"),
+		ResourceName("shipper", "shippers/{shipper}"),
+	}
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		filtering.DeclareIdent("display_name", filtering.TypeString),
+		filtering.DeclareIdent("shipper", filtering.TypeString),
+		Declare(macros...),
+	)
+	assert.NilError(t, err)
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		filter, err := filtering.ParseFilter(&mockRequest{}, declarations)
+		assert.NilError(t, err)
+		actual, err := Apply(filter, declarations, macros...)
+		assert.NilError(t, err)
+		assert.Assert(t, actual.CheckedExpr == nil)
+	})
+	t.Run("macros", func(t *testing.T) {
+		t.Parallel()
+		filter, err := filtering.ParseFilter(
+			&mockRequest{filter: `display_name = "Factory" AND shipper = "1"`},
+			declarations,
+		)
+		assert.NilError(t, err)
+		actual, err := Apply(filter, declarations, macros...)
+		assert.NilError(t, err)
+		assertUnparse(t, `lower(display_name) = "factory" AND shipper = "shippers/1"`, actual)
+	})
+	t.Run("undeclared", func(t *testing.T) {
+		t.Parallel()
+		undeclared, err := filtering.NewDeclarations(
+			filtering.DeclareStandardFunctions(),
+			filtering.DeclareIdent("display_name", filtering.TypeString),
+		)
+		assert.NilError(t, err)
+		filter, err := filtering.ParseFilter(&mockRequest{filter: `display_name = "Factory"`}, undeclared)
+		assert.NilError(t, err)
+		_, err = Apply(filter, undeclared, macros...)
+		assert.ErrorContains(t, err, "undeclared function 'lower'")
+	})
+}
+
+func TestImplementFunctions(t *testing.T) {
+	t.Parallel()
+	macros := []Macro{CaseInsensitive("display_name"), CaseInsensitive("name")}
+	declarations, err := filtering.NewDeclarations(filtering.DeclareStandardFunctions(), Declare(macros...))
+	assert.NilError(t, err)
+	registry, err := filtering.NewFunctionRegistry(declarations, ImplementFunctions(macros...))
+	assert.NilError(t, err)
+	assert.NilError(t, registry.ValidateSQL())
+}
+
+// assertUnparse asserts that the provided filter unparses to the expected filter.
+func assertUnparse(t *testing.T, expected string, filter filtering.Filter) {
+	t.Helper()
+	actual, err := filtering.Unparse(filter.CheckedExpr.GetExpr())
+	assert.NilError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+type mockRequest struct {
+	filter string
+}
+
+func (m *mockRequest) GetFilter() string {
+	return m.filter
+}
@@ -0,0 +1,66 @@
+package macros
+
+import (
+	"time"
+
+	"go.einride.tech/aip/filtering"
+	"go.einride.tech/aip/filtering/exprs"
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+)
+
+// RelativeTime returns a Macro that expands comparisons of timestamps with durations to comparisons with timestamps
+// relative to the current time, as returned by the provided function.
+//
+// For example, `create_time > duration("-24h")` is rewritten to `create_time > timestamp("2022-08-11T22:22:22Z")`
+// when the current time is 2022-08-12T22:22:22Z. The macro declares overloads of the comparison functions for
+// comparing timestamps with durations. Relative timestamps are truncated to seconds.
+func RelativeTime(now func() time.Time) Macro {
+	comparisons := []string{
+		filtering.FunctionEquals,
+		filtering.FunctionNotEquals,
+		filtering.FunctionLessThan,
+		filtering.FunctionLessEquals,
+		filtering.FunctionGreaterThan,
+		filtering.FunctionGreaterEquals,
+	}
+	declarations := make([]filtering.DeclarationOption, 0, len(comparisons))
+	for _, function := range comparisons {
+		declarations = append(declarations, filtering.DeclareStandardFunctionOverloads(
+			function,
+			filtering.NewFunctionOverload(
+				function+"_timestamp_duration", filtering.TypeBool, filtering.TypeTimestamp, filtering.TypeDuration,
+			),
+		))
+	}
+	return Macro{
+		declarations: declarations,
+		apply: func(cursor *filtering.Cursor, types map[int64]*expr.Type) {
+			var function, value string
+			var field *expr.Expr
+			if !exprs.MatchAnyFunction(
+				&function,
+				matchType(types, filtering.TypeTimestamp, &field),
+				exprs.MatchFunction(filtering.FunctionDuration, exprs.MatchAnyString(&value)),
+			)(cursor.Expr()) {
+				return
+			}
+			if !isComparison(function, comparisons) {
+				return
+			}
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return
+			}
+			cursor.Replace(filtering.Function(function, clone(field), filtering.Timestamp(now().Add(d).UTC())))
+		},
+	}
+}
+
+func isComparison(function string, comparisons []string) bool {
+	for _, comparison := range comparisons {
+		if function == comparison {
+			return true
+		}
+	}
+	return false
+}
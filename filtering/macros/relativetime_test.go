@@ -0,0 +1,50 @@
+package macros
+
+import (
+	"testing"
+	"time"
+
+	"go.einride.tech/aip/filtering"
+	"gotest.tools/v3/assert"
+)
+
+func TestRelativeTime(t *testing.T) {
+	t.Parallel()
+	macro := RelativeTime(func() time.Time {
+		return time.Date(2022, 8, 12, 22, 22, 22, 500, time.FixedZone("CEST", 2*60*60))
+	})
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		filtering.DeclareIdent("create_time", filtering.TypeTimestamp),
+		filtering.DeclareIdent("ttl", filtering.TypeDuration),
+		Declare(macro),
+	)
+	assert.NilError(t, err)
+	for _, tt := range []struct {
+		filter   string
+		expected string
+	}{
+		{
+			filter:   `create_time > duration("-24h")`,
+			expected: `create_time > timestamp("2022-08-11T20:22:22Z")`,
+		},
+		{
+			filter:   `create_time <= duration("1h30m") AND ttl > duration("1h")`,
+			expected: `create_time <= timestamp("2022-08-12T21:52:22Z") AND ttl > duration("1h")`,
+		},
+		{
+			filter:   `create_time > "2022-01-01T00:00:00Z"`,
+			expected: `create_time > "2022-01-01T00:00:00Z"`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.filter, func(t *testing.T) {
+			t.Parallel()
+			filter, err := filtering.ParseFilter(&mockRequest{filter: tt.filter}, declarations)
+			assert.NilError(t, err)
+			actual, err := Apply(filter, declarations, macro)
+			assert.NilError(t, err)
+			assertUnparse(t, tt.expected, actual)
+		})
+	}
+}
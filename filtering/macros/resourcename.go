@@ -0,0 +1,40 @@
+package macros
+
+import (
+	"strings"
+
+	"go.einride.tech/aip/filtering"
+	"go.einride.tech/aip/filtering/exprs"
+	"go.einride.tech/aip/resourcename"
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+)
+
+// ResourceName returns a Macro that expands resource IDs compared with the field with the provided qualified name
+// to full resource names of the provided pattern.
+//
+// For example, with the pattern `shippers/{shipper}`, `shipper = "1"` is rewritten to `shipper = "shippers/1"`.
+// The pattern must have a single variable, and values that contain a slash, such as full resource names, are left as
+// is. Equality and inequality comparisons are rewritten, and the macro needs no additional declarations.
+func ResourceName(name, pattern string) Macro {
+	return Macro{
+		apply: func(cursor *filtering.Cursor, _ map[int64]*expr.Type) {
+			var function, value string
+			var field *expr.Expr
+			names := []string{name}
+			if !exprs.MatchAnyFunction(&function, matchNames(names, &field), exprs.MatchAnyString(&value))(cursor.Expr()) {
+				return
+			}
+			if function != filtering.FunctionEquals && function != filtering.FunctionNotEquals {
+				return
+			}
+			if value == "" || strings.ContainsRune(value, '/') {
+				return
+			}
+			resourceName := resourcename.Sprint(pattern, value)
+			if !resourcename.Match(pattern, resourceName) {
+				return
+			}
+			cursor.Replace(filtering.Function(function, clone(field), filtering.String(resourceName)))
+		},
+	}
+}
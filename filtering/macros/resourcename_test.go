@@ -0,0 +1,45 @@
+package macros
+
+import (
+	"testing"
+
+	"go.einride.tech/aip/filtering"
+	"gotest.tools/v3/assert"
+)
+
+func TestResourceName(t *testing.T) {
+	t.Parallel()
+	macros := []Macro{
+		ResourceName("shipper", "shippers/{shipper}"),
+		ResourceName("site", "shippers/{shipper}/sites/{site}"),
+	}
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		filtering.DeclareIdent("shipper", filtering.TypeString),
+		filtering.DeclareIdent("site", filtering.TypeString),
+		Declare(macros...),
+	)
+	assert.NilError(t, err)
+	for _, tt := range []struct {
+		filter   string
+		expected string
+	}{
+		{filter: `shipper = "1"`, expected: `shipper = "shippers/1"`},
+		{filter: `shipper != "1" OR shipper = "2"`, expected: `shipper != "shippers/1" OR shipper = "shippers/2"`},
+		{filter: `shipper = "shippers/1"`, expected: `shipper = "shippers/1"`},
+		{filter: `shipper = "*"`, expected: `shipper = "shippers/*"`},
+		{filter: `shipper = ""`, expected: `shipper = ""`},
+		{filter: `shipper:"1"`, expected: `shipper:"1"`},
+		{filter: `site = "1"`, expected: `site = "1"`},
+	} {
+		tt := tt
+		t.Run(tt.filter, func(t *testing.T) {
+			t.Parallel()
+			filter, err := filtering.ParseFilter(&mockRequest{filter: tt.filter}, declarations)
+			assert.NilError(t, err)
+			actual, err := Apply(filter, declarations, macros...)
+			assert.NilError(t, err)
+			assertUnparse(t, tt.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package filtering
+
+import (
+	"strings"
+
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// MessageFieldsOption configures DeclareMessageFields.
+type MessageFieldsOption func(*messageFieldsOptions)
+
+type messageFieldsOptions struct {
+	allowedPaths []string
+	deniedPaths  []string
+}
+
+// AllowFieldPaths is a MessageFieldsOption that only declares the provided field paths and their subfields.
+func AllowFieldPaths(paths ...string) MessageFieldsOption {
+	return func(options *messageFieldsOptions) {
+		options.allowedPaths = append(options.allowedPaths, paths...)
+	}
+}
+
+// DenyFieldPaths is a MessageFieldsOption that excludes the provided field paths and their subfields.
+func DenyFieldPaths(paths ...string) MessageFieldsOption {
+	return func(options *messageFieldsOptions) {
+		options.deniedPaths = append(options.deniedPaths, paths...)
+	}
+}
+
+// DeclareMessageFields is a DeclarationOption that declares idents for the fields of the provided message.
+//
+// Scalar, enum, timestamp, duration, wrapper and google.protobuf.Struct fields are declared with their corresponding
+// types, and repeated and map fields of those types are declared as lists and maps. Fields of nested messages are
+// declared with qualified names, such as "origin.display_name", and the nested messages themselves with their message
+// types, which supports presence checks such as `origin:*`. Recursive messages are declared with their message types,
+// but their fields are not declared. Repeated fields of nested messages are declared as lists of messages, which
+// supports restrictions on the fields of any element, such as `line_items.title = "ABC"`. Map fields of nested
+// messages and bytes fields are not declared.
+func DeclareMessageFields(message protoreflect.MessageDescriptor, opts ...MessageFieldsOption) DeclarationOption {
+	return func(declarations *Declarations) error {
+		var options messageFieldsOptions
+		for _, opt := range opts {
+			opt(&options)
+		}
+		return declarations.declareMessageFields(message, "", &options, map[protoreflect.FullName]bool{})
+	}
+}
+
+func (d *Declarations) declareMessageFields(
+	message protoreflect.MessageDescriptor,
+	parentPath string,
+	options *messageFieldsOptions,
+	visited map[protoreflect.FullName]bool,
+) error {
+	visited[message.FullName()] = true
+	defer delete(visited, message.FullName())
+	fields := message.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		path := string(field.Name())
+		if parentPath != "" {
+			path = parentPath + "." + path
+		}
+		if field.Kind() == protoreflect.MessageKind && !field.IsList() && !field.IsMap() {
+			if _, ok := wellKnownType(field.Message()); !ok {
+				// Nested messages are declared with their message type, which supports presence checks such as
+				// `lat_lng:*`, unless some of their fields are excluded.
+				if options.includesAll(path) {
+					if err := d.declareMessageIdent(path, field.Message()); err != nil {
+						return err
+					}
+				}
+				if visited[field.Message().FullName()] || !options.traverses(path) {
+					continue
+				}
+				if err := d.declareMessageFields(field.Message(), path, options, visited); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !options.includes(path) {
+			continue
+		}
+		if err := d.declareMessageField(path, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Declarations) declareMessageField(path string, field protoreflect.FieldDescriptor) error {
+	switch {
+	case field.IsMap():
+		keyType, ok := fieldElementType(field.MapKey())
+		if !ok {
+			return nil
+		}
+		valueType, ok := fieldElementType(field.MapValue())
+		if !ok {
+			return nil
+		}
+		if err := d.declareFieldEnumType(field.MapValue()); err != nil {
+			return err
+		}
+		return d.declareIdent(path, TypeMap(keyType, valueType))
+	case field.IsList():
+		elementType, ok := fieldElementType(field)
+		if !ok {
+			if field.Kind() != protoreflect.MessageKind {
+				return nil
+			}
+			// Repeated message fields support member access to the fields of their elements.
+			if err := d.declareMessageType(field.Message()); err != nil {
+				return err
+			}
+			return d.declareIdent(path, TypeList(TypeMessage(field.Message())))
+		}
+		if err := d.declareFieldEnumType(field); err != nil {
+			return err
+		}
+		return d.declareIdent(path, TypeList(elementType))
+	case field.Kind() == protoreflect.EnumKind:
+		return d.declareEnumIdent(path, fieldEnumType(field))
+	default:
+		t, ok := fieldElementType(field)
+		if !ok {
+			return nil
+		}
+		return d.declareIdent(path, t)
+	}
+}
+
+func (d *Declarations) declareFieldEnumType(field protoreflect.FieldDescriptor) error {
+	if field.Kind() != protoreflect.EnumKind {
+		return nil
+	}
+	return d.declareEnumType(fieldEnumType(field))
+}
+
+// fieldElementType returns the type of a singular value of the provided field.
+func fieldElementType(field protoreflect.FieldDescriptor) (*expr.Type, bool) {
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		return TypeBool, true
+	case protoreflect.Int32Kind,
+		protoreflect.Sint32Kind,
+		protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind,
+		protoreflect.Sint64Kind,
+		protoreflect.Sfixed64Kind,
+		protoreflect.Uint32Kind,
+		protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind,
+		protoreflect.Fixed64Kind:
+		return TypeInt, true
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return TypeFloat, true
+	case protoreflect.StringKind:
+		return TypeString, true
+	case protoreflect.EnumKind:
+		return TypeEnum(fieldEnumType(field)), true
+	case protoreflect.MessageKind:
+		return wellKnownType(field.Message())
+	default:
+		return nil, false
+	}
+}
+
+// fieldType returns the type of the provided field, where fields of nested messages have message types.
+func fieldType(field protoreflect.FieldDescriptor) (*expr.Type, bool) {
+	switch {
+	case field.IsMap():
+		keyType, ok := fieldElementType(field.MapKey())
+		if !ok {
+			return nil, false
+		}
+		valueType, ok := fieldValueType(field.MapValue())
+		if !ok {
+			return nil, false
+		}
+		return TypeMap(keyType, valueType), true
+	case field.IsList():
+		elementType, ok := fieldValueType(field)
+		if !ok {
+			return nil, false
+		}
+		return TypeList(elementType), true
+	default:
+		return fieldValueType(field)
+	}
+}
+
+// fieldValueType returns the type of a singular value of the provided field, including message types.
+func fieldValueType(field protoreflect.FieldDescriptor) (*expr.Type, bool) {
+	if field.Kind() == protoreflect.MessageKind {
+		if t, ok := wellKnownType(field.Message()); ok {
+			return t, true
+		}
+		return TypeMessage(field.Message()), true
+	}
+	return fieldElementType(field)
+}
+
+// wellKnownType returns the type of the provided message, if it is a supported well-known type.
+func wellKnownType(message protoreflect.MessageDescriptor) (*expr.Type, bool) {
+	switch message.FullName() {
+	case "google.protobuf.Timestamp":
+		return TypeTimestamp, true
+	case "google.protobuf.Duration":
+		return TypeDuration, true
+	case "google.protobuf.Int64Value",
+		"google.protobuf.Int32Value",
+		"google.protobuf.UInt64Value",
+		"google.protobuf.UInt32Value":
+		return TypeIntWrapper, true
+	case "google.protobuf.DoubleValue", "google.protobuf.FloatValue":
+		return TypeFloatWrapper, true
+	case "google.protobuf.StringValue":
+		return TypeStringWrapper, true
+	case "google.protobuf.BoolValue":
+		return TypeBoolWrapper, true
+	case "google.protobuf.Struct":
+		return TypeStruct, true
+	case "google.protobuf.Value":
+		return TypeDyn, true
+	case "google.protobuf.ListValue":
+		return TypeList(TypeDyn), true
+	default:
+		return nil, false
+	}
+}
+
+// fieldEnumType returns the enum type of the provided enum field.
+func fieldEnumType(field protoreflect.FieldDescriptor) protoreflect.EnumType {
+	if enumType, err := protoregistry.GlobalTypes.FindEnumByName(field.Enum().FullName()); err == nil {
+		return enumType
+	}
+	return dynamicpb.NewEnumType(field.Enum())
+}
+
+// includes returns true if the field with the provided path should be declared.
+func (o *messageFieldsOptions) includes(path string) bool {
+	if o.isDenied(path) {
+		return false
+	}
+	if len(o.allowedPaths) == 0 {
+		return true
+	}
+	for _, allowedPath := range o.allowedPaths {
+		if isPathOrSubPath(path, allowedPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// includesAll returns true if the field with the provided path and all its subfields should be declared.
+func (o *messageFieldsOptions) includesAll(path string) bool {
+	if !o.includes(path) {
+		return false
+	}
+	for _, deniedPath := range o.deniedPaths {
+		if isPathOrSubPath(deniedPath, path) {
+			return false
+		}
+	}
+	return true
+}
+
+// traverses returns true if the fields of the message field with the provided path should be traversed.
+func (o *messageFieldsOptions) traverses(path string) bool {
+	if o.includes(path) {
+		return true
+	}
+	if o.isDenied(path) {
+		return false
+	}
+	for _, allowedPath := range o.allowedPaths {
+		if isPathOrSubPath(allowedPath, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *messageFieldsOptions) isDenied(path string) bool {
+	for _, deniedPath := range o.deniedPaths {
+		if isPathOrSubPath(path, deniedPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPathOrSubPath returns true if path is equal to, or a sub-path of, the provided parent path.
+func isPathOrSubPath(path, parent string) bool {
+	return path == parent || strings.HasPrefix(path, parent+".")
+}
@@ -0,0 +1,221 @@
+package filtering
+
+import (
+	"testing"
+
+	freightv1 "go.einride.tech/aip/proto/gen/einride/example/freight/v1"
+	syntaxv1 "go.einride.tech/aip/proto/gen/einride/example/syntax/v1"
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/genproto/googleapis/type/latlng"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"gotest.tools/v3/assert"
+)
+
+func TestDeclareMessageFields(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		name       string
+		message    protoreflect.MessageDescriptor
+		opts       []MessageFieldsOption
+		expected   map[string]*expr.Type
+		undeclared []string
+	}{
+		{
+			name:    "shipment",
+			message: (&freightv1.Shipment{}).ProtoReflect().Descriptor(),
+			expected: map[string]*expr.Type{
+				"name":                  TypeString,
+				"create_time":           TypeTimestamp,
+				"annotations":           TypeMap(TypeString, TypeString),
+				"external_reference_id": TypeString,
+				"line_items":            TypeList(TypeMessage((&freightv1.LineItem{}).ProtoReflect().Descriptor())),
+			},
+			undeclared: []string{"line_items.title", "line_items_map"},
+		},
+		{
+			name:    "nested message",
+			message: (&freightv1.Site{}).ProtoReflect().Descriptor(),
+			expected: map[string]*expr.Type{
+				"lat_lng":           TypeMessage((&latlng.LatLng{}).ProtoReflect().Descriptor()),
+				"lat_lng.latitude":  TypeFloat,
+				"lat_lng.longitude": TypeFloat,
+				"personnel_count":   TypeInt,
+			},
+		},
+		{
+			name:    "scalars and enums",
+			message: (&syntaxv1.Message{}).ProtoReflect().Descriptor(),
+			expected: map[string]*expr.Type{
+				"bool":             TypeBool,
+				"uint64":           TypeInt,
+				"float":            TypeFloat,
+				"enum":             TypeEnum(syntaxv1.Enum(0).Type()),
+				"repeated_int64":   TypeList(TypeInt),
+				"repeated_enum":    TypeList(TypeEnum(syntaxv1.Enum(0).Type())),
+				"ENUM_ONE":         TypeEnum(syntaxv1.Enum(0).Type()),
+				"oneof_string":     TypeString,
+				"repeated_string":  TypeList(TypeString),
+				"repeated_message": TypeList(TypeMessage((&syntaxv1.Message{}).ProtoReflect().Descriptor())),
+				"message":          TypeMessage((&syntaxv1.Message{}).ProtoReflect().Descriptor()),
+			},
+			undeclared: []string{"bytes", "message.string", "map_string_message"},
+		},
+		{
+			name:    "well-known types",
+			message: newWellKnownTypesMessage(t),
+			expected: map[string]*expr.Type{
+				"weight":     TypeIntWrapper,
+				"count":      TypeIntWrapper,
+				"score":      TypeFloatWrapper,
+				"nickname":   TypeStringWrapper,
+				"active":     TypeBoolWrapper,
+				"metadata":   TypeStruct,
+				"attributes": TypeDyn,
+				"tags":       TypeList(TypeDyn),
+			},
+			undeclared: []string{"weight.value", "metadata.fields"},
+		},
+		{
+			name:    "allow",
+			message: (&freightv1.Site{}).ProtoReflect().Descriptor(),
+			opts:    []MessageFieldsOption{AllowFieldPaths("name", "lat_lng.latitude")},
+			expected: map[string]*expr.Type{
+				"name":             TypeString,
+				"lat_lng.latitude": TypeFloat,
+			},
+			undeclared: []string{"display_name", "lat_lng", "lat_lng.longitude"},
+		},
+		{
+			name:    "deny",
+			message: (&freightv1.Site{}).ProtoReflect().Descriptor(),
+			opts:    []MessageFieldsOption{DenyFieldPaths("lat_lng", "create_time")},
+			expected: map[string]*expr.Type{
+				"name":         TypeString,
+				"display_name": TypeString,
+			},
+			undeclared: []string{"create_time", "lat_lng", "lat_lng.latitude", "lat_lng.longitude"},
+		},
+		{
+			name:    "deny nested field",
+			message: (&freightv1.Site{}).ProtoReflect().Descriptor(),
+			opts:    []MessageFieldsOption{DenyFieldPaths("lat_lng.longitude")},
+			expected: map[string]*expr.Type{
+				"lat_lng.latitude": TypeFloat,
+			},
+			undeclared: []string{"lat_lng", "lat_lng.longitude"},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			declarations, err := NewDeclarations(DeclareMessageFields(tt.message, tt.opts...))
+			assert.NilError(t, err)
+			for name, expectedType := range tt.expected {
+				ident, ok := declarations.LookupIdent(name)
+				assert.Assert(t, ok, "expected %s to be declared", name)
+				assert.DeepEqual(t, expectedType, ident.GetIdent().GetType(), protocmp.Transform())
+			}
+			for _, name := range tt.undeclared {
+				_, ok := declarations.LookupIdent(name)
+				assert.Assert(t, !ok, "expected %s to be undeclared", name)
+			}
+		})
+	}
+}
+
+func TestDeclareMessageFields_parseFilter(t *testing.T) {
+	t.Parallel()
+	declarations, err := NewDeclarations(
+		DeclareStandardFunctions(),
+		DeclareMessageFields((&freightv1.Site{}).ProtoReflect().Descriptor()),
+	)
+	assert.NilError(t, err)
+	filter, err := ParseFilter(&mockRequest{
+		filter: `create_time > "2022-08-12T22:22:22Z" AND lat_lng.latitude > 50.0 AND personnel_count >= 10`,
+	}, declarations)
+	assert.NilError(t, err)
+	assert.Assert(t, filter.CheckedExpr != nil)
+}
+
+func TestDeclareMessageFields_presence(t *testing.T) {
+	t.Parallel()
+	declarations, err := NewDeclarations(
+		DeclareStandardFunctions(),
+		DeclareMessageFields((&syntaxv1.Message{}).ProtoReflect().Descriptor()),
+	)
+	assert.NilError(t, err)
+	for _, tt := range []struct {
+		filter   string
+		message  *syntaxv1.Message
+		expected bool
+	}{
+		{
+			filter:   `message:*`,
+			message:  &syntaxv1.Message{Message: &syntaxv1.Message{}},
+			expected: true,
+		},
+		{
+			filter:   `message:*`,
+			message:  &syntaxv1.Message{},
+			expected: false,
+		},
+		{
+			filter:   `NOT message:* AND string = "foo"`,
+			message:  &syntaxv1.Message{String_: "foo"},
+			expected: true,
+		},
+	} {
+		tt := tt
+		t.Run(tt.filter, func(t *testing.T) {
+			t.Parallel()
+			filter, err := ParseFilter(&mockRequest{filter: tt.filter}, declarations)
+			assert.NilError(t, err)
+			actual, err := Evaluate(filter, tt.message)
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+// newWellKnownTypesMessage returns the descriptor of a message with wrapper and google.protobuf.Struct fields.
+func newWellKnownTypesMessage(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+	field := func(name string, number int32, typeName string) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+			TypeName: proto.String(typeName),
+			JsonName: proto.String(name),
+		}
+	}
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("einride/example/wellknown/v1/resource.proto"),
+		Package:    proto.String("einride.example.wellknown.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/struct.proto", "google/protobuf/wrappers.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Resource"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("weight", 1, ".google.protobuf.Int64Value"),
+					field("count", 2, ".google.protobuf.UInt32Value"),
+					field("score", 3, ".google.protobuf.FloatValue"),
+					field("nickname", 4, ".google.protobuf.StringValue"),
+					field("active", 5, ".google.protobuf.BoolValue"),
+					field("metadata", 6, ".google.protobuf.Struct"),
+					field("attributes", 7, ".google.protobuf.Value"),
+					field("tags", 8, ".google.protobuf.ListValue"),
+				},
+			},
+		},
+	}, protoregistry.GlobalFiles)
+	assert.NilError(t, err)
+	return file.Messages().ByName("Resource")
+}
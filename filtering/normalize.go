@@ -0,0 +1,266 @@
+package filtering
+
+import (
+	"fmt"
+	"sort"
+
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Normalize simplifies the filter into a canonical form and type-checks the result against the provided declarations.
+//
+// The normalization:
+//   - flattens nested AND and OR expressions, and rebuilds them as left-nested chains
+//   - removes duplicate operands of AND and OR expressions
+//   - folds constant bool operands of AND, OR and NOT expressions
+//   - pushes NOT inward using De Morgan's laws, removes double negations and negates = and != comparisons
+//   - converts FUZZY to AND when all operands are bool restrictions
+//   - orders the operands of AND and OR expressions by their unparsed filter syntax
+//
+// The provided filter is not modified.
+func Normalize(filter Filter, declarations *Declarations) (Filter, error) {
+	if filter.CheckedExpr.GetExpr() == nil {
+		return filter, nil
+	}
+	checkedExpr := proto.Clone(filter.CheckedExpr).(*expr.CheckedExpr)
+	n := normalizer{
+		typeMap:      checkedExpr.GetTypeMap(),
+		referenceMap: checkedExpr.GetReferenceMap(),
+		nextID:       MaxID(checkedExpr.GetExpr()) + 1,
+	}
+	normalized, err := n.normalize(checkedExpr.GetExpr())
+	if err != nil {
+		return Filter{}, fmt.Errorf("normalize filter: %w", err)
+	}
+	var checker Checker
+	checker.Init(normalized, checkedExpr.GetSourceInfo(), declarations)
+	result, err := checker.Check()
+	if err != nil {
+		return Filter{}, fmt.Errorf("normalize filter: %w", err)
+	}
+	return Filter{CheckedExpr: result, Warnings: filter.Warnings}, nil
+}
+
+type normalizer struct {
+	typeMap      map[int64]*expr.Type
+	referenceMap map[int64]*expr.Reference
+	nextID       int64
+}
+
+func (n *normalizer) normalize(e *expr.Expr) (*expr.Expr, error) {
+	callExpr := e.GetCallExpr()
+	if callExpr == nil {
+		return e, nil
+	}
+	switch function := n.junctionFunction(e); function {
+	case FunctionAnd, FunctionOr:
+		return n.normalizeJunction(function, callExpr.GetArgs())
+	}
+	if callExpr.GetFunction() == FunctionNot && len(callExpr.GetArgs()) == 1 {
+		return n.negate(e, callExpr.GetArgs()[0])
+	}
+	for i, arg := range callExpr.GetArgs() {
+		normalized, err := n.normalize(arg)
+		if err != nil {
+			return nil, err
+		}
+		callExpr.Args[i] = normalized
+	}
+	return e, nil
+}
+
+// negate returns the normalized negation of the provided expression, reusing the provided NOT expression if needed.
+func (n *normalizer) negate(notExpr, e *expr.Expr) (*expr.Expr, error) {
+	if value, ok := n.boolValue(e); ok {
+		return n.newBool(!value), nil
+	}
+	callExpr := e.GetCallExpr()
+	switch function := n.junctionFunction(e); function {
+	case FunctionAnd, FunctionOr:
+		negatedFunction := FunctionOr
+		if function == FunctionOr {
+			negatedFunction = FunctionAnd
+		}
+		operands := make([]*expr.Expr, 0, len(callExpr.GetArgs()))
+		for _, arg := range callExpr.GetArgs() {
+			operands = append(operands, n.newNot(arg))
+		}
+		return n.normalizeJunction(negatedFunction, operands)
+	}
+	switch callExpr.GetFunction() {
+	case FunctionNot:
+		if len(callExpr.GetArgs()) == 1 {
+			return n.normalize(callExpr.GetArgs()[0])
+		}
+	case FunctionEquals, FunctionNotEquals:
+		// Comparisons of projections, such as `line_items.title = "ABC"`, hold if any element matches, and the
+		// negated comparison would hold if any element does not match.
+		if len(callExpr.GetArgs()) == 2 && !n.hasProjection(e) {
+			if callExpr.GetFunction() == FunctionEquals {
+				callExpr.Function = FunctionNotEquals
+			} else {
+				callExpr.Function = FunctionEquals
+			}
+			return n.normalize(e)
+		}
+	}
+	normalized, err := n.normalize(e)
+	if err != nil {
+		return nil, err
+	}
+	notExpr.GetCallExpr().Args[0] = normalized
+	return notExpr, nil
+}
+
+// hasProjection returns true if the provided expression selects a field of the elements of a repeated message field,
+// such as `line_items.title`.
+func (n *normalizer) hasProjection(e *expr.Expr) bool {
+	var result bool
+	Walk(func(child, _ *expr.Expr) bool {
+		// Expression IDs start at 0, so the operand of non-select expressions must not be looked up.
+		operand := child.GetSelectExpr().GetOperand()
+		if operand != nil && n.typeMap[operand.GetId()].GetListType() != nil {
+			result = true
+		}
+		return !result
+	}, e)
+	return result
+}
+
+// normalizeJunction returns the normalized AND or OR of the provided operands.
+func (n *normalizer) normalizeJunction(function string, args []*expr.Expr) (*expr.Expr, error) {
+	var operands []*expr.Expr
+	if err := n.collectOperands(function, args, &operands); err != nil {
+		return nil, err
+	}
+	// The identity operand is true for AND and false for OR, and the other bool value absorbs all operands.
+	identity := function == FunctionAnd
+	keys := make(map[*expr.Expr]string, len(operands))
+	seen := make(map[string]bool, len(operands))
+	result := operands[:0]
+	for _, operand := range operands {
+		if value, ok := n.boolValue(operand); ok {
+			if value != identity {
+				return n.newBool(value), nil
+			}
+			continue
+		}
+		key, err := Unparse(operand)
+		if err != nil {
+			return nil, err
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys[operand] = key
+		result = append(result, operand)
+	}
+	if len(result) == 0 {
+		return n.newBool(identity), nil
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return keys[result[i]] < keys[result[j]]
+	})
+	junction := result[0]
+	for _, operand := range result[1:] {
+		junction = n.newCall(function, junction, operand)
+	}
+	return junction, nil
+}
+
+// collectOperands normalizes the provided args and collects the operands of any nested junctions of the same function.
+func (n *normalizer) collectOperands(function string, args []*expr.Expr, operands *[]*expr.Expr) error {
+	for _, arg := range args {
+		if n.junctionFunction(arg) == function {
+			if err := n.collectOperands(function, arg.GetCallExpr().GetArgs(), operands); err != nil {
+				return err
+			}
+			continue
+		}
+		normalized, err := n.normalize(arg)
+		if err != nil {
+			return err
+		}
+		if normalized.GetCallExpr().GetFunction() == function {
+			// Negations can normalize into junctions of the same function.
+			*operands = append(*operands, flattenJunction(function, normalized)...)
+			continue
+		}
+		*operands = append(*operands, normalized)
+	}
+	return nil
+}
+
+// junctionFunction returns AND or OR if the provided expression is a junction, where FUZZY of bool operands is AND.
+func (n *normalizer) junctionFunction(e *expr.Expr) string {
+	callExpr := e.GetCallExpr()
+	if len(callExpr.GetArgs()) < 2 {
+		return ""
+	}
+	switch callExpr.GetFunction() {
+	case FunctionAnd, FunctionOr:
+		return callExpr.GetFunction()
+	case FunctionFuzzyAnd:
+		for _, arg := range callExpr.GetArgs() {
+			if !proto.Equal(n.typeMap[arg.GetId()], TypeBool) {
+				return ""
+			}
+		}
+		return FunctionAnd
+	}
+	return ""
+}
+
+// boolValue returns the value of the provided expression if it is a constant bool.
+func (n *normalizer) boolValue(e *expr.Expr) (bool, bool) {
+	constant := e.GetConstExpr()
+	if constant == nil {
+		constant = n.referenceMap[e.GetId()].GetValue()
+	}
+	value, ok := constant.GetConstantKind().(*expr.Constant_BoolValue)
+	if !ok {
+		return false, false
+	}
+	return value.BoolValue, true
+}
+
+func (n *normalizer) newCall(function string, args ...*expr.Expr) *expr.Expr {
+	result := Function(function, args...)
+	result.Id = n.newID()
+	return result
+}
+
+func (n *normalizer) newNot(arg *expr.Expr) *expr.Expr {
+	return n.newCall(FunctionNot, arg)
+}
+
+func (n *normalizer) newBool(value bool) *expr.Expr {
+	return &expr.Expr{
+		Id: n.newID(),
+		ExprKind: &expr.Expr_ConstExpr{
+			ConstExpr: &expr.Constant{
+				ConstantKind: &expr.Constant_BoolValue{BoolValue: value},
+			},
+		},
+	}
+}
+
+func (n *normalizer) newID() int64 {
+	id := n.nextID
+	n.nextID++
+	return id
+}
+
+// flattenJunction returns the operands of the provided normalized junction.
+func flattenJunction(function string, e *expr.Expr) []*expr.Expr {
+	if e.GetCallExpr().GetFunction() != function {
+		return []*expr.Expr{e}
+	}
+	var result []*expr.Expr
+	for _, arg := range e.GetCallExpr().GetArgs() {
+		result = append(result, flattenJunction(function, arg)...)
+	}
+	return result
+}
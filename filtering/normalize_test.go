@@ -0,0 +1,149 @@
+package filtering
+
+import (
+	"testing"
+
+	freightv1 "go.einride.tech/aip/proto/gen/einride/example/freight/v1"
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"gotest.tools/v3/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		filter       string
+		declarations []DeclarationOption
+		expected     string
+	}{
+		{
+			filter: `(a = 1 AND (b = 2)) AND a = 1`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("a", TypeInt),
+				DeclareIdent("b", TypeInt),
+			},
+			expected: `a = 1 AND b = 2`,
+		},
+		{
+			filter: `c OR (b OR a) OR c`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("a", TypeBool),
+				DeclareIdent("b", TypeBool),
+				DeclareIdent("c", TypeBool),
+			},
+			expected: `a OR b OR c`,
+		},
+		{
+			filter: `NOT (NOT x)`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("x", TypeBool),
+			},
+			expected: `x`,
+		},
+		{
+			filter: `NOT (a = 1 OR NOT (b != "foo" AND c))`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("a", TypeInt),
+				DeclareIdent("b", TypeString),
+				DeclareIdent("c", TypeBool),
+			},
+			expected: `a != 1 AND b != "foo" AND c`,
+		},
+		{
+			filter: `NOT (line_items.title = "ABC" OR name != "shipments/1")`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareMessageFields((&freightv1.Shipment{}).ProtoReflect().Descriptor()),
+			},
+			expected: `NOT line_items.title = "ABC" AND name = "shipments/1"`,
+		},
+		{
+			filter: `NOT (a < 1 OR b)`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("a", TypeInt),
+				DeclareIdent("b", TypeBool),
+			},
+			expected: `NOT a < 1 AND NOT b`,
+		},
+		{
+			filter: `b a AND c`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareFunction(FunctionFuzzyAnd, NewFunctionOverload("fuzzy_bool", TypeBool, TypeBool, TypeBool)),
+				DeclareIdent("a", TypeBool),
+				DeclareIdent("b", TypeBool),
+				DeclareIdent("c", TypeBool),
+			},
+			expected: `a AND b AND c`,
+		},
+		{
+			filter: `New York`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareFunction(FunctionFuzzyAnd, NewFunctionOverload("fuzzy_string", TypeBool, TypeString, TypeString)),
+				DeclareIdent("New", TypeString),
+				DeclareIdent("York", TypeString),
+			},
+			expected: `New York`,
+		},
+		{
+			filter: `(a OR yes) AND (b OR no) AND NOT no`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("a", TypeBool),
+				DeclareIdent("b", TypeBool),
+				declareBoolConstant("yes", true),
+				declareBoolConstant("no", false),
+			},
+			expected: `b`,
+		},
+		{
+			filter: `a AND NOT yes`,
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("a", TypeBool),
+				declareBoolConstant("yes", true),
+			},
+			expected: `false`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.filter, func(t *testing.T) {
+			t.Parallel()
+			declarations, err := NewDeclarations(tt.declarations...)
+			assert.NilError(t, err)
+			filter, err := ParseFilter(&mockRequest{filter: tt.filter}, declarations)
+			assert.NilError(t, err)
+			original, err := Unparse(filter.CheckedExpr.GetExpr())
+			assert.NilError(t, err)
+			normalized, err := Normalize(filter, declarations)
+			assert.NilError(t, err)
+			actual, err := Unparse(normalized.CheckedExpr.GetExpr())
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expected, actual)
+			ids := map[int64]bool{}
+			Walk(func(currExpr, _ *expr.Expr) bool {
+				assert.Assert(t, !ids[currExpr.GetId()], "duplicate id %d", currExpr.GetId())
+				ids[currExpr.GetId()] = true
+				_, ok := normalized.CheckedExpr.GetTypeMap()[currExpr.GetId()]
+				assert.Assert(t, ok, "missing type for id %d", currExpr.GetId())
+				return true
+			}, normalized.CheckedExpr.GetExpr())
+			unmodified, err := Unparse(filter.CheckedExpr.GetExpr())
+			assert.NilError(t, err)
+			assert.Equal(t, original, unmodified)
+		})
+	}
+}
+
+func declareBoolConstant(name string, value bool) DeclarationOption {
+	return func(declarations *Declarations) error {
+		return declarations.declareConstant(name, TypeBool, &expr.Constant{
+			ConstantKind: &expr.Constant_BoolValue{BoolValue: value},
+		})
+	}
+}
@@ -12,25 +12,40 @@ import (
 
 // Parser for filter expressions.
 type Parser struct {
-	filter    string
-	lexer     Lexer
-	id        int64
-	positions []int32
+	filter       string
+	lexer        Lexer
+	id           int64
+	positions    []int32
+	limits       Limits
+	depth        int
+	restrictions int
+	recovering   bool
+	errs         []error
 }
 
 // Init (re-)initializes the parser to parse the provided filter.
-func (p *Parser) Init(filter string) {
+func (p *Parser) Init(filter string, opts ...ParserOption) {
 	filter = strings.TrimSpace(filter)
 	*p = Parser{
 		filter:    filter,
 		positions: p.positions[:0],
 		id:        -1,
+		errs:      p.errs[:0],
+	}
+	for _, opt := range opts {
+		opt(p)
 	}
 	p.lexer.Init(filter)
 }
 
 // Parse the filter.
+//
+// When error recovery is enabled, Parse returns the partial expression together with the first error, if any.
+// Use Errors to get all errors.
 func (p *Parser) Parse() (*expr.ParsedExpr, error) {
+	if p.limits.MaxLength > 0 && len(p.filter) > p.limits.MaxLength {
+		return nil, p.limitErrorf(positionOf(p.filter, p.limits.MaxLength), "max length", p.limits.MaxLength)
+	}
 	e, err := p.ParseExpression()
 	if err != nil {
 		return nil, err
@@ -38,15 +53,25 @@ func (p *Parser) Parse() (*expr.ParsedExpr, error) {
 	end := p.lexer.Position()
 	if token, err := p.lexer.Lex(); err != nil {
 		if !errors.Is(err, io.EOF) {
-			return nil, err
+			if err := p.recordError(err); err != nil {
+				return nil, err
+			}
+			p.skipRemaining()
 		}
 	} else {
-		return nil, p.errorf(end, "unexpected trailing token %s", token.Type)
+		if err := p.recordError(p.errorf(end, "unexpected trailing token %s", token.Type)); err != nil {
+			return nil, err
+		}
+		p.skipRemaining()
 	}
-	return &expr.ParsedExpr{
+	result := &expr.ParsedExpr{
 		Expr:       e,
 		SourceInfo: p.SourceInfo(),
-	}, nil
+	}
+	if len(p.errs) > 0 {
+		return result, p.errs[0]
+	}
+	return result, nil
 }
 
 func (p *Parser) SourceInfo() *expr.SourceInfo {
@@ -83,7 +108,9 @@ func (p *Parser) ParseExpression() (_ *expr.Expr, err error) {
 		}
 		sequences = append(sequences, sequence)
 		if err := p.eatTokens(TokenTypeWhitespace, TokenTypeAnd, TokenTypeWhitespace); err != nil {
-			break
+			if !p.eatDanglingKeyword(TokenTypeAnd) {
+				break
+			}
 		}
 	}
 	exp := sequences[0]
@@ -153,7 +180,9 @@ func (p *Parser) ParseFactor() (_ *expr.Expr, err error) {
 		}
 		terms = append(terms, term)
 		if err := p.eatTokens(TokenTypeWhitespace, TokenTypeOr, TokenTypeWhitespace); err != nil {
-			break
+			if !p.eatDanglingKeyword(TokenTypeOr) {
+				break
+			}
 		}
 	}
 	if len(terms) == 1 {
@@ -246,9 +275,14 @@ func (p *Parser) ParseRestriction() (_ *expr.Expr, err error) {
 			err = p.wrapf(err, start, "restriction")
 		}
 	}()
+	p.restrictions++
+	if p.limits.MaxRestrictions > 0 && p.restrictions > p.limits.MaxRestrictions {
+		return nil, p.limitErrorf(start, "max restrictions", p.limits.MaxRestrictions)
+	}
+	comparableLexer := p.lexer
 	comp, err := p.ParseComparable()
 	if err != nil {
-		return nil, err
+		return p.recoverError(comparableLexer, err)
 	}
 	if !(p.sniff(TokenType.IsComparator) || p.sniff(TokenTypeWhitespace.Test, TokenType.IsComparator)) {
 		return comp, nil
@@ -258,14 +292,18 @@ func (p *Parser) ParseRestriction() (_ *expr.Expr, err error) {
 	if err != nil {
 		return nil, err
 	}
+	argLexer := p.lexer
 	_ = p.eatTokens(TokenTypeWhitespace)
 	arg, err := p.ParseArg()
 	if err != nil {
-		return nil, err
+		if arg, err = p.recoverError(argLexer, err); err != nil {
+			return nil, err
+		}
 	}
 	// Special case for `:`
-	if comparatorToken.Type == TokenTypeHas && arg.GetIdentExpr() != nil {
+	if comparatorToken.Type == TokenTypeHas && arg.GetIdentExpr() != nil && !isPresenceWildcard(arg) {
 		// m:foo - true if m contains the key "foo".
+		// m:* - true if m is present, where the wildcard remains an ident.
 		arg = parsedString(arg.GetId(), arg.GetIdentExpr().GetName())
 	}
 	return parsedFunction(p.nextID(start), comparatorToken.Type.Function(), comp, arg), nil
@@ -287,7 +325,9 @@ func (p *Parser) ParseComparable() (_ *expr.Expr, err error) {
 			err = p.wrapf(err, start, "comparable")
 		}
 	}()
-	if function, ok := p.TryParseFunction(); ok {
+	if function, ok, err := p.tryParseFunction(); err != nil {
+		return nil, err
+	} else if ok {
 		return function, nil
 	}
 	if number, ok := p.TryParseNumber(); ok {
@@ -325,6 +365,9 @@ func (p *Parser) ParseMember() (_ *expr.Expr, err error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := p.checkStringLength(valueToken); err != nil {
+		return nil, err
+	}
 	if !p.sniffTokens(TokenTypeDot) {
 		if valueToken.Type == TokenTypeString {
 			return parsedString(p.nextID(valueToken.Position), valueToken.Unquote()), nil
@@ -337,6 +380,9 @@ func (p *Parser) ParseMember() (_ *expr.Expr, err error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := p.checkStringLength(firstFieldToken); err != nil {
+		return nil, err
+	}
 	member := parsedMember(p.nextID(start), value, firstFieldToken.Unquote())
 	for {
 		if err := p.eatTokens(TokenTypeDot); err != nil {
@@ -346,6 +392,9 @@ func (p *Parser) ParseMember() (_ *expr.Expr, err error) {
 		if err != nil {
 			return nil, err
 		}
+		if err := p.checkStringLength(fieldToken); err != nil {
+			return nil, err
+		}
 		member = parsedMember(p.nextID(start), member, fieldToken.Unquote())
 	}
 	return member, nil
@@ -382,15 +431,27 @@ func (p *Parser) ParseFunction() (_ *expr.Expr, err error) {
 		}
 		_ = name.WriteByte('.')
 	}
+	leftParen := p.lexer.Position()
 	if err := p.eatTokens(TokenTypeLeftParen); err != nil {
 		return nil, err
 	}
+	if err := p.enter(leftParen); err != nil {
+		return nil, err
+	}
+	defer p.exit()
 	_ = p.eatTokens(TokenTypeWhitespace)
 	args := make([]*expr.Expr, 0)
 	for !p.sniffTokens(TokenTypeRightParen) {
+		argStart := p.lexer.Position()
+		if p.limits.MaxFunctionArgs > 0 && len(args) == p.limits.MaxFunctionArgs {
+			return nil, p.limitErrorf(argStart, "max function args", p.limits.MaxFunctionArgs)
+		}
+		argLexer := p.lexer
 		arg, err := p.ParseArg()
 		if err != nil {
-			return nil, err
+			if arg, err = p.recoverError(argLexer, err); err != nil {
+				return nil, err
+			}
 		}
 		args = append(args, arg)
 		_ = p.eatTokens(TokenTypeWhitespace)
@@ -401,19 +462,31 @@ func (p *Parser) ParseFunction() (_ *expr.Expr, err error) {
 	}
 	_ = p.eatTokens(TokenTypeWhitespace)
 	if err := p.eatTokens(TokenTypeRightParen); err != nil {
-		return nil, err
+		if err := p.recordError(err); err != nil {
+			return nil, err
+		}
 	}
 	return parsedFunction(p.nextID(start), name.String(), args...), nil
 }
 
 func (p *Parser) TryParseFunction() (*expr.Expr, bool) {
+	function, ok, _ := p.tryParseFunction()
+	return function, ok
+}
+
+// tryParseFunction parses a Function, backtracking on all errors except exceeded limits.
+func (p *Parser) tryParseFunction() (*expr.Expr, bool, error) {
 	start := *p
 	function, err := p.ParseFunction()
 	if err != nil {
+		var limitErr *LimitError
+		if errors.As(err, &limitErr) {
+			return nil, false, err
+		}
 		*p = start
-		return nil, false
+		return nil, false, nil
 	}
-	return function, true
+	return function, true, nil
 }
 
 // ParseComposite parses a Composite.
@@ -430,9 +503,14 @@ func (p *Parser) ParseComposite() (_ *expr.Expr, err error) {
 			err = p.wrapf(err, start, "composite")
 		}
 	}()
+	leftParen := p.lexer.Position()
 	if err := p.eatTokens(TokenTypeLeftParen); err != nil {
 		return nil, err
 	}
+	if err := p.enter(leftParen); err != nil {
+		return nil, err
+	}
+	defer p.exit()
 	_ = p.eatTokens(TokenTypeWhitespace)
 	expression, err := p.ParseExpression()
 	if err != nil {
@@ -440,7 +518,9 @@ func (p *Parser) ParseComposite() (_ *expr.Expr, err error) {
 	}
 	_ = p.eatTokens(TokenTypeWhitespace)
 	if err := p.eatTokens(TokenTypeRightParen); err != nil {
-		return nil, err
+		if err := p.recordError(err); err != nil {
+			return nil, err
+		}
 	}
 	return expression, nil
 }
@@ -656,6 +736,39 @@ func (p *Parser) eatTokens(wantTokenTypes ...TokenType) error {
 	return nil
 }
 
+// enter increments the nesting depth at the provided position.
+func (p *Parser) enter(position Position) error {
+	p.depth++
+	if p.limits.MaxDepth > 0 && p.depth > p.limits.MaxDepth {
+		return p.limitErrorf(position, "max depth", p.limits.MaxDepth)
+	}
+	return nil
+}
+
+// exit decrements the nesting depth.
+func (p *Parser) exit() {
+	p.depth--
+}
+
+func (p *Parser) checkStringLength(token Token) error {
+	if token.Type != TokenTypeString || p.limits.MaxStringLength <= 0 {
+		return nil
+	}
+	if len(token.Unquote()) > p.limits.MaxStringLength {
+		return p.limitErrorf(token.Position, "max string length", p.limits.MaxStringLength)
+	}
+	return nil
+}
+
+func (p *Parser) limitErrorf(position Position, limit string, max int) error {
+	return &LimitError{
+		filter:   p.filter,
+		position: position,
+		limit:    limit,
+		max:      max,
+	}
+}
+
 func (p *Parser) errorf(position Position, format string, args ...interface{}) error {
 	return &parseError{
 		filter:   p.filter,
@@ -1,6 +1,7 @@
 package filtering
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -276,3 +277,194 @@ func assertUniqueExprIDs(t *testing.T, exp *expr.Expr) {
 		return true
 	}, exp)
 }
+
+func TestParser_limits(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		name             string
+		filter           string
+		limits           Limits
+		expectedLimit    string
+		expectedPosition Position
+	}{
+		{
+			name:   "within limits",
+			filter: `a = "foo" AND f(b, c) AND (d OR (e))`,
+			limits: Limits{MaxLength: 100, MaxDepth: 2, MaxRestrictions: 5, MaxFunctionArgs: 2, MaxStringLength: 3},
+		},
+		{
+			name:             "max length",
+			filter:           "a = 1 AND\nb = 2",
+			limits:           Limits{MaxLength: 11},
+			expectedLimit:    "max length",
+			expectedPosition: Position{Offset: 11, Line: 2, Column: 2},
+		},
+		{
+			name:             "max depth",
+			filter:           "a AND (b OR (c AND (d)))",
+			limits:           Limits{MaxDepth: 2},
+			expectedLimit:    "max depth",
+			expectedPosition: Position{Offset: 19, Line: 1, Column: 20},
+		},
+		{
+			name:             "max depth function",
+			filter:           "f(g(h()))",
+			limits:           Limits{MaxDepth: 2},
+			expectedLimit:    "max depth",
+			expectedPosition: Position{Offset: 5, Line: 1, Column: 6},
+		},
+		{
+			name:             "max restrictions",
+			filter:           "a = 1 b c:d",
+			limits:           Limits{MaxRestrictions: 2},
+			expectedLimit:    "max restrictions",
+			expectedPosition: Position{Offset: 8, Line: 1, Column: 9},
+		},
+		{
+			name:             "max function args",
+			filter:           "a = f(1, 2, 3)",
+			limits:           Limits{MaxFunctionArgs: 2},
+			expectedLimit:    "max function args",
+			expectedPosition: Position{Offset: 12, Line: 1, Column: 13},
+		},
+		{
+			name:             "max string length",
+			filter:           `a = "foo" OR b = f("foobar")`,
+			limits:           Limits{MaxStringLength: 3},
+			expectedLimit:    "max string length",
+			expectedPosition: Position{Offset: 19, Line: 1, Column: 20},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var parser Parser
+			parser.Init(tt.filter, WithLimits(tt.limits))
+			_, err := parser.Parse()
+			if tt.expectedLimit == "" {
+				assert.NilError(t, err)
+				return
+			}
+			var limitErr *LimitError
+			assert.Assert(t, errors.As(err, &limitErr), "expected LimitError but got %v", err)
+			assert.Equal(t, tt.expectedLimit, limitErr.Limit())
+			assert.Equal(t, tt.expectedPosition, limitErr.Position())
+			assert.Equal(t, tt.filter, limitErr.Filter())
+		})
+	}
+}
+
+func TestParser_errorRecovery(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		filter         string
+		expected       *expr.Expr
+		expectedErrors []string
+	}{
+		{
+			filter:   `a = 1 AND b`,
+			expected: And(Equals(Text("a"), Int(1)), Text("b")),
+		},
+		{
+			filter:         `a =`,
+			expected:       Equals(Text("a"), &expr.Expr{}),
+			expectedErrors: []string{"1:4: parse token"},
+		},
+		{
+			filter:         `a = 1 AND`,
+			expected:       And(Equals(Text("a"), Int(1)), &expr.Expr{}),
+			expectedErrors: []string{"1:10: parse token"},
+		},
+		{
+			filter:         `a = 1 OR`,
+			expected:       Or(Equals(Text("a"), Int(1)), &expr.Expr{}),
+			expectedErrors: []string{"1:9: parse token"},
+		},
+		{
+			filter:         `a = AND b = 2`,
+			expected:       And(Equals(Text("a"), &expr.Expr{}), Equals(Text("b"), Int(2))),
+			expectedErrors: []string{"1:5: unexpected token AND"},
+		},
+		{
+			filter:         `a = = 1 AND b = 2`,
+			expected:       And(Equals(Text("a"), &expr.Expr{}), Equals(Text("b"), Int(2))),
+			expectedErrors: []string{"1:5: unexpected token ="},
+		},
+		{
+			filter:         `(a = 1 OR b = 2`,
+			expected:       Or(Equals(Text("a"), Int(1)), Equals(Text("b"), Int(2))),
+			expectedErrors: []string{"1:16: expected )"},
+		},
+		{
+			filter:         `(a =) AND b`,
+			expected:       And(Equals(Text("a"), &expr.Expr{}), Text("b")),
+			expectedErrors: []string{"1:5: unexpected token )"},
+		},
+		{
+			filter:         `f(a, =) AND b`,
+			expected:       And(Function("f", Text("a"), &expr.Expr{}), Text("b")),
+			expectedErrors: []string{"1:6: unexpected token ="},
+		},
+		{
+			filter:         `f(a`,
+			expected:       Function("f", Text("a")),
+			expectedErrors: []string{"1:4: expected )"},
+		},
+		{
+			filter:         `a = "foo`,
+			expected:       Equals(Text("a"), &expr.Expr{}),
+			expectedErrors: []string{"1:5: unterminated string"},
+		},
+		{
+			filter:         "a = foo\xa0 AND b",
+			expected:       Equals(Text("a"), Text("foo")),
+			expectedErrors: []string{"1:8: invalid UTF-8"},
+		},
+		{
+			filter:         `a = 1) b`,
+			expected:       Equals(Text("a"), Int(1)),
+			expectedErrors: []string{"1:6: unexpected trailing token )"},
+		},
+		{
+			filter:         `a = AND b =`,
+			expected:       And(Equals(Text("a"), &expr.Expr{}), Equals(Text("b"), &expr.Expr{})),
+			expectedErrors: []string{"1:5: unexpected token AND", "1:12: parse token"},
+		},
+	} {
+		tt := tt
+		t.Run(tt.filter, func(t *testing.T) {
+			t.Parallel()
+			var parser Parser
+			parser.Init(tt.filter, WithErrorRecovery())
+			actual, err := parser.Parse()
+			assert.Equal(t, len(tt.expectedErrors), len(parser.Errors()), "%v", parser.Errors())
+			for i, expectedError := range tt.expectedErrors {
+				innermost, ok := innermostError(parser.Errors()[i])
+				assert.Assert(t, ok)
+				assert.Equal(t, expectedError, innermost.Position().String()+": "+innermost.Message())
+			}
+			if len(tt.expectedErrors) > 0 {
+				assert.Equal(t, parser.Errors()[0], err)
+			} else {
+				assert.NilError(t, err)
+			}
+			assert.DeepEqual(
+				t,
+				tt.expected,
+				actual.GetExpr(),
+				protocmp.Transform(),
+				protocmp.IgnoreFields(&expr.Expr{}, "id"),
+			)
+			assertUniqueExprIDs(t, actual.GetExpr())
+		})
+	}
+}
+
+func TestParser_errorRecoveryLimits(t *testing.T) {
+	t.Parallel()
+	var parser Parser
+	parser.Init("a AND (b OR (c AND (d", WithErrorRecovery(), WithLimits(Limits{MaxDepth: 2}))
+	_, err := parser.Parse()
+	var limitErr *LimitError
+	assert.Assert(t, errors.As(err, &limitErr), "expected LimitError but got %v", err)
+}
@@ -0,0 +1,98 @@
+package filtering
+
+import (
+	"errors"
+	"io"
+
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+)
+
+// WithErrorRecovery is a ParserOption that makes the parser recover from syntax errors, to produce a partial
+// expression for incomplete or invalid filters, such as filters being typed by a user.
+//
+// Unparsable restrictions and args are represented by expressions without an expression kind, and the parser resumes
+// at the next AND or OR keyword, closing parenthesis, or comma. Missing closing parentheses are tolerated.
+// Exceeded limits are not recovered from.
+func WithErrorRecovery() ParserOption {
+	return func(parser *Parser) {
+		parser.recovering = true
+	}
+}
+
+// Errors returns all errors recovered from by the last call to Parse, in the order they were encountered.
+func (p *Parser) Errors() []error {
+	return p.errs
+}
+
+// recordError records the provided error when error recovery is enabled, and returns the error otherwise.
+func (p *Parser) recordError(err error) error {
+	var limitErr *LimitError
+	if !p.recovering || errors.As(err, &limitErr) {
+		return err
+	}
+	p.errs = append(p.errs, err)
+	return nil
+}
+
+// recoverError records the provided error when error recovery is enabled, and returns an expression without an
+// expression kind in place of the unparsable part of the filter, which starts at the provided lexer state.
+func (p *Parser) recoverError(lexer Lexer, err error) (*expr.Expr, error) {
+	if err := p.recordError(err); err != nil {
+		return nil, err
+	}
+	p.lexer = lexer
+	position := p.lexer.Position()
+	p.synchronize()
+	return &expr.Expr{Id: p.nextID(position)}, nil
+}
+
+// synchronize skips tokens until the next AND or OR keyword, closing parenthesis, comma, or the end of the filter.
+func (p *Parser) synchronize() {
+	for {
+		if p.sniffTokens(TokenTypeWhitespace, TokenTypeAnd) ||
+			p.sniffTokens(TokenTypeWhitespace, TokenTypeOr) ||
+			p.sniffTokens(TokenTypeRightParen) ||
+			p.sniffTokens(TokenTypeWhitespace, TokenTypeRightParen) ||
+			p.sniffTokens(TokenTypeComma) ||
+			p.sniffTokens(TokenTypeWhitespace, TokenTypeComma) {
+			return
+		}
+		if !p.skipToken() {
+			return
+		}
+	}
+}
+
+// skipRemaining skips all remaining tokens.
+func (p *Parser) skipRemaining() {
+	for p.skipToken() {
+	}
+}
+
+// skipToken skips the next token, or the next rune if it can not be lexed, and returns false at the end of the filter.
+func (p *Parser) skipToken() bool {
+	if _, err := p.lexer.Lex(); err != nil {
+		if errors.Is(err, io.EOF) {
+			return false
+		}
+		p.lexer.skipRune()
+	}
+	return true
+}
+
+// eatDanglingKeyword eats a keyword at the end of the filter when error recovery is enabled, so that the missing
+// operand of the keyword can be recovered.
+func (p *Parser) eatDanglingKeyword(keyword TokenType) bool {
+	if !p.recovering {
+		return false
+	}
+	start := *p
+	if err := p.eatTokens(TokenTypeWhitespace, keyword); err != nil {
+		return false
+	}
+	if _, err := p.lexer.Lex(); !errors.Is(err, io.EOF) {
+		*p = start
+		return false
+	}
+	return true
+}
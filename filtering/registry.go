@@ -0,0 +1,160 @@
+package filtering
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FunctionImplementation implements a function overload on evaluated argument values.
+//
+// Argument and result values are nil (null), bool, int64, uint64, float64, string, time.Time, time.Duration and
+// protoreflect.Message, where values of unsigned int fields are uint64. Repeated fields are passed as protoreflect.List
+// and map fields as protoreflect.Map.
+type FunctionImplementation func(args ...interface{}) (interface{}, error)
+
+// SQLFunction renders a function overload as SQL, given the SQL expressions of its arguments.
+type SQLFunction func(args ...string) (string, error)
+
+// FunctionRegistry pairs declared function overloads with their implementations.
+//
+// A FunctionRegistry is created by NewFunctionRegistry, which validates that every declared custom overload has an
+// implementation, including custom overloads of standard functions such as `=`. Standard overloads are implemented by
+// the library and can not be registered.
+type FunctionRegistry struct {
+	declarations    *Declarations
+	implementations map[string]FunctionImplementation
+	sql             map[string]SQLFunction
+}
+
+// FunctionRegistryOption configures a FunctionRegistry.
+type FunctionRegistryOption func(*FunctionRegistry) error
+
+// ImplementFunction is a FunctionRegistryOption that registers the Go implementation of a function overload.
+func ImplementFunction(overloadID string, implementation FunctionImplementation) FunctionRegistryOption {
+	return func(registry *FunctionRegistry) error {
+		if _, ok := registry.implementations[overloadID]; ok {
+			return fmt.Errorf("reimplementation of overload %s", overloadID)
+		}
+		registry.implementations[overloadID] = implementation
+		return nil
+	}
+}
+
+// ImplementSQLFunction is a FunctionRegistryOption that registers the SQL rendering of a function overload.
+//
+// SQL renderings are optional, use ValidateSQL to check that all custom overloads have one.
+func ImplementSQLFunction(overloadID string, sql SQLFunction) FunctionRegistryOption {
+	return func(registry *FunctionRegistry) error {
+		if _, ok := registry.sql[overloadID]; ok {
+			return fmt.Errorf("reimplementation of SQL for overload %s", overloadID)
+		}
+		registry.sql[overloadID] = sql
+		return nil
+	}
+}
+
+// NewFunctionRegistry creates a new FunctionRegistry for the custom functions of the provided declarations.
+//
+// An error is returned if any declared custom overload is unimplemented, or if an implementation is registered for an
+// overload that is not declared.
+func NewFunctionRegistry(declarations *Declarations, opts ...FunctionRegistryOption) (*FunctionRegistry, error) {
+	r := &FunctionRegistry{
+		declarations:    declarations,
+		implementations: make(map[string]FunctionImplementation),
+		sql:             make(map[string]SQLFunction),
+	}
+	for _, opt := range opts {
+		if err := opt(r); err != nil {
+			return nil, err
+		}
+	}
+	overloadIDs := r.customOverloadIDs()
+	var unimplemented []string
+	for overloadID := range overloadIDs {
+		if _, ok := r.implementations[overloadID]; !ok {
+			unimplemented = append(unimplemented, overloadID)
+		}
+	}
+	if len(unimplemented) > 0 {
+		sort.Strings(unimplemented)
+		return nil, fmt.Errorf("unimplemented overloads: %s", strings.Join(unimplemented, ", "))
+	}
+	for _, implemented := range []map[string]bool{keys(r.implementations), keys(r.sql)} {
+		for overloadID := range implemented {
+			if !overloadIDs[overloadID] {
+				return nil, fmt.Errorf("implementation of undeclared overload %s", overloadID)
+			}
+		}
+	}
+	return r, nil
+}
+
+// LookupFunction looks up the Go implementation of the overload with the provided ID.
+func (r *FunctionRegistry) LookupFunction(overloadID string) (FunctionImplementation, bool) {
+	if r == nil {
+		return nil, false
+	}
+	result, ok := r.implementations[overloadID]
+	return result, ok
+}
+
+// LookupSQLFunction looks up the SQL rendering of the overload with the provided ID.
+func (r *FunctionRegistry) LookupSQLFunction(overloadID string) (SQLFunction, bool) {
+	if r == nil {
+		return nil, false
+	}
+	result, ok := r.sql[overloadID]
+	return result, ok
+}
+
+// ValidateSQL returns an error if any declared custom overload has no SQL rendering.
+func (r *FunctionRegistry) ValidateSQL() error {
+	var unimplemented []string
+	for overloadID := range r.customOverloadIDs() {
+		if _, ok := r.sql[overloadID]; !ok {
+			unimplemented = append(unimplemented, overloadID)
+		}
+	}
+	if len(unimplemented) > 0 {
+		sort.Strings(unimplemented)
+		return fmt.Errorf("overloads without SQL: %s", strings.Join(unimplemented, ", "))
+	}
+	return nil
+}
+
+// customOverloadIDs returns the IDs of the overloads declared with DeclareFunction that are not implemented by the
+// library, including custom overloads of standard functions.
+//
+// Overloads declared implicitly, such as the equality overloads of enum types, are implemented by the library.
+func (r *FunctionRegistry) customOverloadIDs() map[string]bool {
+	standard := standardOverloadIDs()
+	result := make(map[string]bool)
+	for overloadID := range r.declarations.customOverloads {
+		if standard[overloadID] {
+			continue
+		}
+		result[overloadID] = true
+	}
+	return result
+}
+
+// standardOverloadIDs returns the IDs of all overloads implemented by the library, which are the overloads declared
+// by DeclareStandardFunctions and DeclareStringFunctions.
+func standardOverloadIDs() map[string]bool {
+	result := make(map[string]bool)
+	for _, decl := range append(StandardFunctionDeclarations(), StringFunctionDeclarations()...) {
+		for _, overload := range decl.GetFunction().GetOverloads() {
+			result[overload.GetOverloadId()] = true
+		}
+	}
+	return result
+}
+
+func keys[T any](m map[string]T) map[string]bool {
+	result := make(map[string]bool, len(m))
+	for key := range m {
+		result[key] = true
+	}
+	return result
+}
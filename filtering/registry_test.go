@@ -0,0 +1,235 @@
+package filtering
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	freightv1 "go.einride.tech/aip/proto/gen/einride/example/freight/v1"
+	syntaxv1 "go.einride.tech/aip/proto/gen/einride/example/syntax/v1"
+	"google.golang.org/genproto/googleapis/type/latlng"
+	"gotest.tools/v3/assert"
+)
+
+func TestNewFunctionRegistry(t *testing.T) {
+	t.Parallel()
+	declarations, err := NewDeclarations(
+		DeclareStandardFunctions(),
+		DeclareStringFunctions(),
+		DeclareFunction(
+			"distance",
+			NewFunctionOverload("distance_float_float_float_float", TypeFloat, TypeFloat, TypeFloat, TypeFloat, TypeFloat),
+		),
+		DeclareFunction("prefix", NewFunctionOverload("prefix_string_string", TypeBool, TypeString, TypeString)),
+		DeclareFunction(FunctionEquals, NewFunctionOverload("equals_string_int", TypeBool, TypeString, TypeInt)),
+	)
+	assert.NilError(t, err)
+	implementation := func(...interface{}) (interface{}, error) {
+		return nil, nil
+	}
+	for _, tt := range []struct {
+		name          string
+		opts          []FunctionRegistryOption
+		errorContains string
+	}{
+		{
+			name: "implemented",
+			opts: []FunctionRegistryOption{
+				ImplementFunction("distance_float_float_float_float", implementation),
+				ImplementFunction("prefix_string_string", implementation),
+				ImplementFunction("equals_string_int", implementation),
+			},
+		},
+		{
+			name: "unimplemented",
+			opts: []FunctionRegistryOption{
+				ImplementFunction("distance_float_float_float_float", implementation),
+			},
+			errorContains: "unimplemented overloads: equals_string_int, prefix_string_string",
+		},
+		{
+			name: "undeclared",
+			opts: []FunctionRegistryOption{
+				ImplementFunction("distance_float_float_float_float", implementation),
+				ImplementFunction("prefix_string_string", implementation),
+				ImplementFunction("equals_string_int", implementation),
+				ImplementFunction("suffix_string_string", implementation),
+			},
+			errorContains: "implementation of undeclared overload suffix_string_string",
+		},
+		{
+			name: "standard",
+			opts: []FunctionRegistryOption{
+				ImplementFunction("distance_float_float_float_float", implementation),
+				ImplementFunction("prefix_string_string", implementation),
+				ImplementFunction("equals_string_int", implementation),
+				ImplementFunction(FunctionOverloadEqualsString, implementation),
+			},
+			errorContains: "implementation of undeclared overload " + FunctionOverloadEqualsString,
+		},
+		{
+			name: "string function",
+			opts: []FunctionRegistryOption{
+				ImplementFunction("distance_float_float_float_float", implementation),
+				ImplementFunction("prefix_string_string", implementation),
+				ImplementFunction("equals_string_int", implementation),
+				ImplementFunction(FunctionOverloadMatchesString, implementation),
+			},
+			errorContains: "implementation of undeclared overload " + FunctionOverloadMatchesString,
+		},
+		{
+			name: "reimplemented",
+			opts: []FunctionRegistryOption{
+				ImplementFunction("distance_float_float_float_float", implementation),
+				ImplementFunction("distance_float_float_float_float", implementation),
+			},
+			errorContains: "reimplementation of overload distance_float_float_float_float",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			registry, err := NewFunctionRegistry(declarations, tt.opts...)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			assert.NilError(t, err)
+			assert.ErrorContains(
+				t,
+				registry.ValidateSQL(),
+				"overloads without SQL: distance_float_float_float_float, equals_string_int, prefix_string_string",
+			)
+		})
+	}
+}
+
+func TestNewFunctionRegistry_implicitOverloads(t *testing.T) {
+	t.Parallel()
+	declarations, err := NewDeclarations(
+		DeclareStandardFunctions(),
+		DeclareMessageFields((&syntaxv1.Message{}).ProtoReflect().Descriptor()),
+		DeclareStandardFunctionOverloads(
+			FunctionLessThan,
+			NewFunctionOverload("<_timestamp_duration", TypeBool, TypeTimestamp, TypeDuration),
+		),
+		DeclareFunction("prefix", NewFunctionOverload("prefix_string_string", TypeBool, TypeString, TypeString)),
+	)
+	assert.NilError(t, err)
+	implementation := func(...interface{}) (interface{}, error) {
+		return nil, nil
+	}
+	registry, err := NewFunctionRegistry(declarations, ImplementFunction("prefix_string_string", implementation))
+	assert.NilError(t, err)
+	assert.ErrorContains(t, registry.ValidateSQL(), "overloads without SQL: prefix_string_string")
+	_, err = NewFunctionRegistry(
+		declarations,
+		ImplementFunction("prefix_string_string", implementation),
+		ImplementFunction(FunctionEquals+"_einride.example.syntax.v1.Enum", implementation),
+	)
+	assert.ErrorContains(t, err, "implementation of undeclared overload =_einride.example.syntax.v1.Enum")
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+		declarations, err := NewDeclarations(
+			DeclareStandardFunctions(),
+			DeclareMessageFields((&syntaxv1.Message{}).ProtoReflect().Descriptor()),
+			DeclareFunction("prefix", NewFunctionOverload("prefix_string_string", TypeBool, TypeString, TypeString)),
+		)
+		assert.NilError(t, err)
+		data, err := declarations.MarshalJSON()
+		assert.NilError(t, err)
+		imported, err := NewDeclarations(DeclareJSON(data))
+		assert.NilError(t, err)
+		_, err = NewFunctionRegistry(imported)
+		assert.ErrorContains(t, err, "unimplemented overloads: prefix_string_string")
+		_, err = NewFunctionRegistry(imported, ImplementFunction("prefix_string_string", implementation))
+		assert.NilError(t, err)
+	})
+	t.Run("not standard", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewDeclarations(
+			DeclareStandardFunctionOverloads("prefix", NewFunctionOverload("prefix_int_int", TypeBool, TypeInt, TypeInt)),
+		)
+		assert.ErrorContains(t, err, "prefix is not a standard function")
+	})
+}
+
+func TestEvaluate_functions(t *testing.T) {
+	t.Parallel()
+	declarations, err := NewDeclarations(
+		DeclareStandardFunctions(),
+		DeclareMessageIdent("lat_lng", (&latlng.LatLng{}).ProtoReflect().Descriptor()),
+		DeclareIdent("display_name", TypeString),
+		DeclareFunction(
+			"distance",
+			NewFunctionOverload("distance_float_float_float_float", TypeFloat, TypeFloat, TypeFloat, TypeFloat, TypeFloat),
+		),
+		DeclareFunction("prefix", NewFunctionOverload("prefix_string_string", TypeBool, TypeString, TypeString)),
+		DeclareFunction(FunctionEquals, NewFunctionOverload("equals_string_int", TypeBool, TypeString, TypeInt)),
+	)
+	assert.NilError(t, err)
+	registry, err := NewFunctionRegistry(
+		declarations,
+		ImplementFunction("distance_float_float_float_float", func(args ...interface{}) (interface{}, error) {
+			lat1, lng1, lat2, lng2 := args[0].(float64), args[1].(float64), args[2].(float64), args[3].(float64)
+			return math.Hypot(lat2-lat1, lng2-lng1), nil
+		}),
+		ImplementFunction("prefix_string_string", func(args ...interface{}) (interface{}, error) {
+			return nil, errors.New("boom")
+		}),
+		ImplementFunction("equals_string_int", func(args ...interface{}) (interface{}, error) {
+			return int64(len(args[0].(string))) == args[1].(int64), nil
+		}),
+	)
+	assert.NilError(t, err)
+	site := &freightv1.Site{
+		DisplayName: "Depot",
+		LatLng:      &latlng.LatLng{Latitude: 57.7, Longitude: 11.9},
+	}
+	for _, tt := range []struct {
+		filter        string
+		expected      bool
+		errorContains string
+	}{
+		{
+			filter:   `distance(lat_lng.latitude, lat_lng.longitude, 57.7, 12.9) < 1.5`,
+			expected: true,
+		},
+		{
+			filter:   `distance(lat_lng.latitude, lat_lng.longitude, 57.7, 13.9) < 1.5`,
+			expected: false,
+		},
+		{
+			filter:        `prefix(display_name, "Dep")`,
+			errorContains: "prefix: boom",
+		},
+		{
+			filter:   `display_name = 5 AND display_name = "Depot"`,
+			expected: true,
+		},
+		{
+			filter:   `display_name = 4`,
+			expected: false,
+		},
+	} {
+		tt := tt
+		t.Run(tt.filter, func(t *testing.T) {
+			t.Parallel()
+			filter, err := ParseFilter(&mockRequest{filter: tt.filter}, declarations)
+			assert.NilError(t, err)
+			actual, err := Evaluate(filter, site, WithFunctions(registry))
+			predicate, compileErr := Compile(filter, site.ProtoReflect().Descriptor(), WithFunctions(registry))
+			assert.NilError(t, compileErr)
+			compiledActual, compileErr := predicate(site)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				assert.ErrorContains(t, compileErr, tt.errorContains)
+				return
+			}
+			assert.NilError(t, err)
+			assert.NilError(t, compileErr)
+			assert.Equal(t, tt.expected, actual)
+			assert.Equal(t, tt.expected, compiledActual)
+		})
+	}
+}
@@ -1,28 +1,48 @@
 package filtering
 
+import "go.einride.tech/aip/validation"
+
 // Request is an interface for gRPC requests that contain a standard AIP filter.
 type Request interface {
 	GetFilter() string
 }
 
 // ParseFilter parses and type-checks the filter in the provided Request.
-func ParseFilter(request Request, declarations *Declarations) (Filter, error) {
+//
+// ParserOptions, such as WithLimits, are applied to the parser before parsing.
+//
+// Parse and type errors are returned as a validation.Error with a field violation on the filter field, which
+// converts to a gRPC status with code INVALID_ARGUMENT. The underlying Error can be retrieved with errors.As.
+func ParseFilter(request Request, declarations *Declarations, opts ...ParserOption) (Filter, error) {
 	if request.GetFilter() == "" {
 		return Filter{}, nil
 	}
 	var parser Parser
-	parser.Init(request.GetFilter())
+	parser.Init(request.GetFilter(), opts...)
 	parsedExpr, err := parser.Parse()
 	if err != nil {
-		return Filter{}, err
+		return Filter{}, newFieldError(err)
 	}
 	var checker Checker
-	checker.Init(parsedExpr.GetExpr(), parsedExpr.GetSourceInfo(), declarations)
+	checker.Init(parsedExpr.GetExpr(), parsedExpr.GetSourceInfo(), declarations, WithFilter(parser.filter))
 	checkedExpr, err := checker.Check()
 	if err != nil {
-		return Filter{}, err
+		return Filter{}, newFieldError(err)
 	}
 	return Filter{
 		CheckedExpr: checkedExpr,
+		Warnings:    checker.Warnings(),
 	}, nil
 }
+
+// newFieldError returns a validation error on the filter field, described by the innermost Error in the provided error.
+func newFieldError(err error) error {
+	description := err.Error()
+	if innermost, ok := innermostError(err); ok {
+		description = innermost.Message()
+		if position := innermost.Position(); position.Line != 0 {
+			description = position.String() + ": " + description
+		}
+	}
+	return validation.NewFieldError("filter", description, err)
+}
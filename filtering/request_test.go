@@ -0,0 +1,146 @@
+package filtering
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func TestParseFilter_errors(t *testing.T) {
+	t.Parallel()
+	declarations, err := NewDeclarations(DeclareStandardFunctions(), DeclareIdent("a", TypeInt))
+	assert.NilError(t, err)
+	for _, tt := range []struct {
+		filter              string
+		expectedCode        ErrorCode
+		expectedDescription string
+	}{
+		{
+			filter:              `a = "foo`,
+			expectedCode:        ErrorCodeSyntax,
+			expectedDescription: "1:5: unterminated string",
+		},
+		{
+			filter:              `a = 1 AND b = 2`,
+			expectedCode:        ErrorCodeType,
+			expectedDescription: "1:11: undeclared identifier 'b'",
+		},
+	} {
+		tt := tt
+		t.Run(tt.filter, func(t *testing.T) {
+			t.Parallel()
+			_, err := ParseFilter(&mockRequest{filter: tt.filter}, declarations)
+			var filterErr Error
+			assert.Assert(t, errors.As(err, &filterErr))
+			assert.Equal(t, tt.expectedCode, filterErr.Code())
+			s := status.Convert(err)
+			assert.Equal(t, codes.InvalidArgument, s.Code())
+			assert.Equal(t, len(s.Details()), 1)
+			assert.DeepEqual(
+				t,
+				&errdetails.BadRequest{
+					FieldViolations: []*errdetails.BadRequest_FieldViolation{
+						{Field: "filter", Description: tt.expectedDescription},
+					},
+				},
+				s.Details()[0],
+				protocmp.Transform(),
+			)
+		})
+	}
+}
+
+func TestParseFilter_aliases(t *testing.T) {
+	t.Parallel()
+	declarations, err := NewDeclarations(
+		DeclareStandardFunctions(),
+		DeclareIdent("origin_site", TypeString),
+		DeclareIdent("create_time", TypeTimestamp),
+		DeclareAlias("origin", "origin_site"),
+		DeprecateIdent("origin", "use origin_site"),
+		DeprecateIdent("create_time", ""),
+	)
+	assert.NilError(t, err)
+	filter, err := ParseFilter(
+		&mockRequest{filter: `origin = "sites/1" AND create_time > "2022-08-12T22:22:22Z"`},
+		declarations,
+	)
+	assert.NilError(t, err)
+	unparsed, err := Unparse(filter.CheckedExpr.GetExpr())
+	assert.NilError(t, err)
+	assert.Equal(t, `origin_site = "sites/1" AND create_time > "2022-08-12T22:22:22Z"`, unparsed)
+	originExpr := filter.CheckedExpr.GetExpr().GetCallExpr().GetArgs()[0].GetCallExpr().GetArgs()[0]
+	assert.Equal(
+		t,
+		"origin",
+		filter.CheckedExpr.GetSourceInfo().GetMacroCalls()[originExpr.GetId()].GetIdentExpr().GetName(),
+	)
+	assert.DeepEqual(
+		t,
+		[]Warning{
+			{
+				Position: Position{Offset: 0, Line: 1, Column: 1},
+				Message:  "'origin' is deprecated: use origin_site",
+			},
+			{
+				Position: Position{Offset: 23, Line: 1, Column: 24},
+				Message:  "'create_time' is deprecated",
+			},
+		},
+		filter.Warnings,
+	)
+}
+
+func TestNewDeclarations_aliases(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		name          string
+		declarations  []DeclarationOption
+		errorContains string
+	}{
+		{
+			name: "undeclared target",
+			declarations: []DeclarationOption{
+				DeclareAlias("origin", "origin_site"),
+			},
+			errorContains: "alias origin: undeclared ident origin_site",
+		},
+		{
+			name: "alias of declared ident",
+			declarations: []DeclarationOption{
+				DeclareIdent("origin", TypeString),
+				DeclareIdent("origin_site", TypeString),
+				DeclareAlias("origin", "origin_site"),
+			},
+			errorContains: "redeclaration of origin",
+		},
+		{
+			name: "ident of declared alias",
+			declarations: []DeclarationOption{
+				DeclareIdent("origin_site", TypeString),
+				DeclareAlias("origin", "origin_site"),
+				DeclareIdent("origin", TypeString),
+			},
+			errorContains: "redeclaration of alias origin",
+		},
+		{
+			name: "deprecation of undeclared ident",
+			declarations: []DeclarationOption{
+				DeprecateIdent("origin", ""),
+			},
+			errorContains: "deprecation of undeclared ident origin",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := NewDeclarations(tt.declarations...)
+			assert.ErrorContains(t, err, tt.errorContains)
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package filtering
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// declarationsJSON is the JSON document of Declarations.
+//
+// Idents, functions and types are encoded with the protobuf JSON mapping of the google.api.expr.v1alpha1 types.
+type declarationsJSON struct {
+	Idents       []json.RawMessage `json:"idents"`
+	Functions    []json.RawMessage `json:"functions"`
+	Enums        []enumJSON        `json:"enums"`
+	Messages     []messageJSON     `json:"messages"`
+	Aliases      map[string]string `json:"aliases,omitempty"`
+	Deprecations map[string]string `json:"deprecations,omitempty"`
+}
+
+type enumJSON struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+type messageJSON struct {
+	Name   string      `json:"name"`
+	Fields []fieldJSON `json:"fields"`
+}
+
+type fieldJSON struct {
+	Name string          `json:"name"`
+	Type json.RawMessage `json:"type"`
+}
+
+// MarshalJSON encodes the declarations as a JSON document, that can be served to clients such as filter builders.
+//
+// The document has the following fields:
+//   - idents: the declared idents and constants as google.api.expr.v1alpha1.Decl, ordered by name
+//   - functions: the declared functions and their overloads as google.api.expr.v1alpha1.Decl, ordered by name
+//   - enums: the declared enum types with their full name and value names, ordered by full name
+//   - messages: the declared message types with their full name and filterable fields, ordered by full name
+//   - aliases: the declared aliases, as a map from alias to target
+//   - deprecations: the deprecated idents, as a map from name to reason
+//
+// Decls and types use the protobuf JSON mapping. Documentation of idents and overloads is included in their doc field.
+// Use DeclareJSON to declare the contents of the document.
+func (d *Declarations) MarshalJSON() ([]byte, error) {
+	document := declarationsJSON{
+		Idents:       []json.RawMessage{},
+		Functions:    []json.RawMessage{},
+		Enums:        []enumJSON{},
+		Messages:     []messageJSON{},
+		Aliases:      d.Aliases(),
+		Deprecations: d.Deprecations(),
+	}
+	for _, decl := range d.Idents() {
+		data, err := protojson.Marshal(decl)
+		if err != nil {
+			return nil, fmt.Errorf("marshal declarations: ident %s: %w", decl.GetName(), err)
+		}
+		document.Idents = append(document.Idents, data)
+	}
+	for _, decl := range d.Functions() {
+		data, err := protojson.Marshal(decl)
+		if err != nil {
+			return nil, fmt.Errorf("marshal declarations: function %s: %w", decl.GetName(), err)
+		}
+		document.Functions = append(document.Functions, data)
+	}
+	for _, enumType := range d.EnumTypes() {
+		values := enumType.Descriptor().Values()
+		enum := enumJSON{
+			Name:   string(enumType.Descriptor().FullName()),
+			Values: make([]string, 0, values.Len()),
+		}
+		for i := 0; i < values.Len(); i++ {
+			enum.Values = append(enum.Values, string(values.Get(i).Name()))
+		}
+		document.Enums = append(document.Enums, enum)
+	}
+	for _, message := range d.Messages() {
+		messageDocument := messageJSON{Name: string(message.FullName()), Fields: []fieldJSON{}}
+		fields := message.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			t, ok := fieldType(fields.Get(i))
+			if !ok {
+				continue
+			}
+			data, err := protojson.Marshal(t)
+			if err != nil {
+				return nil, fmt.Errorf("marshal declarations: field %s: %w", fields.Get(i).FullName(), err)
+			}
+			messageDocument.Fields = append(messageDocument.Fields, fieldJSON{
+				Name: string(fields.Get(i).Name()),
+				Type: data,
+			})
+		}
+		document.Messages = append(document.Messages, messageDocument)
+	}
+	return json.Marshal(document)
+}
+
+// DeclareJSON is a DeclarationOption that declares the contents of a JSON document encoded by
+// Declarations.MarshalJSON.
+//
+// Enum and message types are resolved by their full name from the global protobuf registries, and it is an error if
+// a type can not be resolved or if the values of a resolved enum type differ from the document.
+func DeclareJSON(data []byte) DeclarationOption {
+	return func(declarations *Declarations) error {
+		if err := declarations.declareJSON(data); err != nil {
+			return fmt.Errorf("declare JSON: %w", err)
+		}
+		return nil
+	}
+}
+
+func (d *Declarations) declareJSON(data []byte) error {
+	var document declarationsJSON
+	if err := json.Unmarshal(data, &document); err != nil {
+		return err
+	}
+	// Functions are declared first, to preserve the order of overloads implicitly declared by enum types.
+	var overloadIDs []string
+	for _, functionData := range document.Functions {
+		var decl expr.Decl
+		if err := protojson.Unmarshal(functionData, &decl); err != nil {
+			return err
+		}
+		if err := d.declare(&decl); err != nil {
+			return err
+		}
+		for _, overload := range decl.GetFunction().GetOverloads() {
+			overloadIDs = append(overloadIDs, overload.GetOverloadId())
+		}
+	}
+	enumTypes := make(map[string]protoreflect.EnumType, len(document.Enums))
+	for _, enum := range document.Enums {
+		enumType, err := resolveEnumJSON(enum)
+		if err != nil {
+			return err
+		}
+		if err := d.declareEnumType(enumType); err != nil {
+			return err
+		}
+		enumTypes[enum.Name] = enumType
+	}
+	messages := make(map[string]protoreflect.MessageDescriptor, len(document.Messages))
+	for _, messageDocument := range document.Messages {
+		descriptor, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(messageDocument.Name))
+		if err != nil {
+			return fmt.Errorf("message %s: %w", messageDocument.Name, err)
+		}
+		message, ok := descriptor.(protoreflect.MessageDescriptor)
+		if !ok {
+			return fmt.Errorf("message %s: not a message", messageDocument.Name)
+		}
+		if err := d.declareMessageType(message); err != nil {
+			return err
+		}
+		messages[messageDocument.Name] = message
+	}
+	for _, identData := range document.Idents {
+		var decl expr.Decl
+		if err := protojson.Unmarshal(identData, &decl); err != nil {
+			return err
+		}
+		if err := d.declareIdentJSON(&decl, enumTypes, messages); err != nil {
+			return err
+		}
+	}
+	for _, alias := range sortedKeys(document.Aliases) {
+		if err := d.declareAlias(alias, document.Aliases[alias]); err != nil {
+			return err
+		}
+	}
+	for _, name := range sortedKeys(document.Deprecations) {
+		if err := d.deprecateIdent(name, document.Deprecations[name]); err != nil {
+			return err
+		}
+	}
+	// The document does not record how overloads were declared, so all overloads except the equality overloads of
+	// enum types are declared as custom overloads, unless they are standard overloads.
+	for _, overloadID := range overloadIDs {
+		if !d.isEnumOverload(overloadID) {
+			d.customOverloads[overloadID] = true
+		}
+	}
+	return nil
+}
+
+// declareIdentJSON declares an ident from a JSON document, where idents of enum and message types in the document
+// are declared as enum and message idents.
+func (d *Declarations) declareIdentJSON(
+	decl *expr.Decl,
+	enumTypes map[string]protoreflect.EnumType,
+	messages map[string]protoreflect.MessageDescriptor,
+) error {
+	ident := decl.GetIdent()
+	if ident == nil {
+		return fmt.Errorf("ident %s: not an ident declaration", decl.GetName())
+	}
+	var err error
+	enumType, isEnum := enumTypes[ident.GetType().GetMessageType()]
+	message, isMessage := messages[ident.GetType().GetMessageType()]
+	switch {
+	case ident.GetValue() == nil && isEnum:
+		err = d.declareEnumIdent(decl.GetName(), enumType)
+	case ident.GetValue() == nil && isMessage:
+		err = d.declareMessageIdent(decl.GetName(), message)
+	default:
+		err = d.declare(decl)
+	}
+	if err != nil {
+		return err
+	}
+	if ident.GetDoc() != "" {
+		d.idents[decl.GetName()].GetIdent().Doc = ident.GetDoc()
+	}
+	return nil
+}
+
+// resolveEnumJSON resolves the enum type of an enum in a JSON document.
+func resolveEnumJSON(enum enumJSON) (protoreflect.EnumType, error) {
+	enumType, err := protoregistry.GlobalTypes.FindEnumByName(protoreflect.FullName(enum.Name))
+	if err != nil {
+		return nil, fmt.Errorf("enum %s: %w", enum.Name, err)
+	}
+	values := enumType.Descriptor().Values()
+	if values.Len() != len(enum.Values) {
+		return nil, fmt.Errorf("enum %s: mismatched values", enum.Name)
+	}
+	for _, value := range enum.Values {
+		if values.ByName(protoreflect.Name(value)) == nil {
+			return nil, fmt.Errorf("enum %s: unknown value %s", enum.Name, value)
+		}
+	}
+	return enumType, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	result := make([]string, 0, len(m))
+	for key := range m {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,178 @@
+package filtering
+
+import (
+	"encoding/json"
+	"testing"
+
+	freightv1 "go.einride.tech/aip/proto/gen/einride/example/freight/v1"
+	syntaxv1 "go.einride.tech/aip/proto/gen/einride/example/syntax/v1"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func TestDeclarations_MarshalJSON(t *testing.T) {
+	t.Parallel()
+	declarations, err := NewDeclarations(
+		DeclareStandardFunctions(),
+		DeclareIdent("create_time", TypeTimestamp),
+		DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+		DeclareMessageIdent("site", (&freightv1.Site{}).ProtoReflect().Descriptor()),
+		DeclareAlias("created", "create_time"),
+		DescribeIdent("create_time", "The creation time of the resource."),
+		DeprecateIdent("created", "use create_time"),
+	)
+	assert.NilError(t, err)
+	data, err := json.Marshal(declarations)
+	assert.NilError(t, err)
+	var document struct {
+		Idents []struct {
+			Name  string `json:"name"`
+			Ident struct {
+				Doc string `json:"doc"`
+			} `json:"ident"`
+		} `json:"idents"`
+		Enums []struct {
+			Name   string   `json:"name"`
+			Values []string `json:"values"`
+		} `json:"enums"`
+		Messages []struct {
+			Name   string `json:"name"`
+			Fields []struct {
+				Name string `json:"name"`
+			} `json:"fields"`
+		} `json:"messages"`
+		Aliases      map[string]string `json:"aliases"`
+		Deprecations map[string]string `json:"deprecations"`
+	}
+	assert.NilError(t, json.Unmarshal(data, &document))
+	identNames := make([]string, 0, len(document.Idents))
+	for _, ident := range document.Idents {
+		identNames = append(identNames, ident.Name)
+		if ident.Name == "create_time" {
+			assert.Equal(t, "The creation time of the resource.", ident.Ident.Doc)
+		}
+	}
+	assert.DeepEqual(t, []string{"ENUM_ONE", "ENUM_TWO", "ENUM_UNSPECIFIED", "create_time", "enum", "site"}, identNames)
+	assert.Equal(t, 1, len(document.Enums))
+	assert.Equal(t, "einride.example.syntax.v1.Enum", document.Enums[0].Name)
+	assert.DeepEqual(t, []string{"ENUM_UNSPECIFIED", "ENUM_ONE", "ENUM_TWO"}, document.Enums[0].Values)
+	messageNames := make([]string, 0, len(document.Messages))
+	for _, message := range document.Messages {
+		messageNames = append(messageNames, message.Name)
+		assert.Assert(t, len(message.Fields) > 0)
+	}
+	assert.DeepEqual(t, []string{"einride.example.freight.v1.Site", "google.type.LatLng"}, messageNames)
+	assert.DeepEqual(t, map[string]string{"created": "create_time"}, document.Aliases)
+	assert.DeepEqual(t, map[string]string{"created": "use create_time"}, document.Deprecations)
+	// The document is stable.
+	data2, err := json.Marshal(declarations)
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), string(data2))
+}
+
+func TestDeclareJSON(t *testing.T) {
+	t.Parallel()
+	declarations, err := NewDeclarations(
+		DeclareStandardFunctions(),
+		DeclareIdent("create_time", TypeTimestamp),
+		DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+		DeclareMessageIdent("site", (&freightv1.Site{}).ProtoReflect().Descriptor()),
+		DeclareFunction(
+			"distance",
+			NewFunctionOverload("distance_site_site", TypeFloat, TypeMessage(
+				(&freightv1.Site{}).ProtoReflect().Descriptor(),
+			), TypeMessage((&freightv1.Site{}).ProtoReflect().Descriptor())),
+		),
+		DeclareAlias("created", "create_time"),
+		DescribeIdent("create_time", "The creation time of the resource."),
+		DeprecateIdent("created", "use create_time"),
+	)
+	assert.NilError(t, err)
+	data, err := json.Marshal(declarations)
+	assert.NilError(t, err)
+	imported, err := NewDeclarations(DeclareJSON(data))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, declarations.Idents(), imported.Idents(), protocmp.Transform())
+	assert.DeepEqual(t, declarations.Functions(), imported.Functions(), protocmp.Transform())
+	assert.Equal(t, len(declarations.EnumTypes()), len(imported.EnumTypes()))
+	for i, enumType := range declarations.EnumTypes() {
+		assert.Equal(t, enumType, imported.EnumTypes()[i])
+	}
+	assert.Equal(t, len(declarations.Messages()), len(imported.Messages()))
+	for i, message := range declarations.Messages() {
+		assert.Equal(t, message, imported.Messages()[i])
+	}
+	assert.DeepEqual(t, declarations.Aliases(), imported.Aliases())
+	assert.DeepEqual(t, declarations.Deprecations(), imported.Deprecations())
+	enumType, ok := imported.LookupEnumIdent("enum")
+	assert.Assert(t, ok)
+	assert.Equal(t, protoreflect.FullName("einride.example.syntax.v1.Enum"), enumType.Descriptor().FullName())
+	importedData, err := json.Marshal(imported)
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), string(importedData))
+	filter, err := ParseFilter(&mockRequest{filter: `created > "2022-08-12T22:22:22Z" AND enum = ENUM_ONE`}, imported)
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(filter.Warnings))
+}
+
+func TestDeclareJSON_errors(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		name          string
+		data          string
+		errorContains string
+	}{
+		{
+			name:          "invalid JSON",
+			data:          `{`,
+			errorContains: "declare JSON",
+		},
+		{
+			name:          "unresolvable enum",
+			data:          `{"enums": [{"name": "foo.Bar", "values": ["BAR_UNSPECIFIED"]}]}`,
+			errorContains: "enum foo.Bar",
+		},
+		{
+			name:          "mismatched enum values",
+			data:          `{"enums": [{"name": "einride.example.syntax.v1.Enum", "values": ["ENUM_UNSPECIFIED"]}]}`,
+			errorContains: "enum einride.example.syntax.v1.Enum: mismatched values",
+		},
+		{
+			name:          "unresolvable message",
+			data:          `{"messages": [{"name": "foo.Bar", "fields": []}]}`,
+			errorContains: "message foo.Bar",
+		},
+		{
+			name:          "undeclared alias target",
+			data:          `{"aliases": {"created": "create_time"}}`,
+			errorContains: "alias created: undeclared ident create_time",
+		},
+		{
+			name:          "deprecation of undeclared ident",
+			data:          `{"deprecations": {"created": ""}}`,
+			errorContains: "deprecation of undeclared ident created",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := NewDeclarations(DeclareJSON([]byte(tt.data)))
+			assert.ErrorContains(t, err, tt.errorContains)
+		})
+	}
+}
+
+func TestDescribeIdent(t *testing.T) {
+	t.Parallel()
+	_, err := NewDeclarations(DescribeIdent("create_time", "The creation time of the resource."))
+	assert.ErrorContains(t, err, "description of undeclared ident create_time")
+	declarations, err := NewDeclarations(
+		DeclareIdent("create_time", TypeTimestamp),
+		DescribeIdent("create_time", "The creation time of the resource."),
+	)
+	assert.NilError(t, err)
+	decl, ok := declarations.LookupIdent("create_time")
+	assert.Assert(t, ok)
+	assert.Equal(t, "The creation time of the resource.", decl.GetIdent().GetDoc())
+}
@@ -0,0 +1,241 @@
+package sqlfilter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect provides the SQL syntax of a specific database.
+type Dialect interface {
+	// Placeholder returns the placeholder for the positional parameter with the provided 1-based index.
+	Placeholder(index int) string
+	// QuoteIdentifier returns the provided identifier quoted for use as a column name.
+	QuoteIdentifier(name string) string
+	// Like returns a LIKE expression matching the operand against the pattern.
+	// Patterns use backslash as escape character.
+	Like(operand, pattern string) string
+	// RegexpMatch returns an expression that is true if the operand contains a match of the regular expression.
+	RegexpMatch(operand, pattern string) string
+	// MapValue returns an expression selecting the string value of the provided key from a map column.
+	MapValue(column, key string, params Params) (string, error)
+	// MapHasKey returns an expression that is true if a map column contains the provided key.
+	MapHasKey(column, key string, params Params) (string, error)
+	// ListContains returns an expression that is true if a list column contains the provided element.
+	ListContains(column, element string) (string, error)
+	// MapNotEmpty returns an expression that is true if a map column is non-null and has at least one key.
+	MapNotEmpty(column string) (string, error)
+	// ListNotEmpty returns an expression that is true if a list column is non-null and has at least one element.
+	ListNotEmpty(column string) (string, error)
+	// Duration converts a duration to a parameter value.
+	Duration(d time.Duration) interface{}
+}
+
+// Params allocates positional parameters.
+type Params interface {
+	// Add a parameter with the provided value and return its placeholder.
+	Add(value interface{}) string
+}
+
+// Standard dialects.
+//
+//nolint:gochecknoglobals
+var (
+	// PostgreSQL is the dialect of PostgreSQL.
+	//
+	// Map fields are expected to be stored as jsonb, list fields as arrays and durations as intervals.
+	PostgreSQL Dialect = postgreSQL{}
+	// Spanner is the GoogleSQL dialect of Cloud Spanner.
+	//
+	// Map fields are expected to be stored as JSON, list fields as arrays and durations as INT64 nanoseconds.
+	Spanner Dialect = spanner{}
+	// SQLite is the dialect of SQLite.
+	//
+	// Map and list fields are expected to be stored as JSON and durations as INTEGER nanoseconds.
+	// Note that LIKE is case-insensitive for ASCII characters in SQLite, unless PRAGMA case_sensitive_like is set, and
+	// that REGEXP requires a user-defined regexp function.
+	SQLite Dialect = sqlite{}
+)
+
+type postgreSQL struct{}
+
+func (postgreSQL) Placeholder(index int) string {
+	return "$" + strconv.Itoa(index)
+}
+
+func (postgreSQL) QuoteIdentifier(name string) string {
+	return quoteIdentifier(name, '"')
+}
+
+func (postgreSQL) Like(operand, pattern string) string {
+	return operand + " LIKE " + pattern + ` ESCAPE '\'`
+}
+
+func (postgreSQL) RegexpMatch(operand, pattern string) string {
+	return operand + " ~ " + pattern
+}
+
+func (postgreSQL) MapValue(column, key string, params Params) (string, error) {
+	return "(" + column + " ->> " + params.Add(key) + ")", nil
+}
+
+func (postgreSQL) MapHasKey(column, key string, params Params) (string, error) {
+	return column + " ? " + params.Add(key), nil
+}
+
+func (postgreSQL) ListContains(column, element string) (string, error) {
+	return element + " = ANY(" + column + ")", nil
+}
+
+func (postgreSQL) MapNotEmpty(column string) (string, error) {
+	return "COALESCE(" + column + ", '{}'::jsonb) <> '{}'::jsonb", nil
+}
+
+func (postgreSQL) ListNotEmpty(column string) (string, error) {
+	return "COALESCE(cardinality(" + column + "), 0) > 0", nil
+}
+
+func (postgreSQL) Duration(d time.Duration) interface{} {
+	return d
+}
+
+type spanner struct{}
+
+func (spanner) Placeholder(index int) string {
+	return "@p" + strconv.Itoa(index)
+}
+
+func (spanner) QuoteIdentifier(name string) string {
+	// Quoted identifiers in GoogleSQL use the escape sequences of string literals rather than doubled quotes.
+	var result strings.Builder
+	result.Grow(len(name) + 2)
+	_ = result.WriteByte('`')
+	for i := 0; i < len(name); i++ {
+		if name[i] == '`' || name[i] == '\\' {
+			_ = result.WriteByte('\\')
+		}
+		_ = result.WriteByte(name[i])
+	}
+	_ = result.WriteByte('`')
+	return result.String()
+}
+
+func (spanner) Like(operand, pattern string) string {
+	return operand + " LIKE " + pattern
+}
+
+func (spanner) RegexpMatch(operand, pattern string) string {
+	return "REGEXP_CONTAINS(" + operand + ", " + pattern + ")"
+}
+
+func (spanner) MapValue(column, key string, _ Params) (string, error) {
+	path, err := spannerJSONPath(key)
+	if err != nil {
+		return "", err
+	}
+	return "JSON_VALUE(" + column + ", " + path + ")", nil
+}
+
+func (spanner) MapHasKey(column, key string, _ Params) (string, error) {
+	path, err := spannerJSONPath(key)
+	if err != nil {
+		return "", err
+	}
+	return "JSON_QUERY(" + column + ", " + path + ") IS NOT NULL", nil
+}
+
+func (spanner) ListContains(column, element string) (string, error) {
+	return element + " IN UNNEST(" + column + ")", nil
+}
+
+func (spanner) MapNotEmpty(column string) (string, error) {
+	// JSON values are not comparable in Spanner, so the map is compared by its JSON string.
+	return "COALESCE(TO_JSON_STRING(" + column + "), '{}') NOT IN ('{}', 'null')", nil
+}
+
+func (spanner) ListNotEmpty(column string) (string, error) {
+	return "COALESCE(ARRAY_LENGTH(" + column + "), 0) > 0", nil
+}
+
+func (spanner) Duration(d time.Duration) interface{} {
+	return d.Nanoseconds()
+}
+
+// spannerJSONPath returns a JSON path literal for the provided key.
+// JSON paths in Spanner must be constant, so the key is inlined in the query.
+func spannerJSONPath(key string) (string, error) {
+	if strings.ContainsAny(key, `'"\`) || !isPrintable(key) {
+		return "", fmt.Errorf("unsupported map key %q", key)
+	}
+	return `'$."` + key + `"'`, nil
+}
+
+type sqlite struct{}
+
+func (sqlite) Placeholder(index int) string {
+	return "?" + strconv.Itoa(index)
+}
+
+func (sqlite) QuoteIdentifier(name string) string {
+	return quoteIdentifier(name, '"')
+}
+
+func (sqlite) Like(operand, pattern string) string {
+	return operand + " LIKE " + pattern + ` ESCAPE '\'`
+}
+
+func (sqlite) RegexpMatch(operand, pattern string) string {
+	return operand + " REGEXP " + pattern
+}
+
+func (sqlite) MapValue(column, key string, params Params) (string, error) {
+	return "json_extract(" + column + ", " + params.Add(sqliteJSONPath(key)) + ")", nil
+}
+
+func (sqlite) MapHasKey(column, key string, params Params) (string, error) {
+	return "json_type(" + column + ", " + params.Add(sqliteJSONPath(key)) + ") IS NOT NULL", nil
+}
+
+func (sqlite) ListContains(column, element string) (string, error) {
+	return "EXISTS (SELECT 1 FROM json_each(" + column + ") WHERE json_each.value = " + element + ")", nil
+}
+
+func (sqlite) MapNotEmpty(column string) (string, error) {
+	return "EXISTS (SELECT 1 FROM json_each(" + column + "))", nil
+}
+
+func (sqlite) ListNotEmpty(column string) (string, error) {
+	return "EXISTS (SELECT 1 FROM json_each(" + column + "))", nil
+}
+
+func (sqlite) Duration(d time.Duration) interface{} {
+	return d.Nanoseconds()
+}
+
+func sqliteJSONPath(key string) string {
+	return `$."` + strings.ReplaceAll(key, `"`, `\"`) + `"`
+}
+
+func quoteIdentifier(name string, quote byte) string {
+	var result strings.Builder
+	result.Grow(len(name) + 2)
+	_ = result.WriteByte(quote)
+	for i := 0; i < len(name); i++ {
+		if name[i] == quote {
+			_ = result.WriteByte(quote)
+		}
+		_ = result.WriteByte(name[i])
+	}
+	_ = result.WriteByte(quote)
+	return result.String()
+}
+
+func isPrintable(s string) bool {
+	for _, r := range s {
+		if r < ' ' || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,30 @@
+package sqlfilter
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestDialect_QuoteIdentifier(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		name     string
+		dialect  Dialect
+		ident    string
+		expected string
+	}{
+		{name: "postgresql", dialect: PostgreSQL, ident: "display_name", expected: `"display_name"`},
+		{name: "postgresql quote", dialect: PostgreSQL, ident: `a"b`, expected: `"a""b"`},
+		{name: "spanner", dialect: Spanner, ident: "display_name", expected: "`display_name`"},
+		{name: "spanner backtick", dialect: Spanner, ident: "a`b", expected: "`a\\`b`"},
+		{name: "spanner backslash", dialect: Spanner, ident: `a\b`, expected: "`a\\\\b`"},
+		{name: "sqlite quote", dialect: SQLite, ident: `a"b`, expected: `"a""b"`},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, tt.dialect.QuoteIdentifier(tt.ident))
+		})
+	}
+}
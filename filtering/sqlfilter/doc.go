@@ -0,0 +1,4 @@
+// Package sqlfilter provides primitives for transpiling AIP filters to parameterized SQL WHERE clauses.
+//
+// See: https://google.aip.dev/160 (Filtering)
+package sqlfilter
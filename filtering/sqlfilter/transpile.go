@@ -0,0 +1,673 @@
+package sqlfilter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.einride.tech/aip/filtering"
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// ColumnMapping maps a filter identifier, such as "create_time" or "origin.display_name", to a SQL column expression.
+type ColumnMapping func(ident string) (string, error)
+
+// Option configures Transpile.
+type Option func(*transpiler)
+
+// WithDialect sets the SQL dialect. The default dialect is PostgreSQL.
+func WithDialect(dialect Dialect) Option {
+	return func(t *transpiler) {
+		t.dialect = dialect
+	}
+}
+
+// WithColumnMapping sets the mapping from filter identifiers to SQL columns.
+//
+// The default mapping quotes unqualified identifiers as column names, and fails for qualified identifiers.
+func WithColumnMapping(columns ColumnMapping) Option {
+	return func(t *transpiler) {
+		t.columns = columns
+	}
+}
+
+// WithEnumNumbers transpiles enum constants to their numbers instead of their value names.
+//
+// The enum types must be registered in the global protobuf type registry.
+func WithEnumNumbers() Option {
+	return func(t *transpiler) {
+		t.enumNumbers = true
+	}
+}
+
+// WithFunctions transpiles custom functions using the SQL renderings in the provided registry.
+//
+// Use FunctionRegistry.ValidateSQL to check that all custom functions have SQL renderings.
+func WithFunctions(registry *filtering.FunctionRegistry) Option {
+	return func(t *transpiler) {
+		t.functions = registry
+	}
+}
+
+// WithRepeatedField transpiles restrictions on the fields of the elements of the repeated message field with the
+// provided path, such as `line_items.title = "Pallet"`, to a condition on any element.
+//
+// The exists function wraps the condition on an element in an existential subquery, such as
+// "EXISTS (SELECT 1 FROM line_items WHERE line_items.shipment_id = shipments.id AND " + condition + ")", and the
+// fields of the elements are mapped by the column mapping with qualified names, such as "line_items.title".
+func WithRepeatedField(path string, exists func(condition string) string) Option {
+	return func(t *transpiler) {
+		if t.repeatedFields == nil {
+			t.repeatedFields = make(map[string]func(string) string)
+		}
+		t.repeatedFields[path] = exists
+	}
+}
+
+// Transpile the filter to a SQL WHERE clause with positional parameters.
+//
+// An empty filter transpiles to an empty WHERE clause. Comparisons of nullable wrapper, timestamp and duration fields
+// are transpiled with the null semantics of filtering.Evaluate, where null is unequal to any other value, as in
+// `weight != 5`.
+func Transpile(filter filtering.Filter, opts ...Option) (_ string, _ []interface{}, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("transpile filter: %w", err)
+		}
+	}()
+	if filter.CheckedExpr == nil {
+		return "", nil, nil
+	}
+	t := transpiler{
+		dialect:     PostgreSQL,
+		checkedExpr: filter.CheckedExpr,
+	}
+	for _, opt := range opts {
+		opt(&t)
+	}
+	if t.columns == nil {
+		t.columns = t.defaultColumnMapping
+	}
+	sql, err := t.transpile(filter.CheckedExpr.GetExpr())
+	if err != nil {
+		return "", nil, err
+	}
+	return sql, t.args, nil
+}
+
+type transpiler struct {
+	dialect     Dialect
+	columns     ColumnMapping
+	enumNumbers bool
+	functions   *filtering.FunctionRegistry
+	checkedExpr *expr.CheckedExpr
+	args        []interface{}
+	// repeatedFields are the exists functions of repeated message fields, by path.
+	repeatedFields map[string]func(condition string) string
+	// inRepeatedField is true while transpiling a condition on the elements of a repeated field.
+	inRepeatedField bool
+	// inNot is true while transpiling the argument of NOT.
+	inNot bool
+}
+
+var _ Params = &transpiler{}
+
+// Add implements Params.
+func (t *transpiler) Add(value interface{}) string {
+	t.args = append(t.args, value)
+	return t.dialect.Placeholder(len(t.args))
+}
+
+func (t *transpiler) defaultColumnMapping(ident string) (string, error) {
+	if strings.Contains(ident, ".") {
+		return "", fmt.Errorf("no column mapping for qualified identifier '%s'", ident)
+	}
+	return t.dialect.QuoteIdentifier(ident), nil
+}
+
+func (t *transpiler) transpile(e *expr.Expr) (string, error) {
+	if !t.inRepeatedField && t.isCondition(e) {
+		if paths := t.repeatedFieldPaths(e, nil); len(paths) > 0 {
+			return t.transpileRepeatedFieldCondition(e, paths)
+		}
+	}
+	switch kind := e.GetExprKind().(type) {
+	case *expr.Expr_ConstExpr:
+		return t.transpileConstant(e, kind.ConstExpr)
+	case *expr.Expr_IdentExpr:
+		if reference := t.checkedExpr.GetReferenceMap()[e.GetId()]; reference.GetValue() != nil {
+			return t.transpileConstant(e, reference.GetValue())
+		}
+		return t.columns(kind.IdentExpr.GetName())
+	case *expr.Expr_SelectExpr:
+		return t.transpileSelect(e)
+	case *expr.Expr_CallExpr:
+		return t.transpileCall(e)
+	default:
+		return "", fmt.Errorf("unsupported expr kind %T", kind)
+	}
+}
+
+// isCondition returns true if the provided expression is a bool restriction, other than a junction or negation.
+func (t *transpiler) isCondition(e *expr.Expr) bool {
+	if t.checkedExpr.GetTypeMap()[e.GetId()].GetPrimitive() != expr.Type_BOOL {
+		return false
+	}
+	switch e.GetCallExpr().GetFunction() {
+	case filtering.FunctionAnd, filtering.FunctionFuzzyAnd, filtering.FunctionOr, filtering.FunctionNot:
+		return false
+	}
+	return true
+}
+
+// repeatedFieldPaths appends the paths of the repeated message fields that are selected from in the provided
+// expression to paths.
+func (t *transpiler) repeatedFieldPaths(e *expr.Expr, paths []string) []string {
+	switch kind := e.GetExprKind().(type) {
+	case *expr.Expr_SelectExpr:
+		operand := kind.SelectExpr.GetOperand()
+		if t.checkedExpr.GetTypeMap()[operand.GetId()].GetListType() != nil {
+			path, _ := filtering.QualifiedName(operand)
+			if !contains(paths, path) {
+				paths = append(paths, path)
+			}
+		}
+		return t.repeatedFieldPaths(operand, paths)
+	case *expr.Expr_CallExpr:
+		for _, arg := range kind.CallExpr.GetArgs() {
+			paths = t.repeatedFieldPaths(arg, paths)
+		}
+	}
+	return paths
+}
+
+// transpileRepeatedFieldCondition transpiles a condition on the elements of a repeated message field, which is true
+// if the condition is true for any element.
+func (t *transpiler) transpileRepeatedFieldCondition(e *expr.Expr, paths []string) (string, error) {
+	if len(paths) > 1 {
+		return "", fmt.Errorf("unsupported restriction on multiple repeated fields '%s'", strings.Join(paths, "', '"))
+	}
+	exists, ok := t.repeatedFields[paths[0]]
+	if !ok {
+		return "", fmt.Errorf("no mapping for repeated field '%s'", paths[0])
+	}
+	t.inRepeatedField = true
+	condition, err := t.transpile(e)
+	t.inRepeatedField = false
+	if err != nil {
+		return "", err
+	}
+	return exists(condition), nil
+}
+
+func (t *transpiler) transpileConstant(e *expr.Expr, constant *expr.Constant) (string, error) {
+	switch kind := constant.GetConstantKind().(type) {
+	case *expr.Constant_NullValue:
+		return "NULL", nil
+	case *expr.Constant_BoolValue:
+		return t.Add(kind.BoolValue), nil
+	case *expr.Constant_Int64Value:
+		return t.Add(kind.Int64Value), nil
+	case *expr.Constant_DoubleValue:
+		return t.Add(kind.DoubleValue), nil
+	case *expr.Constant_StringValue:
+		if t.enumNumbers {
+			if enumType, ok := t.enumType(e); ok {
+				value := enumType.Descriptor().Values().ByName(protoreflect.Name(kind.StringValue))
+				if value == nil {
+					return "", fmt.Errorf("unknown value '%s' of enum %s", kind.StringValue, enumType.Descriptor().FullName())
+				}
+				return t.Add(int64(value.Number())), nil
+			}
+		}
+		return t.Add(kind.StringValue), nil
+	default:
+		return "", fmt.Errorf("unsupported constant kind %T", kind)
+	}
+}
+
+func (t *transpiler) enumType(e *expr.Expr) (protoreflect.EnumType, bool) {
+	messageType := t.checkedExpr.GetTypeMap()[e.GetId()].GetMessageType()
+	if messageType == "" {
+		return nil, false
+	}
+	enumType, err := protoregistry.GlobalTypes.FindEnumByName(protoreflect.FullName(messageType))
+	if err != nil {
+		return nil, false
+	}
+	return enumType, true
+}
+
+func (t *transpiler) transpileSelect(e *expr.Expr) (string, error) {
+	if reference := t.checkedExpr.GetReferenceMap()[e.GetId()]; reference != nil {
+		if reference.GetValue() != nil {
+			return t.transpileConstant(e, reference.GetValue())
+		}
+		return t.columns(reference.GetName())
+	}
+	selectExpr := e.GetSelectExpr()
+	operandType := t.checkedExpr.GetTypeMap()[selectExpr.GetOperand().GetId()]
+	if operandType.GetMessageType() != "" || operandType.GetListType().GetElemType().GetMessageType() != "" {
+		qualifiedName, ok := filtering.QualifiedName(e)
+		if !ok {
+			return "", fmt.Errorf("unsupported select of '%s'", selectExpr.GetField())
+		}
+		return t.columns(qualifiedName)
+	}
+	if operandType.GetMapType() == nil {
+		return "", fmt.Errorf("unsupported select of '%s'", selectExpr.GetField())
+	}
+	if !isString(operandType.GetMapType().GetValueType()) {
+		return "", fmt.Errorf("unsupported select of '%s' from map with non-string values", selectExpr.GetField())
+	}
+	column, err := t.transpile(selectExpr.GetOperand())
+	if err != nil {
+		return "", err
+	}
+	return t.dialect.MapValue(column, selectExpr.GetField(), t)
+}
+
+func (t *transpiler) transpileCall(e *expr.Expr) (string, error) {
+	call := e.GetCallExpr()
+	// Custom overloads of standard functions, such as `=`, are transpiled using their registered SQL rendering.
+	for _, overloadID := range t.checkedExpr.GetReferenceMap()[e.GetId()].GetOverloadId() {
+		if _, ok := t.functions.LookupSQLFunction(overloadID); ok {
+			return t.transpileCustomFunction(e)
+		}
+	}
+	switch call.GetFunction() {
+	case filtering.FunctionAnd, filtering.FunctionFuzzyAnd:
+		return t.transpileJunction("AND", call.GetArgs())
+	case filtering.FunctionOr:
+		return t.transpileJunction("OR", call.GetArgs())
+	case filtering.FunctionNot:
+		if len(call.GetArgs()) != 1 {
+			return "", fmt.Errorf("%s: expected 1 arg but got %d", call.GetFunction(), len(call.GetArgs()))
+		}
+		// Comparisons of nullable operands under NOT are transpiled to be false rather than NULL for null operands.
+		inNot := t.inNot
+		t.inNot = true
+		arg, err := t.transpile(call.GetArgs()[0])
+		t.inNot = inNot
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + arg + ")", nil
+	case filtering.FunctionTimestamp:
+		s, err := t.constantStringArg(call)
+		if err != nil {
+			return "", err
+		}
+		value, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", call.GetFunction(), err)
+		}
+		return t.Add(value), nil
+	case filtering.FunctionDuration:
+		s, err := t.constantStringArg(call)
+		if err != nil {
+			return "", err
+		}
+		value, err := time.ParseDuration(s)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", call.GetFunction(), err)
+		}
+		return t.Add(t.dialect.Duration(value)), nil
+	case filtering.FunctionHas:
+		return t.transpileHas(e)
+	case filtering.FunctionEquals,
+		filtering.FunctionNotEquals,
+		filtering.FunctionLessThan,
+		filtering.FunctionLessEquals,
+		filtering.FunctionGreaterThan,
+		filtering.FunctionGreaterEquals:
+		return t.transpileComparison(e)
+	case filtering.FunctionMatches,
+		filtering.FunctionStartsWith,
+		filtering.FunctionEndsWith,
+		filtering.FunctionLower,
+		filtering.FunctionContains:
+		return t.transpileStringFunction(e)
+	default:
+		return t.transpileCustomFunction(e)
+	}
+}
+
+// transpileStringFunction transpiles a call to a string function, or to a custom overload of a string function.
+func (t *transpiler) transpileStringFunction(e *expr.Expr) (string, error) {
+	call := e.GetCallExpr()
+	for _, overloadID := range t.checkedExpr.GetReferenceMap()[e.GetId()].GetOverloadId() {
+		switch overloadID {
+		case filtering.FunctionOverloadLowerString:
+			if len(call.GetArgs()) != 1 {
+				return "", fmt.Errorf("%s: expected 1 arg but got %d", call.GetFunction(), len(call.GetArgs()))
+			}
+			arg, err := t.transpile(call.GetArgs()[0])
+			if err != nil {
+				return "", err
+			}
+			return "LOWER(" + arg + ")", nil
+		case filtering.FunctionOverloadMatchesString:
+			if len(call.GetArgs()) != 2 {
+				return "", fmt.Errorf("%s: expected 2 args but got %d", call.GetFunction(), len(call.GetArgs()))
+			}
+			lhs, err := t.transpile(call.GetArgs()[0])
+			if err != nil {
+				return "", err
+			}
+			pattern, err := t.transpile(call.GetArgs()[1])
+			if err != nil {
+				return "", err
+			}
+			return t.dialect.RegexpMatch(lhs, pattern), nil
+		case filtering.FunctionOverloadStartsWithString,
+			filtering.FunctionOverloadEndsWithString,
+			filtering.FunctionOverloadContainsString:
+			if len(call.GetArgs()) != 2 {
+				return "", fmt.Errorf("%s: expected 2 args but got %d", call.GetFunction(), len(call.GetArgs()))
+			}
+			lhs, err := t.transpile(call.GetArgs()[0])
+			if err != nil {
+				return "", err
+			}
+			s, ok := constantString(call.GetArgs()[1])
+			if !ok {
+				return "", fmt.Errorf("%s: expected constant string", call.GetFunction())
+			}
+			pattern := escapeLike(s)
+			switch overloadID {
+			case filtering.FunctionOverloadStartsWithString:
+				pattern += "%"
+			case filtering.FunctionOverloadEndsWithString:
+				pattern = "%" + pattern
+			default:
+				pattern = "%" + pattern + "%"
+			}
+			return t.dialect.Like(lhs, t.Add(pattern)), nil
+		}
+	}
+	return t.transpileCustomFunction(e)
+}
+
+// transpileCustomFunction transpiles a call to a custom function using its registered SQL rendering.
+func (t *transpiler) transpileCustomFunction(e *expr.Expr) (string, error) {
+	call := e.GetCallExpr()
+	for _, overloadID := range t.checkedExpr.GetReferenceMap()[e.GetId()].GetOverloadId() {
+		sqlFunction, ok := t.functions.LookupSQLFunction(overloadID)
+		if !ok {
+			continue
+		}
+		args := make([]string, 0, len(call.GetArgs()))
+		for _, arg := range call.GetArgs() {
+			sql, err := t.transpile(arg)
+			if err != nil {
+				return "", err
+			}
+			args = append(args, sql)
+		}
+		result, err := sqlFunction(args...)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", call.GetFunction(), err)
+		}
+		return result, nil
+	}
+	return "", fmt.Errorf("unsupported function '%s'", call.GetFunction())
+}
+
+func (t *transpiler) transpileJunction(operator string, args []*expr.Expr) (string, error) {
+	var result strings.Builder
+	_ = result.WriteByte('(')
+	for i, arg := range args {
+		sql, err := t.transpile(arg)
+		if err != nil {
+			return "", err
+		}
+		if i > 0 {
+			_ = result.WriteByte(' ')
+			_, _ = result.WriteString(operator)
+			_ = result.WriteByte(' ')
+		}
+		_, _ = result.WriteString(sql)
+	}
+	_ = result.WriteByte(')')
+	return result.String(), nil
+}
+
+func (t *transpiler) transpileComparison(e *expr.Expr) (string, error) {
+	call := e.GetCallExpr()
+	if len(call.GetArgs()) != 2 {
+		return "", fmt.Errorf("%s: expected 2 args but got %d", call.GetFunction(), len(call.GetArgs()))
+	}
+	lhs, err := t.transpile(call.GetArgs()[0])
+	if err != nil {
+		return "", err
+	}
+	if t.isWildcardComparison(e) {
+		result, err := t.transpileWildcardComparison(call, lhs)
+		if err != nil {
+			return "", err
+		}
+		return t.coalesceNullable(call, lhs, "", result), nil
+	}
+	if isNull(call.GetArgs()[1]) {
+		switch call.GetFunction() {
+		case filtering.FunctionEquals:
+			return lhs + " IS NULL", nil
+		case filtering.FunctionNotEquals:
+			return lhs + " IS NOT NULL", nil
+		}
+	}
+	var rhs string
+	if t.isTimestampStringComparison(e) {
+		s, ok := constantString(call.GetArgs()[1])
+		if !ok {
+			return "", fmt.Errorf("%s: expected constant timestamp string", call.GetFunction())
+		}
+		value, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", call.GetFunction(), err)
+		}
+		rhs = t.Add(value)
+	} else if rhs, err = t.transpile(call.GetArgs()[1]); err != nil {
+		return "", err
+	}
+	operator := call.GetFunction()
+	if operator == filtering.FunctionNotEquals {
+		operator = "<>"
+	}
+	return t.coalesceNullable(call, lhs, rhs, lhs+" "+operator+" "+rhs), nil
+}
+
+// coalesceNullable returns the provided comparison of nullable operands, such as wrapper and timestamp fields, with
+// the result of the comparison with null in filtering.Evaluate.
+//
+// SQL comparisons with NULL are NULL, which would exclude rows from both `weight != 5` and `NOT weight > 5`, where
+// filtering.Evaluate considers null unequal to 5 and not greater than 5. The rhs is empty for constant operands.
+func (t *transpiler) coalesceNullable(call *expr.Expr_Call, lhs, rhs, comparison string) string {
+	lhsNullable := t.isNullable(call.GetArgs()[0])
+	rhsNullable := rhs != "" && t.isNullable(call.GetArgs()[1])
+	switch {
+	case lhsNullable && rhsNullable:
+		switch call.GetFunction() {
+		case filtering.FunctionEquals:
+			return "COALESCE(" + comparison + ", " + lhs + " IS NULL AND " + rhs + " IS NULL)"
+		case filtering.FunctionNotEquals:
+			return "COALESCE(" + comparison + ", " + lhs + " IS NOT NULL OR " + rhs + " IS NOT NULL)"
+		}
+		if t.inNot {
+			return "COALESCE(" + comparison + ", FALSE)"
+		}
+	case lhsNullable || rhsNullable:
+		operand := lhs
+		if rhsNullable {
+			operand = rhs
+		}
+		if call.GetFunction() == filtering.FunctionNotEquals {
+			return "(" + comparison + " OR " + operand + " IS NULL)"
+		}
+		if t.inNot {
+			return "(" + comparison + " AND " + operand + " IS NOT NULL)"
+		}
+	}
+	return comparison
+}
+
+// isNullable returns true if the provided expression is null when unset, as fields of wrapper types and of the
+// well-known timestamp and duration types are.
+func (t *transpiler) isNullable(e *expr.Expr) bool {
+	exprType := t.checkedExpr.GetTypeMap()[e.GetId()]
+	switch {
+	case exprType.GetWrapper() != expr.Type_PRIMITIVE_TYPE_UNSPECIFIED:
+		return true
+	case exprType.GetWellKnown() == expr.Type_TIMESTAMP, exprType.GetWellKnown() == expr.Type_DURATION:
+		return e.GetIdentExpr() != nil || e.GetSelectExpr() != nil
+	default:
+		return false
+	}
+}
+
+// transpileWildcardComparison transpiles a string comparison with a wildcard pattern to LIKE.
+func (t *transpiler) transpileWildcardComparison(call *expr.Expr_Call, lhs string) (string, error) {
+	pattern, ok := constantString(call.GetArgs()[1])
+	if !ok {
+		return "", fmt.Errorf("%s: expected constant wildcard pattern", call.GetFunction())
+	}
+	segments := filtering.SplitWildcard(pattern)
+	var result string
+	if len(segments) == 1 {
+		result = lhs + " = " + t.Add(segments[0])
+	} else {
+		for i, segment := range segments {
+			segments[i] = escapeLike(segment)
+		}
+		result = t.dialect.Like(lhs, t.Add(strings.Join(segments, "%")))
+	}
+	if call.GetFunction() == filtering.FunctionNotEquals {
+		return "NOT (" + result + ")", nil
+	}
+	return result, nil
+}
+
+func (t *transpiler) isWildcardComparison(e *expr.Expr) bool {
+	for _, overloadID := range t.checkedExpr.GetReferenceMap()[e.GetId()].GetOverloadId() {
+		switch overloadID {
+		case filtering.FunctionOverloadEqualsStringWildcard, filtering.FunctionOverloadNotEqualsStringWildcard:
+			return true
+		}
+	}
+	return false
+}
+
+func (t *transpiler) isTimestampStringComparison(e *expr.Expr) bool {
+	for _, overloadID := range t.checkedExpr.GetReferenceMap()[e.GetId()].GetOverloadId() {
+		switch overloadID {
+		case filtering.FunctionOverloadEqualsTimestampString,
+			filtering.FunctionOverloadNotEqualsTimestampString,
+			filtering.FunctionOverloadLessThanTimestampString,
+			filtering.FunctionOverloadLessEqualsTimestampString,
+			filtering.FunctionOverloadGreaterThanTimestampString,
+			filtering.FunctionOverloadGreaterEqualsTimestampString:
+			return true
+		}
+	}
+	return false
+}
+
+func (t *transpiler) transpileHas(e *expr.Expr) (string, error) {
+	call := e.GetCallExpr()
+	if len(call.GetArgs()) != 2 {
+		return "", fmt.Errorf("%s: expected 2 args but got %d", call.GetFunction(), len(call.GetArgs()))
+	}
+	lhs, err := t.transpile(call.GetArgs()[0])
+	if err != nil {
+		return "", err
+	}
+	for _, overloadID := range t.checkedExpr.GetReferenceMap()[e.GetId()].GetOverloadId() {
+		switch overloadID {
+		case filtering.FunctionOverloadHasMessagePresence:
+			return lhs + " IS NOT NULL", nil
+		case filtering.FunctionOverloadHasStringPresence:
+			return "COALESCE(" + lhs + ", '') <> ''", nil
+		case filtering.FunctionOverloadHasMapPresence:
+			return t.dialect.MapNotEmpty(lhs)
+		case filtering.FunctionOverloadHasListPresence:
+			return t.dialect.ListNotEmpty(lhs)
+		}
+	}
+	lhsType := t.checkedExpr.GetTypeMap()[call.GetArgs()[0].GetId()]
+	switch {
+	case isString(lhsType) || lhsType.GetWrapper() == expr.Type_STRING:
+		s, ok := constantString(call.GetArgs()[1])
+		if !ok {
+			return "", fmt.Errorf("%s: expected constant string", call.GetFunction())
+		}
+		return t.coalesceNullable(call, lhs, "", t.dialect.Like(lhs, t.Add("%"+escapeLike(s)+"%"))), nil
+	case lhsType.GetMapType() != nil:
+		key, ok := constantString(call.GetArgs()[1])
+		if !ok {
+			return "", fmt.Errorf("%s: expected constant string map key", call.GetFunction())
+		}
+		return t.dialect.MapHasKey(lhs, key, t)
+	case lhsType.GetListType() != nil:
+		element, err := t.transpile(call.GetArgs()[1])
+		if err != nil {
+			return "", err
+		}
+		return t.dialect.ListContains(lhs, element)
+	default:
+		return "", fmt.Errorf("%s: unsupported operand type %s", call.GetFunction(), lhsType)
+	}
+}
+
+func (t *transpiler) constantStringArg(call *expr.Expr_Call) (string, error) {
+	if len(call.GetArgs()) != 1 {
+		return "", fmt.Errorf("%s: expected 1 arg but got %d", call.GetFunction(), len(call.GetArgs()))
+	}
+	s, ok := constantString(call.GetArgs()[0])
+	if !ok {
+		return "", fmt.Errorf("%s: expected constant string arg", call.GetFunction())
+	}
+	return s, nil
+}
+
+func constantString(e *expr.Expr) (string, bool) {
+	constant, ok := e.GetConstExpr().GetConstantKind().(*expr.Constant_StringValue)
+	if !ok {
+		return "", false
+	}
+	return constant.StringValue, true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func isNull(e *expr.Expr) bool {
+	_, ok := e.GetConstExpr().GetConstantKind().(*expr.Constant_NullValue)
+	return ok
+}
+
+func isString(t *expr.Type) bool {
+	return t.GetPrimitive() == expr.Type_STRING
+}
+
+// escapeLike escapes the LIKE wildcards of the provided string, using backslash as escape character.
+func escapeLike(s string) string {
+	var result strings.Builder
+	result.Grow(len(s))
+	for _, r := range s {
+		switch r {
+		case '\\', '%', '_':
+			_ = result.WriteByte('\\')
+		}
+		_, _ = result.WriteRune(r)
+	}
+	return result.String()
+}
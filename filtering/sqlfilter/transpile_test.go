@@ -0,0 +1,589 @@
+package sqlfilter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go.einride.tech/aip/filtering"
+	freightv1 "go.einride.tech/aip/proto/gen/einride/example/freight/v1"
+	syntaxv1 "go.einride.tech/aip/proto/gen/einride/example/syntax/v1"
+	"gotest.tools/v3/assert"
+)
+
+func TestTranspile(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		name          string
+		filter        string
+		declarations  []filtering.DeclarationOption
+		opts          []Option
+		expectedSQL   string
+		expectedArgs  []interface{}
+		errorContains string
+	}{
+		{
+			name:        "empty",
+			filter:      "",
+			expectedSQL: "",
+		},
+
+		{
+			name:   "equals",
+			filter: `author = "Karin Boye" AND NOT read`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("author", filtering.TypeString),
+				filtering.DeclareIdent("read", filtering.TypeBool),
+			},
+			expectedSQL:  `("author" = $1 AND NOT ("read"))`,
+			expectedArgs: []interface{}{"Karin Boye"},
+		},
+
+		{
+			name:   "or not equals",
+			filter: `a < 10 OR a != 100`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("a", filtering.TypeInt),
+			},
+			expectedSQL:  `("a" < $1 OR "a" <> $2)`,
+			expectedArgs: []interface{}{int64(10), int64(100)},
+		},
+
+		{
+			name:   "timestamp",
+			filter: `create_time > timestamp("2006-01-02T15:04:05Z") AND update_time <= "2006-01-02T15:04:05Z"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("create_time", filtering.TypeTimestamp),
+				filtering.DeclareIdent("update_time", filtering.TypeTimestamp),
+			},
+			expectedSQL: `("create_time" > $1 AND "update_time" <= $2)`,
+			expectedArgs: []interface{}{
+				time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+				time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+			},
+		},
+
+		{
+			name:   "duration spanner",
+			filter: `ttl > duration("30s")`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("ttl", filtering.TypeDuration),
+			},
+			opts:         []Option{WithDialect(Spanner)},
+			expectedSQL:  "`ttl` > @p1",
+			expectedArgs: []interface{}{(30 * time.Second).Nanoseconds()},
+		},
+
+		{
+			name:   "enum",
+			filter: `enum = ENUM_ONE`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+			},
+			expectedSQL:  `"enum" = $1`,
+			expectedArgs: []interface{}{"ENUM_ONE"},
+		},
+
+		{
+			name:   "enum numbers",
+			filter: `enum = ENUM_ONE`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+			},
+			opts:         []Option{WithEnumNumbers()},
+			expectedSQL:  `"enum" = $1`,
+			expectedArgs: []interface{}{int64(1)},
+		},
+
+		{
+			name:   "has string",
+			filter: `file:"my_file%"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("file", filtering.TypeString),
+			},
+			expectedSQL:  `"file" LIKE $1 ESCAPE '\'`,
+			expectedArgs: []interface{}{`%my\_file\%%`},
+		},
+
+		{
+			name:   "wildcard",
+			filter: `name = "shippers/*/shipments/1_*" AND display_name != "*\*"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("name", filtering.TypeString),
+				filtering.DeclareIdent("display_name", filtering.TypeString),
+			},
+			expectedSQL:  `("name" LIKE $1 ESCAPE '\' AND NOT ("display_name" LIKE $2 ESCAPE '\'))`,
+			expectedArgs: []interface{}{`shippers/%/shipments/1\_%`, `%*`},
+		},
+
+		{
+			name:   "escaped wildcard",
+			filter: `name = "\*"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("name", filtering.TypeString),
+			},
+			expectedSQL:  `"name" = $1`,
+			expectedArgs: []interface{}{"*"},
+		},
+
+		{
+			name:   "escaped backslash wildcard",
+			filter: `name = "C:\\*" AND display_name = "C:\dir"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("name", filtering.TypeString),
+				filtering.DeclareIdent("display_name", filtering.TypeString),
+			},
+			expectedSQL:  `("name" LIKE $1 ESCAPE '\' AND "display_name" = $2)`,
+			expectedArgs: []interface{}{`C:\\%`, `C:\dir`},
+		},
+
+		{
+			name:   "map select",
+			filter: `labels.env = "prod"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("labels", filtering.TypeMap(filtering.TypeString, filtering.TypeString)),
+			},
+			expectedSQL:  `("labels" ->> $1) = $2`,
+			expectedArgs: []interface{}{"env", "prod"},
+		},
+
+		{
+			name:   "map select spanner",
+			filter: `labels.env = "prod"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("labels", filtering.TypeMap(filtering.TypeString, filtering.TypeString)),
+			},
+			opts:         []Option{WithDialect(Spanner)},
+			expectedSQL:  "JSON_VALUE(`labels`, '$.\"env\"') = @p1",
+			expectedArgs: []interface{}{"prod"},
+		},
+
+		{
+			name:   "map select sqlite",
+			filter: `labels.env = "prod"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("labels", filtering.TypeMap(filtering.TypeString, filtering.TypeString)),
+			},
+			opts:         []Option{WithDialect(SQLite)},
+			expectedSQL:  `json_extract("labels", ?1) = ?2`,
+			expectedArgs: []interface{}{`$."env"`, "prod"},
+		},
+
+		{
+			name:   "map has key",
+			filter: `labels:env`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("labels", filtering.TypeMap(filtering.TypeString, filtering.TypeString)),
+			},
+			expectedSQL:  `"labels" ? $1`,
+			expectedArgs: []interface{}{"env"},
+		},
+
+		{
+			name:   "list contains",
+			filter: `tags:"urgent"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("tags", filtering.TypeList(filtering.TypeString)),
+			},
+			opts:         []Option{WithDialect(Spanner)},
+			expectedSQL:  "@p1 IN UNNEST(`tags`)",
+			expectedArgs: []interface{}{"urgent"},
+		},
+
+		{
+			name:   "bool literal",
+			filter: `read = false`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareStandardConstants(),
+				filtering.DeclareIdent("read", filtering.TypeBool),
+			},
+			expectedSQL:  `"read" = $1`,
+			expectedArgs: []interface{}{false},
+		},
+
+		{
+			name:   "presence",
+			filter: `author:* AND publish_time:* AND labels:* AND tags:*`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("author", filtering.TypeString),
+				filtering.DeclareIdent("publish_time", filtering.TypeTimestamp),
+				filtering.DeclareIdent("labels", filtering.TypeMap(filtering.TypeString, filtering.TypeString)),
+				filtering.DeclareIdent("tags", filtering.TypeList(filtering.TypeString)),
+			},
+			expectedSQL: `(((COALESCE("author", '') <> '' AND "publish_time" IS NOT NULL) AND ` +
+				`COALESCE("labels", '{}'::jsonb) <> '{}'::jsonb) AND COALESCE(cardinality("tags"), 0) > 0)`,
+		},
+
+		{
+			name:   "presence spanner",
+			filter: `labels:* AND tags:*`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("labels", filtering.TypeMap(filtering.TypeString, filtering.TypeString)),
+				filtering.DeclareIdent("tags", filtering.TypeList(filtering.TypeString)),
+			},
+			opts: []Option{WithDialect(Spanner)},
+			expectedSQL: "(COALESCE(TO_JSON_STRING(`labels`), '{}') NOT IN ('{}', 'null') AND " +
+				"COALESCE(ARRAY_LENGTH(`tags`), 0) > 0)",
+		},
+
+		{
+			name:   "presence sqlite",
+			filter: `labels:* AND tags:*`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("labels", filtering.TypeMap(filtering.TypeString, filtering.TypeString)),
+				filtering.DeclareIdent("tags", filtering.TypeList(filtering.TypeString)),
+			},
+			opts: []Option{WithDialect(SQLite)},
+			expectedSQL: `(EXISTS (SELECT 1 FROM json_each("labels")) AND ` +
+				`EXISTS (SELECT 1 FROM json_each("tags")))`,
+		},
+
+		{
+			name:   "message presence",
+			filter: `shipment.line_items:* AND NOT shipment.annotations:*`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareMessageIdent("shipment", (&freightv1.Shipment{}).ProtoReflect().Descriptor()),
+			},
+			opts: []Option{
+				WithColumnMapping(func(ident string) (string, error) {
+					return strings.ReplaceAll(ident, ".", "_"), nil
+				}),
+			},
+			expectedSQL: `(COALESCE(cardinality(shipment_line_items), 0) > 0 AND ` +
+				`NOT (COALESCE(shipment_annotations, '{}'::jsonb) <> '{}'::jsonb))`,
+		},
+
+		{
+			name:   "column mapping",
+			filter: `origin.display_name = "Factory"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("origin.display_name", filtering.TypeString),
+			},
+			opts: []Option{
+				WithColumnMapping(func(ident string) (string, error) {
+					if ident == "origin.display_name" {
+						return "origin_sites.display_name", nil
+					}
+					return "", fmt.Errorf("unknown ident %s", ident)
+				}),
+			},
+			expectedSQL:  `origin_sites.display_name = $1`,
+			expectedArgs: []interface{}{"Factory"},
+		},
+
+		{
+			name:   "message field",
+			filter: `shipment.origin_site = "shippers/1/sites/1"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareMessageIdent("shipment", (&freightv1.Shipment{}).ProtoReflect().Descriptor()),
+			},
+			opts: []Option{
+				WithColumnMapping(func(ident string) (string, error) {
+					return strings.ReplaceAll(ident, ".", "_"), nil
+				}),
+			},
+			expectedSQL:  `shipment_origin_site = $1`,
+			expectedArgs: []interface{}{"shippers/1/sites/1"},
+		},
+
+		{
+			name:   "qualified ident without column mapping",
+			filter: `origin.display_name = "Factory"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("origin.display_name", filtering.TypeString),
+			},
+			errorContains: "no column mapping for qualified identifier 'origin.display_name'",
+		},
+
+		{
+			name:   "string functions",
+			filter: `display_name.matches("^Dep") AND startsWith(name, "shippers/1_") AND NOT endsWith(lower(name), "%")`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareStringFunctions(),
+				filtering.DeclareIdent("display_name", filtering.TypeString),
+				filtering.DeclareIdent("name", filtering.TypeString),
+			},
+			expectedSQL:  `(("display_name" ~ $1 AND "name" LIKE $2 ESCAPE '\') AND NOT (LOWER("name") LIKE $3 ESCAPE '\'))`,
+			expectedArgs: []interface{}{"^Dep", `shippers/1\_%`, `%\%`},
+		},
+
+		{
+			name:   "string functions spanner",
+			filter: `display_name.matches("^Dep") AND contains(display_name, "pot")`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareStringFunctions(),
+				filtering.DeclareIdent("display_name", filtering.TypeString),
+			},
+			opts:         []Option{WithDialect(Spanner)},
+			expectedSQL:  "(REGEXP_CONTAINS(`display_name`, @p1) AND `display_name` LIKE @p2)",
+			expectedArgs: []interface{}{"^Dep", "%pot%"},
+		},
+
+		{
+			name:   "non-constant string function arg",
+			filter: `startsWith(display_name, name)`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareStringFunctions(),
+				filtering.DeclareIdent("display_name", filtering.TypeString),
+				filtering.DeclareIdent("name", filtering.TypeString),
+			},
+			errorContains: "startsWith: expected constant string",
+		},
+
+		{
+			name:   "wrappers",
+			filter: `weight = null AND nickname != null AND weight > 100`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareStandardConstants(),
+				filtering.DeclareIdent("weight", filtering.TypeIntWrapper),
+				filtering.DeclareIdent("nickname", filtering.TypeStringWrapper),
+			},
+			expectedSQL:  `(("weight" IS NULL AND "nickname" IS NOT NULL) AND "weight" > $1)`,
+			expectedArgs: []interface{}{int64(100)},
+		},
+
+		{
+			name:   "nullable wrappers",
+			filter: `weight != 5 AND NOT weight > 100 AND NOT nickname:"o" AND nickname != "B*" AND weight != count`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("weight", filtering.TypeIntWrapper),
+				filtering.DeclareIdent("count", filtering.TypeIntWrapper),
+				filtering.DeclareIdent("nickname", filtering.TypeStringWrapper),
+			},
+			expectedSQL: `((((("weight" <> $1 OR "weight" IS NULL) AND NOT (("weight" > $2 AND "weight" IS NOT NULL))) AND ` +
+				`NOT (("nickname" LIKE $3 ESCAPE '\' AND "nickname" IS NOT NULL))) AND ` +
+				`(NOT ("nickname" LIKE $4 ESCAPE '\') OR "nickname" IS NULL)) AND ` +
+				`COALESCE("weight" <> "count", "weight" IS NOT NULL OR "count" IS NOT NULL))`,
+			expectedArgs: []interface{}{int64(5), int64(100), "%o%", "B%"},
+		},
+
+		{
+			name:   "repeated message field",
+			filter: `line_items.title = "Pallet" AND line_items.weight_kg > 100.0 OR NOT line_items.title:"Box"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareMessageFields((&freightv1.Shipment{}).ProtoReflect().Descriptor()),
+			},
+			opts: []Option{
+				WithColumnMapping(func(ident string) (string, error) {
+					return ident, nil
+				}),
+				WithRepeatedField("line_items", func(condition string) string {
+					return "EXISTS (SELECT 1 FROM line_items WHERE line_items.shipment_id = shipments.id AND " +
+						condition + ")"
+				}),
+			},
+			expectedSQL: `(EXISTS (SELECT 1 FROM line_items WHERE line_items.shipment_id = shipments.id AND ` +
+				`line_items.title = $1) AND (EXISTS (SELECT 1 FROM line_items WHERE ` +
+				`line_items.shipment_id = shipments.id AND line_items.weight_kg > $2) OR NOT (EXISTS ` +
+				`(SELECT 1 FROM line_items WHERE line_items.shipment_id = shipments.id AND line_items.title LIKE $3 ` +
+				`ESCAPE '\'))))`,
+			expectedArgs: []interface{}{"Pallet", 100.0, "%Box%"},
+		},
+
+		{
+			name:   "unmapped repeated message field",
+			filter: `line_items.title = "Pallet"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareMessageFields((&freightv1.Shipment{}).ProtoReflect().Descriptor()),
+			},
+			opts: []Option{
+				WithColumnMapping(func(ident string) (string, error) {
+					return ident, nil
+				}),
+			},
+			errorContains: "no mapping for repeated field 'line_items'",
+		},
+
+		{
+			name:   "multiple repeated message fields",
+			filter: `message.repeated_message.int64 = message.repeated_message.repeated_message.int64`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareMessageIdent("message", (&syntaxv1.Message{}).ProtoReflect().Descriptor()),
+			},
+			errorContains: "unsupported restriction on multiple repeated fields",
+		},
+
+		{
+			name:   "unsupported function",
+			filter: `regex(name, "^shippers/")`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareIdent("name", filtering.TypeString),
+				filtering.DeclareFunction(
+					"regex",
+					filtering.NewFunctionOverload("regex_string", filtering.TypeBool, filtering.TypeString, filtering.TypeString),
+				),
+			},
+			errorContains: "unsupported function 'regex'",
+		},
+
+		{
+			name:   "non-constant duration",
+			filter: `ttl > duration(input_field)`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("ttl", filtering.TypeDuration),
+				filtering.DeclareIdent("input_field", filtering.TypeString),
+			},
+			errorContains: "duration: expected constant string arg",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			declarations, err := filtering.NewDeclarations(tt.declarations...)
+			assert.NilError(t, err)
+			filter, err := filtering.ParseFilter(&mockRequest{filter: tt.filter}, declarations)
+			assert.NilError(t, err)
+			sql, args, err := Transpile(filter, tt.opts...)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expectedSQL, sql)
+			assert.DeepEqual(t, tt.expectedArgs, args)
+		})
+	}
+}
+
+func TestTranspile_functions(t *testing.T) {
+	t.Parallel()
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		filtering.DeclareIdent("display_name", filtering.TypeString),
+		filtering.DeclareFunction(
+			"prefix",
+			filtering.NewFunctionOverload(
+				"prefix_string_string",
+				filtering.TypeBool,
+				filtering.TypeString,
+				filtering.TypeString,
+			),
+		),
+		filtering.DeclareFunction(
+			filtering.FunctionEquals,
+			filtering.NewFunctionOverload("equals_string_int", filtering.TypeBool, filtering.TypeString, filtering.TypeInt),
+		),
+	)
+	assert.NilError(t, err)
+	registry, err := filtering.NewFunctionRegistry(
+		declarations,
+		filtering.ImplementFunction("prefix_string_string", func(args ...interface{}) (interface{}, error) {
+			return strings.HasPrefix(args[0].(string), args[1].(string)), nil
+		}),
+		filtering.ImplementSQLFunction("prefix_string_string", func(args ...string) (string, error) {
+			return "STARTS_WITH(" + args[0] + ", " + args[1] + ")", nil
+		}),
+		filtering.ImplementFunction("equals_string_int", func(args ...interface{}) (interface{}, error) {
+			return int64(len(args[0].(string))) == args[1].(int64), nil
+		}),
+		filtering.ImplementSQLFunction("equals_string_int", func(args ...string) (string, error) {
+			return "CHAR_LENGTH(" + args[0] + ") = " + args[1], nil
+		}),
+	)
+	assert.NilError(t, err)
+	assert.NilError(t, registry.ValidateSQL())
+	filter, err := filtering.ParseFilter(
+		&mockRequest{filter: `prefix(display_name, "Dep") OR NOT prefix("a", "b") OR display_name = 5`},
+		declarations,
+	)
+	assert.NilError(t, err)
+	sql, args, err := Transpile(filter, WithFunctions(registry))
+	assert.NilError(t, err)
+	assert.Equal(
+		t,
+		`((STARTS_WITH("display_name", $1) OR NOT (STARTS_WITH($2, $3))) OR CHAR_LENGTH("display_name") = $4)`,
+		sql,
+	)
+	assert.DeepEqual(t, []interface{}{"Dep", "a", "b", int64(5)}, args)
+	_, _, err = Transpile(filter)
+	assert.ErrorContains(t, err, "unsupported function 'prefix'")
+}
+
+func TestTranspile_unsetTimestamps(t *testing.T) {
+	t.Parallel()
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		filtering.DeclareMessageFields((&freightv1.Shipment{}).ProtoReflect().Descriptor()),
+	)
+	assert.NilError(t, err)
+	for _, tt := range []struct {
+		filter       string
+		expected     bool
+		expectedSQL  string
+		expectedArgs []interface{}
+	}{
+		{
+			filter:       `NOT create_time > "2020-01-01T00:00:00Z"`,
+			expected:     true,
+			expectedSQL:  `NOT (("create_time" > $1 AND "create_time" IS NOT NULL))`,
+			expectedArgs: []interface{}{time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			filter:       `create_time != "2020-01-01T00:00:00Z"`,
+			expected:     true,
+			expectedSQL:  `("create_time" <> $1 OR "create_time" IS NULL)`,
+			expectedArgs: []interface{}{time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			filter:      `create_time != update_time`,
+			expected:    false,
+			expectedSQL: `COALESCE("create_time" <> "update_time", "create_time" IS NOT NULL OR "update_time" IS NOT NULL)`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.filter, func(t *testing.T) {
+			t.Parallel()
+			filter, err := filtering.ParseFilter(&mockRequest{filter: tt.filter}, declarations)
+			assert.NilError(t, err)
+			// Unset timestamps are null, and Transpile compares NULL columns like Evaluate compares null values.
+			matches, err := filtering.Evaluate(filter, &freightv1.Shipment{})
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expected, matches)
+			sql, args, err := Transpile(filter)
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expectedSQL, sql)
+			assert.DeepEqual(t, tt.expectedArgs, args)
+		})
+	}
+}
+
+type mockRequest struct {
+	filter string
+}
+
+func (m *mockRequest) GetFilter() string {
+	return m.filter
+}
@@ -0,0 +1,204 @@
+package filtering
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// storedFilterVersion is the current version of the stored filter encoding.
+const storedFilterVersion = 1
+
+// StoredFilter is a type-checked filter decoded by UnmarshalFilter.
+type StoredFilter struct {
+	// Filter is the stored filter, as it was type-checked when stored. Warnings are not stored.
+	Filter Filter
+	// Fingerprint is the fingerprint of the declarations the filter was type-checked against.
+	Fingerprint string
+}
+
+// storedFilterJSON is the JSON document of a stored filter.
+type storedFilterJSON struct {
+	Version     int             `json:"version"`
+	Fingerprint string          `json:"fingerprint"`
+	CheckedExpr json.RawMessage `json:"checked_expr,omitempty"`
+}
+
+// MarshalFilter encodes the filter, type-checked against the provided declarations, for storage in saved searches
+// and subscriptions.
+//
+// The encoding is a versioned JSON document with the checked expression, in the protobuf JSON mapping, and the
+// fingerprint of the declarations. Use UnmarshalFilter to decode it and Revalidate to check it against the
+// declarations of a later version of the API.
+func MarshalFilter(filter Filter, declarations *Declarations) ([]byte, error) {
+	document := storedFilterJSON{
+		Version:     storedFilterVersion,
+		Fingerprint: declarations.Fingerprint(),
+	}
+	if filter.CheckedExpr != nil {
+		data, err := protojson.Marshal(filter.CheckedExpr)
+		if err != nil {
+			return nil, fmt.Errorf("marshal filter: %w", err)
+		}
+		document.CheckedExpr = data
+	}
+	return json.Marshal(document)
+}
+
+// UnmarshalFilter decodes a filter encoded by MarshalFilter.
+func UnmarshalFilter(data []byte) (StoredFilter, error) {
+	var document storedFilterJSON
+	if err := json.Unmarshal(data, &document); err != nil {
+		return StoredFilter{}, fmt.Errorf("unmarshal filter: %w", err)
+	}
+	if document.Version != storedFilterVersion {
+		return StoredFilter{}, fmt.Errorf("unmarshal filter: unsupported version %d", document.Version)
+	}
+	result := StoredFilter{Fingerprint: document.Fingerprint}
+	if len(document.CheckedExpr) > 0 {
+		var checkedExpr expr.CheckedExpr
+		if err := protojson.Unmarshal(document.CheckedExpr, &checkedExpr); err != nil {
+			return StoredFilter{}, fmt.Errorf("unmarshal filter: %w", err)
+		}
+		result.Filter.CheckedExpr = &checkedExpr
+	}
+	return result, nil
+}
+
+// RevalidationError is returned by Revalidate when a stored filter is no longer valid.
+type RevalidationError struct {
+	// InvalidIdents are the idents of the stored filter that are undeclared, or declared with other types or values,
+	// in the current declarations. Ordered by name.
+	InvalidIdents []string
+	err           error
+}
+
+// Error implements error.
+func (r *RevalidationError) Error() string {
+	if len(r.InvalidIdents) > 0 {
+		return fmt.Sprintf("revalidate filter: invalid idents %s: %v", strings.Join(r.InvalidIdents, ", "), r.err)
+	}
+	return fmt.Sprintf("revalidate filter: %v", r.err)
+}
+
+// Unwrap returns the type error of the stored filter.
+func (r *RevalidationError) Unwrap() error {
+	return r.err
+}
+
+// Revalidate type-checks the stored filter against the provided declarations, without re-parsing it.
+//
+// Filters that are still valid are returned with warnings from the current declarations, such as usages of
+// identifiers deprecated since the filter was stored. Filters that are no longer valid return a *RevalidationError
+// reporting the idents that became invalid. Comparing the fingerprint of the stored filter to the fingerprint of the
+// current declarations detects whether revalidation can have a different result than when the filter was stored.
+func Revalidate(stored StoredFilter, declarations *Declarations) (Filter, error) {
+	if stored.Filter.CheckedExpr == nil {
+		return Filter{}, nil
+	}
+	// The checker rewrites the checked expression, so the stored filter is left intact.
+	checkedExpr := proto.Clone(stored.Filter.CheckedExpr).(*expr.CheckedExpr)
+	var checker Checker
+	checker.Init(checkedExpr.GetExpr(), checkedExpr.GetSourceInfo(), declarations)
+	result, err := checker.Check()
+	if err != nil {
+		return Filter{}, &RevalidationError{
+			InvalidIdents: invalidIdents(stored.Filter.CheckedExpr, declarations),
+			err:           err,
+		}
+	}
+	return Filter{
+		CheckedExpr: result,
+		Warnings:    checker.Warnings(),
+	}, nil
+}
+
+// invalidIdents returns the names of the idents referenced by the checked expression that are undeclared, or
+// declared with other types or values, in the provided declarations.
+func invalidIdents(checkedExpr *expr.CheckedExpr, declarations *Declarations) []string {
+	invalid := map[string]bool{}
+	Walk(func(e, _ *expr.Expr) bool {
+		reference, ok := checkedExpr.GetReferenceMap()[e.GetId()]
+		if !ok || reference.GetName() == "" {
+			return true
+		}
+		ident, ok := declarations.LookupIdent(reference.GetName())
+		if !ok ||
+			!proto.Equal(ident.GetIdent().GetType(), checkedExpr.GetTypeMap()[e.GetId()]) ||
+			!proto.Equal(ident.GetIdent().GetValue(), reference.GetValue()) {
+			invalid[reference.GetName()] = true
+		}
+		// Referenced qualified names, such as `origin.display_name`, are checked as a whole.
+		return false
+	}, checkedExpr.GetExpr())
+	result := make([]string, 0, len(invalid))
+	for name := range invalid {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Fingerprint returns a fingerprint of the declarations, which changes when the declarations change in a way that
+// can change the result of type-checking a filter.
+//
+// The fingerprint covers idents, functions, enum types, the filterable fields of message types, aliases and
+// deprecations, but not documentation.
+func (d *Declarations) Fingerprint() string {
+	h := sha256.New()
+	write := func(kind string, parts ...string) {
+		for _, part := range append([]string{kind}, parts...) {
+			var length [binary.MaxVarintLen64]byte
+			_, _ = h.Write(length[:binary.PutUvarint(length[:], uint64(len(part)))])
+			_, _ = h.Write([]byte(part))
+		}
+	}
+	for _, decl := range d.Idents() {
+		decl = proto.Clone(decl).(*expr.Decl)
+		decl.GetIdent().Doc = ""
+		write("ident", fingerprintProto(decl))
+	}
+	for _, decl := range d.Functions() {
+		decl = proto.Clone(decl).(*expr.Decl)
+		for _, overload := range decl.GetFunction().GetOverloads() {
+			overload.Doc = ""
+		}
+		write("function", fingerprintProto(decl))
+	}
+	for _, enumType := range d.EnumTypes() {
+		values := enumType.Descriptor().Values()
+		for i := 0; i < values.Len(); i++ {
+			write("enum", string(enumType.Descriptor().FullName()), string(values.Get(i).Name()))
+		}
+	}
+	for _, message := range d.Messages() {
+		fields := message.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			if t, ok := fieldType(fields.Get(i)); ok {
+				write("field", string(message.FullName()), string(fields.Get(i).Name()), fingerprintProto(t))
+			}
+		}
+	}
+	for _, alias := range sortedKeys(d.aliases) {
+		write("alias", alias, d.aliases[alias])
+	}
+	for _, name := range sortedKeys(d.deprecations) {
+		write("deprecation", name, d.deprecations[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// fingerprintProto returns the deterministic binary encoding of the provided message.
+func fingerprintProto(m proto.Message) string {
+	// Declarations are constructed from valid messages, which always marshal.
+	data, _ := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	return string(data)
+}
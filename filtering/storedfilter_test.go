@@ -0,0 +1,191 @@
+package filtering
+
+import (
+	"errors"
+	"testing"
+
+	syntaxv1 "go.einride.tech/aip/proto/gen/einride/example/syntax/v1"
+	"gotest.tools/v3/assert"
+)
+
+func TestRevalidate(t *testing.T) {
+	t.Parallel()
+	const filter = `name = "shippers/1" AND create_time > "2022-08-12T22:22:22Z" AND enum = ENUM_ONE AND site:*`
+	declarations, err := NewDeclarations(
+		DeclareStandardFunctions(),
+		DeclareIdent("name", TypeString),
+		DeclareIdent("create_time", TypeTimestamp),
+		DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+		DeclareIdent("site_name", TypeString),
+		DeclareAlias("site", "site_name"),
+	)
+	assert.NilError(t, err)
+	parsedFilter, err := ParseFilter(&mockRequest{filter: filter}, declarations)
+	assert.NilError(t, err)
+	data, err := MarshalFilter(parsedFilter, declarations)
+	assert.NilError(t, err)
+	stored, err := UnmarshalFilter(data)
+	assert.NilError(t, err)
+	assert.Equal(t, declarations.Fingerprint(), stored.Fingerprint)
+	for _, tt := range []struct {
+		name                  string
+		declarations          []DeclarationOption
+		expectedWarnings      []string
+		expectedInvalidIdents []string
+		errorContains         string
+	}{
+		{
+			name: "unchanged",
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("name", TypeString),
+				DeclareIdent("create_time", TypeTimestamp),
+				DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+				DeclareIdent("site_name", TypeString),
+			},
+		},
+		{
+			name: "deprecated",
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("name", TypeString),
+				DeclareIdent("create_time", TypeTimestamp),
+				DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+				DeclareIdent("site_name", TypeString),
+				DeprecateIdent("create_time", "use update_time"),
+			},
+			expectedWarnings: []string{"'create_time' is deprecated: use update_time"},
+		},
+		{
+			name: "removed and retyped",
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("create_time", TypeInt),
+				DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+				DeclareIdent("site_name", TypeString),
+			},
+			expectedInvalidIdents: []string{"create_time", "name"},
+			errorContains:         "undeclared identifier 'name'",
+		},
+		{
+			name: "removed alias target",
+			declarations: []DeclarationOption{
+				DeclareStandardFunctions(),
+				DeclareIdent("name", TypeString),
+				DeclareIdent("create_time", TypeTimestamp),
+				DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+			},
+			expectedInvalidIdents: []string{"site_name"},
+			errorContains:         "undeclared identifier 'site_name'",
+		},
+		{
+			name: "removed function",
+			declarations: []DeclarationOption{
+				DeclareIdent("name", TypeString),
+				DeclareIdent("create_time", TypeTimestamp),
+				DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+				DeclareIdent("site_name", TypeString),
+			},
+			expectedInvalidIdents: []string{},
+			errorContains:         "no matching overload found for calling '='",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			declarations, err := NewDeclarations(tt.declarations...)
+			assert.NilError(t, err)
+			actual, err := Revalidate(stored, declarations)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				var revalidationErr *RevalidationError
+				assert.Assert(t, errors.As(err, &revalidationErr))
+				assert.DeepEqual(t, tt.expectedInvalidIdents, revalidationErr.InvalidIdents)
+				return
+			}
+			assert.NilError(t, err)
+			unparsed, err := Unparse(actual.CheckedExpr.GetExpr())
+			assert.NilError(t, err)
+			assert.Equal(
+				t,
+				`name = "shippers/1" AND create_time > "2022-08-12T22:22:22Z" AND enum = ENUM_ONE AND site_name:*`,
+				unparsed,
+			)
+			var warnings []string
+			for _, warning := range actual.Warnings {
+				warnings = append(warnings, warning.Message)
+			}
+			assert.DeepEqual(t, tt.expectedWarnings, warnings)
+		})
+	}
+}
+
+func TestUnmarshalFilter(t *testing.T) {
+	t.Parallel()
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		declarations, err := NewDeclarations(DeclareStandardFunctions())
+		assert.NilError(t, err)
+		data, err := MarshalFilter(Filter{}, declarations)
+		assert.NilError(t, err)
+		stored, err := UnmarshalFilter(data)
+		assert.NilError(t, err)
+		assert.Assert(t, stored.Filter.CheckedExpr == nil)
+		actual, err := Revalidate(stored, declarations)
+		assert.NilError(t, err)
+		assert.Assert(t, actual.CheckedExpr == nil)
+	})
+	t.Run("unsupported version", func(t *testing.T) {
+		t.Parallel()
+		_, err := UnmarshalFilter([]byte(`{"version":2,"fingerprint":""}`))
+		assert.ErrorContains(t, err, "unsupported version 2")
+	})
+	t.Run("invalid checked expr", func(t *testing.T) {
+		t.Parallel()
+		_, err := UnmarshalFilter([]byte(`{"version":1,"fingerprint":"","checked_expr":{"foo":1}}`))
+		assert.ErrorContains(t, err, "unmarshal filter")
+	})
+}
+
+func TestDeclarations_Fingerprint(t *testing.T) {
+	t.Parallel()
+	newFingerprint := func(t *testing.T, opts ...DeclarationOption) string {
+		t.Helper()
+		declarations, err := NewDeclarations(opts...)
+		assert.NilError(t, err)
+		return declarations.Fingerprint()
+	}
+	expected := newFingerprint(
+		t,
+		DeclareStandardFunctions(),
+		DeclareIdent("name", TypeString),
+		DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+	)
+	assert.Equal(t, expected, newFingerprint(
+		t,
+		DeclareStandardFunctions(),
+		DeclareIdent("name", TypeString),
+		DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+		DescribeIdent("name", "The resource name."),
+	))
+	assert.Assert(t, expected != newFingerprint(
+		t,
+		DeclareStandardFunctions(),
+		DeclareIdent("name", TypeInt),
+		DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+	))
+	assert.Assert(t, expected != newFingerprint(
+		t,
+		DeclareStandardFunctions(),
+		DeclareIdent("name", TypeString),
+		DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+		DeclareAlias("display_name", "name"),
+	))
+	assert.Assert(t, expected != newFingerprint(
+		t,
+		DeclareStandardFunctions(),
+		DeclareIdent("name", TypeString),
+		DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+		DeprecateIdent("name", "use display_name"),
+	))
+}
@@ -0,0 +1,56 @@
+package filtering
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// callStringFunction calls the string function overload with the provided ID on the provided argument values.
+//
+// String predicates are false for null strings, and the lowercase of a null string is null.
+func callStringFunction(overloadID string, args []interface{}) (interface{}, error) {
+	if overloadID == FunctionOverloadLowerString {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s: expected 1 arg but got %d", FunctionLower, len(args))
+		}
+		if args[0] == nil {
+			return nil, nil
+		}
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected string but got %T", FunctionLower, args[0])
+		}
+		return strings.ToLower(s), nil
+	}
+	if len(args) != 2 {
+		return nil, fmt.Errorf("%s: expected 2 args but got %d", overloadID, len(args))
+	}
+	if args[0] == nil {
+		return false, nil
+	}
+	s, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("%s: expected string but got %T", overloadID, args[0])
+	}
+	arg, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("%s: expected string but got %T", overloadID, args[1])
+	}
+	switch overloadID {
+	case FunctionOverloadMatchesString:
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", FunctionMatches, err)
+		}
+		return re.MatchString(s), nil
+	case FunctionOverloadStartsWithString:
+		return strings.HasPrefix(s, arg), nil
+	case FunctionOverloadEndsWithString:
+		return strings.HasSuffix(s, arg), nil
+	case FunctionOverloadContainsString:
+		return strings.Contains(s, arg), nil
+	default:
+		return nil, fmt.Errorf("no implementation of overload '%s'", overloadID)
+	}
+}
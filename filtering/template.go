@@ -0,0 +1,309 @@
+package filtering
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Template is a parsed and type-checked filter template with parameters, such as
+// `create_time > @since AND shipper = @shipper`.
+//
+// Parameters are bound to Go values with Bind, which produces a type-checked Filter without formatting the values
+// into the filter string.
+type Template struct {
+	template     string
+	parsedExpr   *expr.ParsedExpr
+	declarations *Declarations
+	// params are the types of the parameters used in the template, by name.
+	params map[string]*expr.Type
+}
+
+// ParseTemplate parses and type-checks the provided filter template.
+//
+// Parameters are written as `@name`, and must be declared with DeclareParam in the provided declarations. Parameters
+// are checked against their declared types, so that type errors in the template are reported when parsing it rather
+// than when binding it.
+func ParseTemplate(template string, declarations *Declarations, opts ...ParserOption) (_ *Template, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("parse template: %w", err)
+		}
+	}()
+	result := &Template{
+		template:     template,
+		declarations: declarations,
+		params:       make(map[string]*expr.Type),
+	}
+	if template == "" {
+		return result, nil
+	}
+	var parser Parser
+	parser.Init(template, opts...)
+	parsedExpr, err := parser.Parse()
+	if err != nil {
+		return nil, err
+	}
+	Walk(func(e, _ *expr.Expr) bool {
+		if name, ok := paramName(e); ok && err == nil {
+			t, ok := declarations.LookupParam(name)
+			if !ok {
+				err = fmt.Errorf("undeclared parameter '@%s'", name)
+				return false
+			}
+			result.params[name] = t
+		}
+		return err == nil
+	}, parsedExpr.GetExpr())
+	if err != nil {
+		return nil, err
+	}
+	// The checker rewrites the checked expression, so the template keeps the unchecked expression for binding.
+	checkedParsedExpr := proto.Clone(parsedExpr).(*expr.ParsedExpr)
+	var checker Checker
+	checker.Init(
+		checkedParsedExpr.GetExpr(),
+		checkedParsedExpr.GetSourceInfo(),
+		declarations.withParams(),
+		WithFilter(template),
+	)
+	if _, err := checker.Check(); err != nil {
+		return nil, err
+	}
+	result.parsedExpr = parsedExpr
+	return result, nil
+}
+
+// Params returns the names of the parameters used in the template, without the @ prefix.
+func (t *Template) Params() []string {
+	result := make([]string, 0, len(t.params))
+	for name := range t.params {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Bind binds the provided values to the parameters of the template, and returns the type-checked filter.
+//
+// All parameters used in the template must be bound. Values are bound by the declared type of their parameter:
+//   - bool values to bool parameters
+//   - integer values to int parameters
+//   - integer and floating-point values to float parameters
+//   - string values to string parameters
+//   - time.Time and *timestamppb.Timestamp values to timestamp parameters
+//   - time.Duration and *durationpb.Duration values to duration parameters
+//   - protoreflect.Enum values and enum value names to enum parameters
+//
+// Wrapper parameters accept the values of their primitive type, and nil, which binds to null.
+func (t *Template) Bind(values map[string]interface{}) (_ Filter, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("bind template: %w", err)
+		}
+	}()
+	for name := range values {
+		if _, ok := t.params[name]; !ok {
+			return Filter{}, fmt.Errorf("unknown parameter '@%s'", name)
+		}
+	}
+	if t.parsedExpr == nil {
+		return Filter{}, nil
+	}
+	parsedExpr := proto.Clone(t.parsedExpr).(*expr.ParsedExpr)
+	nextID := MaxID(parsedExpr.GetExpr()) + 1
+	Walk(func(e, parent *expr.Expr) bool {
+		name, ok := paramName(e)
+		if !ok || err != nil {
+			return err == nil
+		}
+		value, ok := values[name]
+		if !ok {
+			err = fmt.Errorf("missing value of parameter '@%s'", name)
+			return false
+		}
+		bound, bindErr := t.bindValue(t.params[name], value)
+		if bindErr != nil {
+			err = fmt.Errorf("parameter '@%s': %w", name, bindErr)
+			return false
+		}
+		bound = bindLiteral(bound, e, parent)
+		// Bound values replace the parameter in place, and nested exprs of bound values, such as the args of
+		// timestamp calls, get new IDs at the position of the parameter.
+		e.ExprKind = bound.GetExprKind()
+		position, hasPosition := parsedExpr.GetSourceInfo().GetPositions()[e.GetId()]
+		Walk(func(child, parent *expr.Expr) bool {
+			if parent != nil {
+				child.Id = nextID
+				nextID++
+				if hasPosition {
+					parsedExpr.GetSourceInfo().GetPositions()[child.GetId()] = position
+				}
+			}
+			return true
+		}, e)
+		return false
+	}, parsedExpr.GetExpr())
+	if err != nil {
+		return Filter{}, err
+	}
+	var checker Checker
+	checker.Init(parsedExpr.GetExpr(), parsedExpr.GetSourceInfo(), t.declarations, WithFilter(t.template))
+	checkedExpr, err := checker.Check()
+	if err != nil {
+		return Filter{}, err
+	}
+	return Filter{
+		CheckedExpr: checkedExpr,
+		Warnings:    checker.Warnings(),
+	}, nil
+}
+
+// bindValue returns a constant expression of the provided value, for a parameter of the provided type.
+func (t *Template) bindValue(paramType *expr.Type, value interface{}) (*expr.Expr, error) {
+	switch {
+	case paramType.GetWrapper() != expr.Type_PRIMITIVE_TYPE_UNSPECIFIED:
+		if value == nil {
+			return Null(), nil
+		}
+		return bindPrimitive(paramType.GetWrapper(), value)
+	case paramType.GetPrimitive() != expr.Type_PRIMITIVE_TYPE_UNSPECIFIED:
+		return bindPrimitive(paramType.GetPrimitive(), value)
+	case paramType.GetWellKnown() == expr.Type_TIMESTAMP:
+		switch value := value.(type) {
+		case time.Time:
+			return Function(FunctionTimestamp, String(value.UTC().Format(time.RFC3339Nano))), nil
+		case *timestamppb.Timestamp:
+			if err := value.CheckValid(); err != nil {
+				return nil, err
+			}
+			return Function(FunctionTimestamp, String(value.AsTime().Format(time.RFC3339Nano))), nil
+		}
+	case paramType.GetWellKnown() == expr.Type_DURATION:
+		switch value := value.(type) {
+		case time.Duration:
+			return Duration(value), nil
+		case *durationpb.Duration:
+			if err := value.CheckValid(); err != nil {
+				return nil, err
+			}
+			return Duration(value.AsDuration()), nil
+		}
+	case paramType.GetMessageType() != "":
+		if enumType, ok := t.declarations.enumTypes[paramType.GetMessageType()]; ok {
+			return bindEnum(enumType.Descriptor(), value)
+		}
+		return nil, fmt.Errorf("unsupported parameter type %s", paramType.GetMessageType())
+	default:
+		return nil, fmt.Errorf("unsupported parameter type %v", paramType)
+	}
+	return nil, fmt.Errorf(
+		"unsupported value of type %T for %s parameter", value, strings.ToLower(paramType.GetWellKnown().String()),
+	)
+}
+
+// bindPrimitive returns a constant expression of the provided value, for a parameter of the provided primitive type.
+func bindPrimitive(primitive expr.Type_PrimitiveType, value interface{}) (*expr.Expr, error) {
+	v := reflect.ValueOf(value)
+	switch primitive {
+	case expr.Type_BOOL:
+		if v.Kind() == reflect.Bool {
+			return Bool(v.Bool()), nil
+		}
+	case expr.Type_INT64:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return Int(v.Int()), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if v.Uint() > math.MaxInt64 {
+				return nil, fmt.Errorf("value %d overflows int64", v.Uint())
+			}
+			return Int(int64(v.Uint())), nil
+		}
+	case expr.Type_DOUBLE:
+		switch v.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return Float(v.Float()), nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return Float(float64(v.Int())), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return Float(float64(v.Uint())), nil
+		}
+	case expr.Type_STRING:
+		if v.Kind() == reflect.String {
+			return String(v.String()), nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported value of type %T for %s parameter", value, strings.ToLower(primitive.String()))
+}
+
+// bindLiteral returns the bound value of the provided parameter, escaped so that bound strings on the right-hand side
+// of `=` and `!=` are compared literally rather than as wildcard patterns, as in `name = "shippers/*"`.
+func bindLiteral(bound, param, parent *expr.Expr) *expr.Expr {
+	constExpr, ok := bound.GetConstExpr().GetConstantKind().(*expr.Constant_StringValue)
+	callExpr := parent.GetCallExpr()
+	if !ok || len(callExpr.GetArgs()) != 2 || callExpr.GetArgs()[1] != param {
+		return bound
+	}
+	switch callExpr.GetFunction() {
+	case FunctionEquals, FunctionNotEquals:
+		return String(escapeWildcard(constExpr.StringValue))
+	}
+	return bound
+}
+
+// bindEnum returns an expression of the enum value constant of the provided value, which is either an enum value or
+// the name of an enum value.
+func bindEnum(enum protoreflect.EnumDescriptor, value interface{}) (*expr.Expr, error) {
+	var enumValue protoreflect.EnumValueDescriptor
+	switch value := value.(type) {
+	case protoreflect.Enum:
+		if value.Descriptor().FullName() != enum.FullName() {
+			return nil, fmt.Errorf("expected enum %s but got %s", enum.FullName(), value.Descriptor().FullName())
+		}
+		enumValue = enum.Values().ByNumber(value.Number())
+		if enumValue == nil {
+			return nil, fmt.Errorf("unknown number %d of enum %s", value.Number(), enum.FullName())
+		}
+	case string:
+		enumValue = enum.Values().ByName(protoreflect.Name(value))
+		if enumValue == nil {
+			return nil, fmt.Errorf("unknown value '%s' of enum %s", value, enum.FullName())
+		}
+	default:
+		return nil, fmt.Errorf("unsupported value of type %T for enum parameter", value)
+	}
+	// Enum values are declared as constants of the enum type, by value name.
+	return Text(string(enumValue.Name())), nil
+}
+
+// paramName returns the name of the template parameter of the provided expression, if it is a parameter.
+func paramName(e *expr.Expr) (string, bool) {
+	name := e.GetIdentExpr().GetName()
+	if !strings.HasPrefix(name, "@") {
+		return "", false
+	}
+	return strings.TrimPrefix(name, "@"), true
+}
+
+// withParams returns a copy of the declarations where the template parameters are declared as `@name` idents.
+func (d *Declarations) withParams() *Declarations {
+	result := *d
+	result.idents = make(map[string]*expr.Decl, len(d.idents)+len(d.params))
+	for name, ident := range d.idents {
+		result.idents[name] = ident
+	}
+	for name, t := range d.params {
+		result.idents["@"+name] = NewIdentDeclaration("@"+name, t)
+	}
+	return &result
+}
@@ -0,0 +1,193 @@
+package filtering
+
+import (
+	"testing"
+	"time"
+
+	freightv1 "go.einride.tech/aip/proto/gen/einride/example/freight/v1"
+	syntaxv1 "go.einride.tech/aip/proto/gen/einride/example/syntax/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gotest.tools/v3/assert"
+)
+
+func TestTemplate(t *testing.T) {
+	t.Parallel()
+	declarations, err := NewDeclarations(
+		DeclareStandardFunctions(),
+		DeclareMessageFields((&freightv1.Shipment{}).ProtoReflect().Descriptor()),
+		DeclareEnumIdent("enum", syntaxv1.Enum(0).Type()),
+		DeclareIdent("weight", TypeIntWrapper),
+		DeclareIdent("score", TypeFloat),
+		DeclareIdent("archived", TypeBool),
+		DeclareIdent("timeout", TypeDuration),
+		DeclareParam("since", TypeTimestamp),
+		DeclareParam("origin", TypeString),
+		DeclareParam("ttl", TypeDuration),
+		DeclareParam("enum", TypeEnum(syntaxv1.Enum(0).Type())),
+		DeclareParam("weight", TypeIntWrapper),
+		DeclareParam("score", TypeFloat),
+		DeclareParam("archived", TypeBool),
+	)
+	assert.NilError(t, err)
+	for _, tt := range []struct {
+		template      string
+		values        map[string]interface{}
+		expected      string
+		errorContains string
+	}{
+		{
+			template: `create_time > @since AND origin_site = @origin`,
+			values: map[string]interface{}{
+				"since":  time.Date(2022, 8, 12, 22, 22, 22, 500, time.UTC),
+				"origin": "shippers/1/sites/1",
+			},
+			expected: `create_time > timestamp("2022-08-12T22:22:22.0000005Z") AND origin_site = "shippers/1/sites/1"`,
+		},
+		{
+			template: `create_time > @since`,
+			values: map[string]interface{}{
+				"since": timestamppb.New(time.Date(2022, 8, 12, 22, 22, 22, 0, time.UTC)),
+			},
+			expected: `create_time > timestamp("2022-08-12T22:22:22Z")`,
+		},
+		{
+			template: `origin_site = @origin OR external_reference_id = @origin`,
+			values:   map[string]interface{}{"origin": `" OR name:*`},
+			expected: `origin_site = '" OR name:\*' OR external_reference_id = '" OR name:\*'`,
+		},
+		{
+			template: `origin_site = @origin AND external_reference_id != @origin`,
+			values:   map[string]interface{}{"origin": `shippers/*\`},
+			expected: `origin_site = "shippers/\*\\" AND external_reference_id != "shippers/\*\\"`,
+		},
+		{
+			template: `timeout < @ttl`,
+			values:   map[string]interface{}{"ttl": 90 * time.Minute},
+			expected: `timeout < duration("1h30m0s")`,
+		},
+		{
+			template: `enum = @enum AND weight != @weight AND score > @score AND archived = @archived`,
+			values: map[string]interface{}{
+				"enum":     syntaxv1.Enum_ENUM_ONE,
+				"weight":   nil,
+				"score":    2,
+				"archived": false,
+			},
+			expected: `enum = ENUM_ONE AND weight != null AND score > 2.0 AND archived = false`,
+		},
+		{
+			template: `enum = @enum AND weight = @weight`,
+			values:   map[string]interface{}{"enum": "ENUM_TWO", "weight": uint32(100)},
+			expected: `enum = ENUM_TWO AND weight = 100`,
+		},
+		{
+			template:      `enum = @enum`,
+			values:        map[string]interface{}{"enum": "ENUM_THREE"},
+			errorContains: "parameter '@enum': unknown value 'ENUM_THREE' of enum einride.example.syntax.v1.Enum",
+		},
+		{
+			template:      `create_time > @since`,
+			values:        map[string]interface{}{"since": "2022-08-12T22:22:22Z"},
+			errorContains: "parameter '@since': unsupported value of type string for timestamp parameter",
+		},
+		{
+			template:      `origin_site = @origin`,
+			values:        map[string]interface{}{"origin": 42},
+			errorContains: "parameter '@origin': unsupported value of type int for string parameter",
+		},
+		{
+			template:      `score > @score`,
+			values:        map[string]interface{}{"score": nil},
+			errorContains: "parameter '@score': unsupported value of type <nil> for double parameter",
+		},
+		{
+			template:      `create_time > @since AND origin_site = @origin`,
+			values:        map[string]interface{}{"since": time.Now()},
+			errorContains: "missing value of parameter '@origin'",
+		},
+		{
+			template:      `origin_site = @origin`,
+			values:        map[string]interface{}{"origin": "shippers/1/sites/1", "since": time.Now()},
+			errorContains: "unknown parameter '@since'",
+		},
+		{
+			template:      `origin_site = @destination`,
+			errorContains: "undeclared parameter '@destination'",
+		},
+		{
+			template:      `origin_site = @since`,
+			errorContains: "no matching overload found for calling '='",
+		},
+		{
+			template:      `origin_site = @origin`,
+			errorContains: "missing value of parameter '@origin'",
+		},
+	} {
+		tt := tt
+		t.Run(tt.template, func(t *testing.T) {
+			t.Parallel()
+			var filter Filter
+			template, err := ParseTemplate(tt.template, declarations)
+			if err == nil {
+				filter, err = template.Bind(tt.values)
+			}
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			assert.NilError(t, err)
+			actual, err := Unparse(filter.CheckedExpr.GetExpr())
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestTemplate_wildcard(t *testing.T) {
+	t.Parallel()
+	declarations, err := NewDeclarations(
+		DeclareStandardFunctions(),
+		DeclareMessageFields((&freightv1.Shipment{}).ProtoReflect().Descriptor()),
+		DeclareParam("name", TypeString),
+	)
+	assert.NilError(t, err)
+	template, err := ParseTemplate(`name = @name`, declarations)
+	assert.NilError(t, err)
+	filter, err := template.Bind(map[string]interface{}{"name": "shippers/*"})
+	assert.NilError(t, err)
+	predicate, err := Compile(filter, (&freightv1.Shipment{}).ProtoReflect().Descriptor())
+	assert.NilError(t, err)
+	for _, tt := range []struct {
+		name     string
+		expected bool
+	}{
+		{name: "shippers/*", expected: true},
+		{name: "shippers/1", expected: false},
+	} {
+		shipment := &freightv1.Shipment{Name: tt.name}
+		actual, err := Evaluate(filter, shipment)
+		assert.NilError(t, err)
+		assert.Equal(t, tt.expected, actual, tt.name)
+		compiledActual, err := predicate(shipment)
+		assert.NilError(t, err)
+		assert.Equal(t, tt.expected, compiledActual, tt.name)
+	}
+}
+
+func TestTemplate_Params(t *testing.T) {
+	t.Parallel()
+	declarations, err := NewDeclarations(
+		DeclareStandardFunctions(),
+		DeclareIdent("origin_site", TypeString),
+		DeclareIdent("destination_site", TypeString),
+		DeclareParam("site", TypeString),
+		DeclareParam("unused", TypeString),
+	)
+	assert.NilError(t, err)
+	template, err := ParseTemplate(`origin_site = @site OR destination_site = @site`, declarations)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"site"}, template.Params())
+	// Parameters are only resolved in templates.
+	_, err = ParseFilter(&mockRequest{filter: `origin_site = @site`}, declarations)
+	assert.ErrorContains(t, err, "undeclared identifier '@site'")
+}
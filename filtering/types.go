@@ -3,6 +3,8 @@ package filtering
 import (
 	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 // Primitive types.
@@ -38,6 +40,15 @@ func TypeList(elementType *expr.Type) *expr.Type {
 	}
 }
 
+// TypeParam returns the type for the type parameter with the provided name.
+func TypeParam(name string) *expr.Type {
+	return &expr.Type{
+		TypeKind: &expr.Type_TypeParam{
+			TypeParam: name,
+		},
+	}
+}
+
 // TypeEnum returns the type of a protobuf enum.
 func TypeEnum(enumType protoreflect.EnumType) *expr.Type {
 	return &expr.Type{
@@ -47,6 +58,15 @@ func TypeEnum(enumType protoreflect.EnumType) *expr.Type {
 	}
 }
 
+// TypeMessage returns the type of a protobuf message.
+func TypeMessage(message protoreflect.MessageDescriptor) *expr.Type {
+	return &expr.Type{
+		TypeKind: &expr.Type_MessageType{
+			MessageType: string(message.FullName()),
+		},
+	}
+}
+
 // Well-known types.
 //
 //nolint:gochecknoglobals
@@ -54,3 +74,31 @@ var (
 	TypeDuration  = &expr.Type{TypeKind: &expr.Type_WellKnown{WellKnown: expr.Type_DURATION}}
 	TypeTimestamp = &expr.Type{TypeKind: &expr.Type_WellKnown{WellKnown: expr.Type_TIMESTAMP}}
 )
+
+// Wrapper types, of the wrapper messages google.protobuf.Int64Value, DoubleValue, StringValue, BoolValue and their
+// narrower variants.
+//
+// Wrapper values are null when unset. They can be passed where the wrapped primitive type is expected, as in
+// `weight > 100`, and compared with null, as in `weight = null`.
+//
+//nolint:gochecknoglobals
+var (
+	TypeIntWrapper    = &expr.Type{TypeKind: &expr.Type_Wrapper{Wrapper: expr.Type_INT64}}
+	TypeFloatWrapper  = &expr.Type{TypeKind: &expr.Type_Wrapper{Wrapper: expr.Type_DOUBLE}}
+	TypeStringWrapper = &expr.Type{TypeKind: &expr.Type_Wrapper{Wrapper: expr.Type_STRING}}
+	TypeBoolWrapper   = &expr.Type{TypeKind: &expr.Type_Wrapper{Wrapper: expr.Type_BOOL}}
+)
+
+// Dynamic types.
+//
+//nolint:gochecknoglobals
+var (
+	// TypeDyn is the type of values with a type that is only known at evaluation time, such as the values of
+	// google.protobuf.Value fields. Dyn values can be compared with values of any type.
+	TypeDyn = &expr.Type{TypeKind: &expr.Type_Dyn{Dyn: &emptypb.Empty{}}}
+	// TypeNull is the type of the null literal.
+	TypeNull = &expr.Type{TypeKind: &expr.Type_Null{Null: structpb.NullValue_NULL_VALUE}}
+	// TypeStruct is the type of google.protobuf.Struct fields, a map from string keys to dyn values, which allows
+	// selecting nested values as in `metadata.customer.tier`.
+	TypeStruct = TypeMap(TypeString, TypeDyn)
+)
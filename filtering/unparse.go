@@ -0,0 +1,332 @@
+package filtering
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+)
+
+// Unparse converts the provided expression into a canonical filter string.
+//
+// The filter string uses minimal parentheses and single spaces between tokens. Unparsing is stable through the
+// Parser: parsing the returned filter string and unparsing the result yields the same filter string.
+//
+// Checked expressions unparse in the form normalized by the Checker, where receiver-style calls such as
+// `s.matches("^h")` unparse as `matches(s, "^h")`, which checks to the same expression.
+//
+// An error is returned for expressions that can not be represented in the filter syntax, such as identifiers that
+// are not valid text tokens and string constants containing both single and double quotes.
+func Unparse(e *expr.Expr) (string, error) {
+	var u unparser
+	if err := u.unparse(e, precedenceExpression); err != nil {
+		return "", fmt.Errorf("unparse: %w", err)
+	}
+	return u.result.String(), nil
+}
+
+// precedence is the grammar level of an expression, from loosest to tightest binding.
+type precedence int
+
+const (
+	precedenceExpression precedence = iota
+	precedenceSequence
+	precedenceFactor
+	precedenceTerm
+	precedenceRestriction
+	precedenceArg
+	precedenceComparable
+)
+
+type unparser struct {
+	result strings.Builder
+}
+
+func (u *unparser) unparse(e *expr.Expr, required precedence) error {
+	p, err := exprPrecedence(e)
+	if err != nil {
+		return err
+	}
+	if p < required {
+		if required == precedenceComparable {
+			return fmt.Errorf("expression with id %d can not be used as a comparable", e.GetId())
+		}
+		_ = u.result.WriteByte('(')
+		if err := u.unparse(e, precedenceExpression); err != nil {
+			return err
+		}
+		_ = u.result.WriteByte(')')
+		return nil
+	}
+	switch kind := e.GetExprKind().(type) {
+	case *expr.Expr_ConstExpr:
+		return u.unparseConstant(kind.ConstExpr)
+	case *expr.Expr_IdentExpr:
+		return u.unparseIdent(kind.IdentExpr.GetName())
+	case *expr.Expr_SelectExpr:
+		return u.unparseSelect(kind.SelectExpr)
+	case *expr.Expr_CallExpr:
+		return u.unparseCall(kind.CallExpr)
+	default:
+		return fmt.Errorf("unsupported expr kind %T", kind)
+	}
+}
+
+func (u *unparser) unparseCall(call *expr.Expr_Call) error {
+	switch call.GetFunction() {
+	case FunctionAnd:
+		return u.unparseJunction(call, " AND ", precedenceSequence)
+	case FunctionFuzzyAnd:
+		return u.unparseJunction(call, " ", precedenceFactor)
+	case FunctionOr:
+		return u.unparseJunction(call, " OR ", precedenceTerm)
+	case FunctionNot:
+		_, _ = u.result.WriteString("NOT ")
+		return u.unparse(call.GetArgs()[0], precedenceRestriction)
+	case FunctionEquals,
+		FunctionNotEquals,
+		FunctionLessThan,
+		FunctionLessEquals,
+		FunctionGreaterThan,
+		FunctionGreaterEquals:
+		return u.unparseRestriction(call, " "+call.GetFunction()+" ")
+	case FunctionHas:
+		if isPresenceWildcard(call.GetArgs()[1]) {
+			if err := u.unparse(call.GetArgs()[0], precedenceComparable); err != nil {
+				return err
+			}
+			_, _ = u.result.WriteString(FunctionHas + PresenceWildcard)
+			return nil
+		}
+		return u.unparseRestriction(call, call.GetFunction())
+	}
+	if err := u.unparseName(call.GetFunction()); err != nil {
+		return err
+	}
+	_ = u.result.WriteByte('(')
+	for i, arg := range call.GetArgs() {
+		if i > 0 {
+			_, _ = u.result.WriteString(", ")
+		}
+		if err := u.unparse(arg, precedenceArg); err != nil {
+			return err
+		}
+	}
+	_ = u.result.WriteByte(')')
+	return nil
+}
+
+func (u *unparser) unparseJunction(call *expr.Expr_Call, separator string, required precedence) error {
+	for i, arg := range call.GetArgs() {
+		if i > 0 {
+			_, _ = u.result.WriteString(separator)
+		}
+		// Junctions are associative, so nested junctions of the same function need no parentheses.
+		if arg.GetCallExpr().GetFunction() == call.GetFunction() && len(arg.GetCallExpr().GetArgs()) >= 2 {
+			if err := u.unparseJunction(arg.GetCallExpr(), separator, required); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := u.unparse(arg, required); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *unparser) unparseRestriction(call *expr.Expr_Call, comparator string) error {
+	if err := u.unparse(call.GetArgs()[0], precedenceComparable); err != nil {
+		return err
+	}
+	_, _ = u.result.WriteString(comparator)
+	return u.unparse(call.GetArgs()[1], precedenceArg)
+}
+
+func (u *unparser) unparseSelect(selectExpr *expr.Expr_Select) error {
+	switch operand := selectExpr.GetOperand(); operand.GetExprKind().(type) {
+	case *expr.Expr_IdentExpr:
+		if err := u.unparseMemberValue(operand.GetIdentExpr().GetName()); err != nil {
+			return err
+		}
+	case *expr.Expr_SelectExpr:
+		if err := u.unparseSelect(operand.GetSelectExpr()); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported select operand with id %d", operand.GetId())
+	}
+	_ = u.result.WriteByte('.')
+	return u.unparseField(selectExpr.GetField())
+}
+
+// unparseIdent unparses an identifier, where qualified identifiers are unparsed as members.
+func (u *unparser) unparseIdent(name string) error {
+	if !strings.Contains(name, ".") {
+		if !isTextToken(name) {
+			return fmt.Errorf("identifier '%s' is not a valid text token", name)
+		}
+		_, _ = u.result.WriteString(name)
+		return nil
+	}
+	fields := strings.Split(name, ".")
+	if err := u.unparseMemberValue(fields[0]); err != nil {
+		return err
+	}
+	for _, field := range fields[1:] {
+		_ = u.result.WriteByte('.')
+		if err := u.unparseField(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unparseMemberValue unparses the value of a member, which is quoted unless it is a valid text token.
+func (u *unparser) unparseMemberValue(value string) error {
+	if isTextToken(value) {
+		_, _ = u.result.WriteString(value)
+		return nil
+	}
+	return u.unparseString(value)
+}
+
+// unparseField unparses the field of a member, which is quoted unless it is a valid text, keyword or number token.
+func (u *unparser) unparseField(field string) error {
+	if isTextToken(field) || TokenType(field).IsKeyword() || isNumberToken(field) {
+		_, _ = u.result.WriteString(field)
+		return nil
+	}
+	return u.unparseString(field)
+}
+
+func (u *unparser) unparseName(name string) error {
+	for i, part := range strings.Split(name, ".") {
+		if !isTextToken(part) && !TokenType(part).IsKeyword() {
+			return fmt.Errorf("function name '%s' is not a valid name", name)
+		}
+		if i > 0 {
+			_ = u.result.WriteByte('.')
+		}
+		_, _ = u.result.WriteString(part)
+	}
+	return nil
+}
+
+func (u *unparser) unparseConstant(constant *expr.Constant) error {
+	switch kind := constant.GetConstantKind().(type) {
+	case *expr.Constant_StringValue:
+		return u.unparseString(kind.StringValue)
+	case *expr.Constant_Int64Value:
+		if kind.Int64Value == math.MinInt64 {
+			return fmt.Errorf("int constant %d is out of range", kind.Int64Value)
+		}
+		_, _ = u.result.WriteString(strconv.FormatInt(kind.Int64Value, 10))
+	case *expr.Constant_DoubleValue:
+		if math.IsInf(kind.DoubleValue, 0) || math.IsNaN(kind.DoubleValue) {
+			return fmt.Errorf("float constant %v is not finite", kind.DoubleValue)
+		}
+		s := strconv.FormatFloat(kind.DoubleValue, 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		_, _ = u.result.WriteString(s)
+	case *expr.Constant_BoolValue:
+		_, _ = u.result.WriteString(strconv.FormatBool(kind.BoolValue))
+	case *expr.Constant_NullValue:
+		_, _ = u.result.WriteString("null")
+	default:
+		return fmt.Errorf("unsupported constant kind %T", kind)
+	}
+	return nil
+}
+
+// unparseString unparses a quoted string, preferring double quotes since strings have no escape sequences.
+func (u *unparser) unparseString(s string) error {
+	switch {
+	case !strings.Contains(s, `"`):
+		_, _ = u.result.WriteString(`"` + s + `"`)
+	case !strings.Contains(s, `'`):
+		_, _ = u.result.WriteString(`'` + s + `'`)
+	default:
+		return fmt.Errorf("string %q contains both single and double quotes", s)
+	}
+	return nil
+}
+
+// exprPrecedence returns the grammar level of the provided expression.
+func exprPrecedence(e *expr.Expr) (precedence, error) {
+	callExpr := e.GetCallExpr()
+	if callExpr == nil {
+		return precedenceComparable, nil
+	}
+	function, args := callExpr.GetFunction(), len(callExpr.GetArgs())
+	switch function {
+	case FunctionAnd, FunctionFuzzyAnd, FunctionOr:
+		if args < 2 {
+			return 0, fmt.Errorf("%s: expected at least 2 args but got %d", function, args)
+		}
+		switch function {
+		case FunctionAnd:
+			return precedenceExpression, nil
+		case FunctionFuzzyAnd:
+			return precedenceSequence, nil
+		default:
+			return precedenceFactor, nil
+		}
+	case FunctionNot:
+		if args != 1 {
+			return 0, fmt.Errorf("%s: expected 1 arg but got %d", function, args)
+		}
+		return precedenceTerm, nil
+	case FunctionEquals,
+		FunctionNotEquals,
+		FunctionLessThan,
+		FunctionLessEquals,
+		FunctionGreaterThan,
+		FunctionGreaterEquals,
+		FunctionHas:
+		if args != 2 {
+			return 0, fmt.Errorf("%s: expected 2 args but got %d", function, args)
+		}
+		return precedenceRestriction, nil
+	default:
+		return precedenceComparable, nil
+	}
+}
+
+// isTextToken returns true if the provided string is lexed as a single text token.
+func isTextToken(s string) bool {
+	if s == "" || TokenType(s).IsKeyword() {
+		return false
+	}
+	if first := s[0]; first == '"' || first == '\'' || isDigit(first) {
+		return false
+	}
+	for _, r := range s {
+		if !isText(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isNumberToken returns true if the provided string is lexed as a single number token.
+func isNumberToken(s string) bool {
+	if s == "" || !isDigit(s[0]) {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
@@ -0,0 +1,186 @@
+package filtering
+
+import (
+	"testing"
+	"time"
+
+	freightv1 "go.einride.tech/aip/proto/gen/einride/example/freight/v1"
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"gotest.tools/v3/assert"
+)
+
+func TestUnparse(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		filter   string
+		expected string
+	}{
+		{filter: "a", expected: "a"},
+		{filter: "New York Giants OR Yankees", expected: "New York Giants OR Yankees"},
+		{filter: "New York (Giants OR Yankees)", expected: "New York Giants OR Yankees"},
+		{filter: "(a b) AND c", expected: "a b AND c"},
+		{filter: "a (b AND c)", expected: "a (b AND c)"},
+		{filter: "(a OR b) AND (c OR d)", expected: "a OR b AND c OR d"},
+		{filter: "a OR (b c)", expected: "a OR (b c)"},
+		{filter: "NOT (a OR b)", expected: "NOT (a OR b)"},
+		{filter: "-a", expected: "NOT a"},
+		{filter: "NOT a = 1", expected: "NOT a = 1"},
+		{filter: "a < 10 OR a >= 100", expected: "a < 10 OR a >= 100"},
+		{filter: "a   !=   -1.5", expected: "a != -1.5"},
+		{filter: "a = 2.", expected: "a = 2.0"},
+		{filter: "a = 0x10", expected: "a = 16"},
+		{filter: "m : foo", expected: `m:"foo"`},
+		{filter: `m:"foo bar"`, expected: `m:"foo bar"`},
+		{filter: "a.b : * AND NOT c:*", expected: "a.b:* AND NOT c:*"},
+		{filter: `a:"*" AND a:*`, expected: `a:"*" AND a:*`},
+		{filter: `a = 'say "hi"'`, expected: `a = 'say "hi"'`},
+		{filter: `a.b.c = "foo"`, expected: `a.b.c = "foo"`},
+		{filter: `"a b".c.AND.1."d e" = x`, expected: `"a b".c.AND.1."d e" = x`},
+		{filter: `a = (b = c)`, expected: `a = (b = c)`},
+		{filter: `foo.bar(a, (b OR c), 1) AND f()`, expected: `foo.bar(a, (b OR c), 1) AND f()`},
+		{
+			filter:   `create_time > timestamp("2006-01-02T15:04:05Z") AND ttl < duration("1h")`,
+			expected: `create_time > timestamp("2006-01-02T15:04:05Z") AND ttl < duration("1h")`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.filter, func(t *testing.T) {
+			t.Parallel()
+			var parser Parser
+			parser.Init(tt.filter)
+			parsedExpr, err := parser.Parse()
+			assert.NilError(t, err)
+			actual, err := Unparse(parsedExpr.GetExpr())
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expected, actual)
+			parser.Init(actual)
+			reparsedExpr, err := parser.Parse()
+			assert.NilError(t, err)
+			roundTripped, err := Unparse(reparsedExpr.GetExpr())
+			assert.NilError(t, err)
+			assert.Equal(t, actual, roundTripped)
+		})
+	}
+}
+
+func TestUnparse_checked(t *testing.T) {
+	t.Parallel()
+	declarations, err := NewDeclarations(
+		DeclareStandardFunctions(),
+		DeclareStringFunctions(),
+		DeclareIdent("display_name", TypeString),
+		DeclareMessageIdent("site", (&freightv1.Site{}).ProtoReflect().Descriptor()),
+	)
+	assert.NilError(t, err)
+	for _, tt := range []struct {
+		filter   string
+		expected string
+	}{
+		{
+			filter:   `display_name.matches("^ACME")`,
+			expected: `matches(display_name, "^ACME")`,
+		},
+		{
+			filter:   `site.display_name.startsWith("A") OR NOT display_name.endsWith("B")`,
+			expected: `startsWith(site.display_name, "A") OR NOT endsWith(display_name, "B")`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.filter, func(t *testing.T) {
+			t.Parallel()
+			filter, err := ParseFilter(&mockRequest{filter: tt.filter}, declarations)
+			assert.NilError(t, err)
+			actual, err := Unparse(filter.CheckedExpr.GetExpr())
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expected, actual)
+			// Normalized receiver-style calls round-trip through ParseFilter.
+			reparsed, err := ParseFilter(&mockRequest{filter: actual}, declarations)
+			assert.NilError(t, err)
+			reunparsed, err := Unparse(reparsed.CheckedExpr.GetExpr())
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expected, reunparsed)
+		})
+	}
+}
+
+func TestUnparse_constructors(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		name          string
+		expr          *expr.Expr
+		expected      string
+		errorContains string
+	}{
+		{
+			name: "expression",
+			expr: Expression(
+				Sequence(Text("a"), Factor(Text("b"), Text("c"))),
+				Not(Equals(Member(Text("d"), "e"), String("f"))),
+			),
+			expected: `a b OR c AND NOT d.e = "f"`,
+		},
+		{
+			name:     "right-nested and",
+			expr:     And(Text("a"), And(Text("b"), Sequence(Text("c"), Text("d")))),
+			expected: `a AND b AND c d`,
+		},
+		{
+			name:     "qualified ident",
+			expr:     Has(Text("labels.env"), String("prod")),
+			expected: `labels.env:"prod"`,
+		},
+		{
+			name: "constants",
+			expr: And(
+				Equals(Text("a"), Int(-1)),
+				LessThan(Text("b"), Float(3)),
+				GreaterThan(Text("c"), Duration(time.Hour)),
+			),
+			expected: `a = -1 AND b < 3.0 AND c > duration("1h0m0s")`,
+		},
+		{
+			name: "literals",
+			expr: And(
+				Equals(Text("a"), &expr.Expr{ExprKind: &expr.Expr_ConstExpr{ConstExpr: &expr.Constant{
+					ConstantKind: &expr.Constant_NullValue{},
+				}}}),
+				NotEquals(Text("b"), &expr.Expr{ExprKind: &expr.Expr_ConstExpr{ConstExpr: &expr.Constant{
+					ConstantKind: &expr.Constant_BoolValue{BoolValue: true},
+				}}}),
+			),
+			expected: `a = null AND b != true`,
+		},
+		{
+			name:     "not not",
+			expr:     Not(Not(Text("a"))),
+			expected: `NOT (NOT a)`,
+		},
+		{
+			name:          "invalid ident",
+			expr:          Equals(Text("a b"), Int(1)),
+			errorContains: "identifier 'a b' is not a valid text token",
+		},
+		{
+			name:          "both quotes",
+			expr:          Equals(Text("a"), String(`"'`)),
+			errorContains: "contains both single and double quotes",
+		},
+		{
+			name:          "restriction as comparable",
+			expr:          Equals(Equals(Text("a"), Text("b")), Text("c")),
+			errorContains: "can not be used as a comparable",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := Unparse(tt.expr)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
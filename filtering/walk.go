@@ -11,6 +11,20 @@ func Walk(fn WalkFunc, currExpr *expr.Expr) {
 	walk(fn, currExpr, nil)
 }
 
+// MaxID returns the largest ID of an expression and its sub-expressions.
+//
+// New expressions added to an expression can use IDs above MaxID, without conflicting with existing IDs.
+func MaxID(currExpr *expr.Expr) int64 {
+	var result int64
+	Walk(func(e, _ *expr.Expr) bool {
+		if e.GetId() > result {
+			result = e.GetId()
+		}
+		return true
+	}, currExpr)
+	return result
+}
+
 func walk(fn WalkFunc, currExpr, parentExpr *expr.Expr) {
 	if fn == nil || currExpr == nil {
 		return
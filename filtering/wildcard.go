@@ -0,0 +1,72 @@
+package filtering
+
+import "strings"
+
+// IsWildcard returns true if the provided string constant is a wildcard pattern.
+//
+// A wildcard pattern contains at least one unescaped `*`, which matches any sequence of characters. Escaped `\*` match
+// a literal `*` and escaped `\\` match a literal `\`.
+func IsWildcard(s string) bool {
+	return len(SplitWildcard(s)) > 1
+}
+
+// hasWildcardSyntax returns true if the provided string constant contains `*`, escaped or not, and is compared as a
+// wildcard pattern in string equality comparisons. Strings without `*` are compared as-is, including backslashes.
+func hasWildcardSyntax(s string) bool {
+	return strings.ContainsRune(s, '*')
+}
+
+// escapeWildcard escapes the provided string so that it matches itself when compared as a wildcard pattern.
+func escapeWildcard(s string) string {
+	if !hasWildcardSyntax(s) {
+		return s
+	}
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`).Replace(s)
+}
+
+// SplitWildcard splits the provided wildcard pattern into the literal segments between its unescaped `*`.
+//
+// Escaped `\*` and `\\` are unescaped into literal `*` and `\` in the returned segments. Other backslashes are
+// literal. A pattern without unescaped `*` returns a single segment.
+func SplitWildcard(pattern string) []string {
+	var segments []string
+	var segment strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case pattern[i] == '\\' && i+1 < len(pattern) && (pattern[i+1] == '*' || pattern[i+1] == '\\'):
+			_ = segment.WriteByte(pattern[i+1])
+			i++
+		case pattern[i] == '*':
+			segments = append(segments, segment.String())
+			segment.Reset()
+		default:
+			_ = segment.WriteByte(pattern[i])
+		}
+	}
+	return append(segments, segment.String())
+}
+
+// MatchWildcard returns true if the provided string matches the provided wildcard pattern.
+func MatchWildcard(pattern, s string) bool {
+	return matchWildcardSegments(SplitWildcard(pattern), s)
+}
+
+// matchWildcardSegments returns true if the provided string matches the segments of a split wildcard pattern.
+func matchWildcardSegments(segments []string, s string) bool {
+	if len(segments) == 1 {
+		return s == segments[0]
+	}
+	first, last := segments[0], segments[len(segments)-1]
+	if len(s) < len(first)+len(last) || !strings.HasPrefix(s, first) || !strings.HasSuffix(s, last) {
+		return false
+	}
+	s = s[len(first) : len(s)-len(last)]
+	for _, segment := range segments[1 : len(segments)-1] {
+		i := strings.Index(s, segment)
+		if i == -1 {
+			return false
+		}
+		s = s[i+len(segment):]
+	}
+	return true
+}
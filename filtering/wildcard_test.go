@@ -0,0 +1,63 @@
+package filtering
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestMatchWildcard(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		pattern  string
+		s        string
+		expected bool
+	}{
+		{pattern: "foo", s: "foo", expected: true},
+		{pattern: "foo", s: "foobar", expected: false},
+		{pattern: "foo*", s: "foobar", expected: true},
+		{pattern: "*bar", s: "foobar", expected: true},
+		{pattern: "*.foo", s: "bar.foo", expected: true},
+		{pattern: "*.foo", s: "bar.fo", expected: false},
+		{pattern: "f*o*r", s: "foobar", expected: true},
+		{pattern: "f*x*r", s: "foobar", expected: false},
+		{pattern: "ab*ba", s: "aba", expected: false},
+		{pattern: "*", s: "", expected: true},
+		{pattern: `\*`, s: "*", expected: true},
+		{pattern: `\*`, s: "foo", expected: false},
+		{pattern: `\**`, s: "*foo", expected: true},
+		{pattern: `a\b*`, s: `a\bc`, expected: true},
+		{pattern: `a\\*`, s: `a\bc`, expected: true},
+		{pattern: `a\\*`, s: `abc`, expected: false},
+		{pattern: `a\\\*`, s: `a\*`, expected: true},
+		{pattern: `a\\\*`, s: `a\bc`, expected: false},
+	} {
+		tt := tt
+		t.Run(tt.pattern+" "+tt.s, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, MatchWildcard(tt.pattern, tt.s))
+		})
+	}
+}
+
+func TestIsWildcard(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		s        string
+		expected bool
+	}{
+		{s: "foo", expected: false},
+		{s: "*", expected: true},
+		{s: "*.foo", expected: true},
+		{s: `a\*b`, expected: false},
+		{s: `a\\*b`, expected: true},
+		{s: `a\\\*b`, expected: false},
+		{s: `a\b`, expected: false},
+	} {
+		tt := tt
+		t.Run(tt.s, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, IsWildcard(tt.s))
+		})
+	}
+}
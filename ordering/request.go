@@ -1,5 +1,7 @@
 package ordering
 
+import "go.einride.tech/aip/validation"
+
 // Request is an interface for requests that support ordering.
 //
 // See: https://google.aip.dev/132#ordering (Standard methods: List > Ordering).
@@ -9,10 +11,13 @@ type Request interface {
 }
 
 // ParseOrderBy request parses the ordering field for a Request.
+//
+// Parse errors are returned as a validation.Error with a field violation on the order_by field, which converts to a
+// gRPC status with code INVALID_ARGUMENT.
 func ParseOrderBy(r Request) (OrderBy, error) {
 	var orderBy OrderBy
 	if err := orderBy.UnmarshalString(r.GetOrderBy()); err != nil {
-		return OrderBy{}, err
+		return OrderBy{}, validation.NewFieldError("order_by", err.Error(), err)
 	}
 	return orderBy, nil
 }
@@ -3,6 +3,8 @@ package ordering
 import (
 	"testing"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"gotest.tools/v3/assert"
 )
 
@@ -28,6 +30,9 @@ func TestParseOrderBy(t *testing.T) {
 		actual, err := ParseOrderBy(r)
 		assert.ErrorContains(t, err, "invalid character '/'")
 		assert.DeepEqual(t, OrderBy{}, actual)
+		s := status.Convert(err)
+		assert.Equal(t, codes.InvalidArgument, s.Code())
+		assert.Equal(t, "invalid fields: order_by", s.Message())
 	})
 }
 
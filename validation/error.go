@@ -14,6 +14,7 @@ type Error struct {
 	fieldViolations []*errdetails.BadRequest_FieldViolation
 	grpcStatus      *status.Status
 	str             string
+	err             error
 }
 
 // NewError creates a new validation error from the provided field violations.
@@ -26,6 +27,22 @@ func NewError(fieldViolations []*errdetails.BadRequest_FieldViolation) error {
 	}
 }
 
+// NewFieldError creates a new validation error with a single field violation on the provided field.
+// The provided error is the cause of the violation, and can be retrieved with errors.Unwrap.
+func NewFieldError(field, description string, err error) error {
+	return &Error{
+		fieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: field, Description: description},
+		},
+		err: err,
+	}
+}
+
+// Unwrap returns the cause of the validation error, if any.
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
 // GRPCStatus converts the validation error to a gRPC status with code INVALID_ARGUMENT.
 func (e *Error) GRPCStatus() *status.Status {
 	if e.grpcStatus == nil {
@@ -1,6 +1,7 @@
 package validation
 
 import (
+	"errors"
 	"testing"
 
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
@@ -49,3 +50,14 @@ func TestError_GRPCStatus(t *testing.T) {
 	assert.Assert(t, ok)
 	assert.DeepEqual(t, expected, actual, protocmp.Transform())
 }
+
+func TestError_NewFieldError(t *testing.T) {
+	t.Parallel()
+	cause := errors.New("cause")
+	err := NewFieldError("filter", "1:3: test", cause)
+	assert.Error(t, err, "field violation on filter: 1:3: test")
+	assert.Assert(t, errors.Is(err, cause))
+	s := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, s.Code())
+	assert.Equal(t, "invalid fields: filter", s.Message())
+}